@@ -0,0 +1,109 @@
+// Package audiotags reads song metadata from the tags at the start of audio
+// files: ID3v2 in MP3s and Vorbis comments in FLAC, Ogg Vorbis and Opus.
+// Only the tag region is read, so the audio itself can stay unread.
+package audiotags
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+)
+
+var (
+	// ErrUnsupported means the data is not in a format Read understands.
+	ErrUnsupported = errors.New("unsupported audio format")
+	// ErrNoTags means the file has no tags Read understands.
+	ErrNoTags = errors.New("no tags found")
+)
+
+// maxField bounds a single tag value or comment block held in memory.
+// Larger frames, such as embedded pictures, are skipped.
+const maxField = 16 << 20
+
+type Tags struct {
+	// Format is mp3, flac, ogg or opus.
+	Format string
+	Artist string
+	Title  string
+	// Date is as written in the file, usually yyyy or yyyy-mm-dd.
+	Date   string
+	Lyrics string
+}
+
+func (t Tags) empty() bool {
+	return t.Artist == "" && t.Title == "" && t.Date == "" && t.Lyrics == ""
+}
+
+// fill copies the fields t lacks from other.
+func (t *Tags) fill(other Tags) {
+	for dst, src := range map[*string]string{
+		&t.Artist: other.Artist,
+		&t.Title:  other.Title,
+		&t.Date:   other.Date,
+		&t.Lyrics: other.Lyrics,
+	} {
+		if *dst == "" {
+			*dst = src
+		}
+	}
+}
+
+// Read parses the tags at the start of r.
+func Read(r io.Reader) (Tags, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(4)
+	if err != nil && len(magic) < 3 {
+		return Tags{}, ErrUnsupported
+	}
+
+	var tags Tags
+	switch {
+	case bytes.HasPrefix(magic, []byte("ID3")):
+		if tags, err = readID3(br); err != nil {
+			return Tags{}, err
+		}
+		tags.Format = "mp3"
+		// Some encoders put an ID3 tag in front of FLAC data.
+		if next, _ := br.Peek(4); string(next) == "fLaC" {
+			flac, err := readFLAC(br)
+			if err != nil {
+				return Tags{}, err
+			}
+			flac.fill(tags)
+			tags = flac
+		}
+	case string(magic) == "fLaC":
+		if tags, err = readFLAC(br); err != nil {
+			return Tags{}, err
+		}
+	case string(magic) == "OggS":
+		if tags, err = readOgg(br); err != nil {
+			return Tags{}, err
+		}
+	default:
+		return Tags{}, ErrUnsupported
+	}
+
+	if tags.empty() {
+		return Tags{Format: tags.Format}, ErrNoTags
+	}
+	return tags, nil
+}
+
+// clean trims whitespace and the NUL terminators some taggers leave, and
+// joins NUL-separated values.
+func clean(s string) string {
+	s = strings.Trim(s, "\x00")
+	return strings.TrimSpace(strings.ReplaceAll(s, "\x00", ", "))
+}
+
+// skip discards n bytes of r.
+func skip(r io.Reader, n int64) error {
+	copied, err := io.CopyN(io.Discard, r, n)
+	if err == io.EOF && copied < n {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,242 @@
+package audiotags
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// id3Frame encodes one ID3v2.3 or v2.4 frame.
+func id3Frame(version byte, id string, data []byte, formatFlags byte) []byte {
+	var frame bytes.Buffer
+	frame.WriteString(id)
+	size := uint32(len(data))
+	if version == 4 {
+		size = toSyncsafe(size)
+	}
+	_ = binary.Write(&frame, binary.BigEndian, size)
+	frame.Write([]byte{0, formatFlags})
+	frame.Write(data)
+	return frame.Bytes()
+}
+
+func id3Tag(version, flags byte, frames ...[]byte) []byte {
+	body := bytes.Join(frames, nil)
+	body = append(body, make([]byte, 32)...) // padding
+	var tag bytes.Buffer
+	tag.WriteString("ID3")
+	tag.Write([]byte{version, 0, flags})
+	_ = binary.Write(&tag, binary.BigEndian, toSyncsafe(uint32(len(body))))
+	tag.Write(body)
+	return tag.Bytes()
+}
+
+func toSyncsafe(n uint32) uint32 {
+	return n&0x7f | (n>>7&0x7f)<<8 | (n>>14&0x7f)<<16 | (n>>21&0x7f)<<24
+}
+
+func latin1(s string) []byte {
+	b := []byte{0}
+	for _, r := range s {
+		b = append(b, byte(r))
+	}
+	return b
+}
+
+func utf8Text(s string) []byte {
+	return append([]byte{3}, s...)
+}
+
+func utf16Text(s string) []byte {
+	b := []byte{1, 0xff, 0xfe}
+	for _, u := range utf16.Encode([]rune(s)) {
+		b = binary.LittleEndian.AppendUint16(b, u)
+	}
+	return b
+}
+
+func vorbisComment(comments ...string) []byte {
+	var b []byte
+	b = binary.LittleEndian.AppendUint32(b, 6)
+	b = append(b, "vendor"...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(comments)))
+	for _, c := range comments {
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(c)))
+		b = append(b, c...)
+	}
+	return b
+}
+
+func flacFile(comments ...string) []byte {
+	block := func(last bool, blockType byte, data []byte) []byte {
+		if last {
+			blockType |= 0x80
+		}
+		n := len(data)
+		return append([]byte{blockType, byte(n >> 16), byte(n >> 8), byte(n)}, data...)
+	}
+	file := []byte("fLaC")
+	file = append(file, block(false, 0, make([]byte, 34))...)
+	file = append(file, block(false, 6, bytes.Repeat([]byte{0xaa}, 5000))...)
+	file = append(file, block(true, 4, vorbisComment(comments...))...)
+	return append(file, "audio frames"...)
+}
+
+// oggFile lays packets out in pages of at most pageSegments lacing values,
+// so packets can span pages.
+func oggFile(pageSegments int, packets ...[]byte) []byte {
+	var lacing []byte
+	var data []byte
+	for _, p := range packets {
+		n := len(p)
+		for ; n >= 255; n -= 255 {
+			lacing = append(lacing, 255)
+		}
+		lacing = append(lacing, byte(n))
+		data = append(data, p...)
+	}
+	var file []byte
+	for seq := uint32(0); len(lacing) > 0; seq++ {
+		count := min(pageSegments, len(lacing))
+		size := 0
+		for _, n := range lacing[:count] {
+			size += int(n)
+		}
+		header := make([]byte, 27)
+		copy(header, "OggS")
+		binary.LittleEndian.PutUint32(header[14:], 1)
+		binary.LittleEndian.PutUint32(header[18:], seq)
+		header[26] = byte(count)
+		file = append(file, header...)
+		file = append(file, lacing[:count]...)
+		file = append(file, data[:size]...)
+		lacing, data = lacing[count:], data[size:]
+	}
+	return file
+}
+
+func TestRead(t *testing.T) {
+	lyrics := func(enc func(string) []byte, text string) []byte {
+		descriptor := enc("")
+		b := append([]byte{descriptor[0]}, "eng"...)
+		b = append(b, descriptor[1:]...)
+		if descriptor[0] == 1 {
+			b = append(b, 0, 0)
+		} else {
+			b = append(b, 0)
+		}
+		return append(b, enc(text)[1:]...)
+	}
+
+	for _, tc := range []struct {
+		name string
+		data []byte
+		want Tags
+	}{
+		{
+			name: "ID3v2.3 with UTF-16, year and day",
+			data: append(id3Tag(3, 0,
+				id3Frame(3, "APIC", bytes.Repeat([]byte{0xff}, 4096), 0),
+				id3Frame(3, "TPE1", utf16Text("Sigur Rós"), 0),
+				id3Frame(3, "TIT2", latin1("Hoppípolla"), 0),
+				id3Frame(3, "TYER", latin1("2005"), 0),
+				id3Frame(3, "TDAT", latin1("1209"), 0),
+				id3Frame(3, "USLT", lyrics(utf16Text, "Brosandi\nhendumst í hring"), 0),
+			), 0xff, 0xfb),
+			want: Tags{Format: "mp3", Artist: "Sigur Rós", Title: "Hoppípolla", Date: "2005-09-12", Lyrics: "Brosandi\nhendumst í hring"},
+		},
+		{
+			name: "ID3v2.4 with UTF-8 and unsynchronised frames",
+			data: id3Tag(4, 0,
+				id3Frame(4, "TPE2", utf8Text("Various"), 0),
+				id3Frame(4, "TPE1", utf8Text("Muse\x00Matt Bellamy\x00"), 0),
+				id3Frame(4, "TIT2", append(latin1("Uprising\u00ff"), 0x00), 0x02),
+				id3Frame(4, "TDRC", utf8Text("2009-09-07"), 0),
+				id3Frame(4, "USLT", lyrics(latin1, "Paranoia is in bloom"), 0),
+			),
+			want: Tags{Format: "mp3", Artist: "Muse, Matt Bellamy", Title: "Uprisingÿ", Date: "2009-09-07", Lyrics: "Paranoia is in bloom"},
+		},
+		{
+			name: "ID3v2.4 falls back to the album artist",
+			data: id3Tag(4, 0, id3Frame(4, "TPE2", utf8Text("Various"), 0), id3Frame(4, "TIT2", utf8Text("Song"), 0)),
+			want: Tags{Format: "mp3", Artist: "Various", Title: "Song"},
+		},
+		{
+			name: "ID3v2.2",
+			data: func() []byte {
+				frame := func(id, text string) []byte {
+					data := latin1(text)
+					return append([]byte{id[0], id[1], id[2], 0, 0, byte(len(data))}, data...)
+				}
+				return id3Tag(2, 0, frame("TP1", "Blur"), frame("TT2", "Song 2"), frame("TYE", "1997"))
+			}(),
+			want: Tags{Format: "mp3", Artist: "Blur", Title: "Song 2", Date: "1997"},
+		},
+		{
+			name: "FLAC",
+			data: flacFile("title=Teardrop", "ARTIST=Massive Attack", "DATE=1998", "UNSYNCEDLYRICS=Love, love is a verb", "comment"),
+			want: Tags{Format: "flac", Artist: "Massive Attack", Title: "Teardrop", Date: "1998", Lyrics: "Love, love is a verb"},
+		},
+		{
+			name: "FLAC behind an ID3 tag",
+			data: append(id3Tag(3, 0, id3Frame(3, "TYER", latin1("1998"), 0)), flacFile("TITLE=Angel", "ALBUMARTIST=Massive Attack")...),
+			want: Tags{Format: "flac", Artist: "Massive Attack", Title: "Angel", Date: "1998"},
+		},
+		{
+			name: "Ogg Vorbis with comments across pages",
+			data: oggFile(3,
+				append([]byte("\x01vorbis"), make([]byte, 22)...),
+				append([]byte("\x03vorbis"), vorbisComment("ARTIST=Radiohead", "TITLE=Reckoner", "LYRICS="+strings.Repeat("la ", 400))...),
+				[]byte("\x05vorbis setup"),
+			),
+			want: Tags{Format: "ogg", Artist: "Radiohead", Title: "Reckoner", Lyrics: strings.TrimSpace(strings.Repeat("la ", 400))},
+		},
+		{
+			name: "Opus",
+			data: oggFile(255, append([]byte("OpusHead"), make([]byte, 11)...), append([]byte("OpusTags"), vorbisComment("ARTIST=Björk", "TITLE=Jóga")...)),
+			want: Tags{Format: "opus", Artist: "Björk", Title: "Jóga"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Read(bytes.NewReader(tc.data))
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestRead_Errors(t *testing.T) {
+	_, err := Read(bytes.NewReader([]byte("RIFF\x00\x00\x00\x00WAVEfmt ")))
+	assert.ErrorIs(t, err, ErrUnsupported)
+	_, err = Read(bytes.NewReader(nil))
+	assert.ErrorIs(t, err, ErrUnsupported)
+
+	tags, err := Read(bytes.NewReader(id3Tag(3, 0, id3Frame(3, "APIC", []byte{0, 1, 2}, 0))))
+	assert.ErrorIs(t, err, ErrNoTags)
+	assert.Equal(t, "mp3", tags.Format)
+	_, err = Read(bytes.NewReader(flacFile()))
+	assert.ErrorIs(t, err, ErrNoTags)
+
+	truncated := flacFile("TITLE=Cut")
+	_, err = Read(bytes.NewReader(truncated[:60]))
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNoTags)
+
+	_, err = Read(bytes.NewReader([]byte("ID3\x03\x00\x00\x7f\x7f\x7f\x7f")))
+	assert.Error(t, err, "a tag larger than the file")
+}
+
+func TestUnsyncReader(t *testing.T) {
+	// A whole-tag unsynchronised ID3v2.3 tag.
+	frame := id3Frame(3, "TIT2", latin1("\u00ff\u00e0 sync"), 0)
+	unsynced := bytes.ReplaceAll(frame, []byte{0xff}, []byte{0xff, 0x00})
+	data := id3Tag(3, 0x80, unsynced)
+
+	got, err := Read(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, "ÿà sync", got.Title)
+}
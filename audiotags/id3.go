@@ -0,0 +1,273 @@
+package audiotags
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf16"
+)
+
+// id3Frames maps the frame IDs of ID3v2.2 and later versions to what they
+// hold.
+var id3Frames = map[string]string{
+	"TP1": "artist", "TPE1": "artist",
+	"TP2": "albumArtist", "TPE2": "albumArtist",
+	"TT2": "title", "TIT2": "title",
+	"TYE": "year", "TYER": "year",
+	"TDA": "dayMonth", "TDAT": "dayMonth",
+	"TDRC": "date",
+	"ULT":  "lyrics", "USLT": "lyrics",
+}
+
+// readID3 parses an ID3v2 tag and leaves r positioned after it.
+func readID3(r *bufio.Reader) (Tags, error) {
+	var header [10]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return Tags{}, fmt.Errorf("read ID3 header: %w", err)
+	}
+	version, flags := header[3], header[5]
+	if version < 2 || version > 4 {
+		return Tags{}, fmt.Errorf("unsupported ID3v2.%d tag", version)
+	}
+	size, ok := syncsafe(header[6:10])
+	if !ok {
+		return Tags{}, errors.New("invalid ID3 tag size")
+	}
+
+	limited := &io.LimitedReader{R: r, N: size}
+	values, err := readID3Frames(limited, version, flags)
+	if err != nil {
+		return Tags{}, err
+	}
+	trailer := limited.N
+	if version == 4 && flags&0x10 != 0 {
+		trailer += 10
+	}
+	if err := skip(r, trailer); err != nil {
+		return Tags{}, fmt.Errorf("read ID3 tag: %w", err)
+	}
+
+	tags := Tags{
+		Artist: values["artist"],
+		Title:  values["title"],
+		Date:   values["date"],
+		Lyrics: values["lyrics"],
+	}
+	if tags.Artist == "" {
+		tags.Artist = values["albumArtist"]
+	}
+	if tags.Date == "" && values["year"] != "" {
+		tags.Date = values["year"]
+		if dm := values["dayMonth"]; len(dm) == 4 {
+			tags.Date += "-" + dm[2:] + "-" + dm[:2]
+		}
+	}
+	return tags, nil
+}
+
+func readID3Frames(limited *io.LimitedReader, version, flags byte) (map[string]string, error) {
+	var tag io.Reader = limited
+	if flags&0x80 != 0 && version < 4 {
+		tag = &unsyncReader{r: bufio.NewReader(limited)}
+	}
+	if version == 2 && flags&0x40 != 0 {
+		return nil, errors.New("compressed ID3v2.2 tags are not supported")
+	}
+	if version > 2 && flags&0x40 != 0 {
+		if err := skipExtendedHeader(tag, version); err != nil {
+			return nil, err
+		}
+	}
+
+	values := make(map[string]string)
+	headerSize := 10
+	if version == 2 {
+		headerSize = 6
+	}
+	header := make([]byte, headerSize)
+	for {
+		if _, err := io.ReadFull(tag, header); err != nil {
+			// Whatever follows the last frame is padding or garbage.
+			return values, nil
+		}
+		id, size, formatFlags, ok := parseFrameHeader(header, version)
+		if !ok {
+			return values, nil
+		}
+
+		key, wanted := id3Frames[id]
+		compressed := (version == 3 && formatFlags&0xc0 != 0) || (version == 4 && formatFlags&0x0c != 0)
+		if !wanted || compressed || size > maxField || values[key] != "" {
+			if err := skip(tag, size); err != nil {
+				return nil, fmt.Errorf("read ID3 frame %s: %w", id, err)
+			}
+			continue
+		}
+
+		data := make([]byte, size)
+		if _, err := io.ReadFull(tag, data); err != nil {
+			return nil, fmt.Errorf("read ID3 frame %s: %w", id, err)
+		}
+		switch {
+		case version == 3 && formatFlags&0x20 != 0:
+			data = trimPrefix(data, 1)
+		case version == 4:
+			if formatFlags&0x40 != 0 {
+				data = trimPrefix(data, 1)
+			}
+			if formatFlags&0x01 != 0 {
+				data = trimPrefix(data, 4)
+			}
+			if formatFlags&0x02 != 0 || flags&0x80 != 0 {
+				data = bytes.ReplaceAll(data, []byte{0xff, 0x00}, []byte{0xff})
+			}
+		}
+
+		if key == "lyrics" {
+			values[key] = decodeLyrics(data)
+		} else if len(data) > 0 {
+			values[key] = clean(decodeText(data[0], data[1:]))
+		}
+	}
+}
+
+func skipExtendedHeader(tag io.Reader, version byte) error {
+	var b [4]byte
+	if _, err := io.ReadFull(tag, b[:]); err != nil {
+		return fmt.Errorf("read ID3 extended header: %w", err)
+	}
+	if version == 3 {
+		return skip(tag, int64(binary.BigEndian.Uint32(b[:])))
+	}
+	size, ok := syncsafe(b[:])
+	if !ok || size < 4 {
+		return errors.New("invalid ID3 extended header size")
+	}
+	return skip(tag, size-4)
+}
+
+func parseFrameHeader(header []byte, version byte) (id string, size int64, formatFlags byte, ok bool) {
+	if version == 2 {
+		id = string(header[:3])
+		size = int64(header[3])<<16 | int64(header[4])<<8 | int64(header[5])
+	} else {
+		id = string(header[:4])
+		formatFlags = header[9]
+		if version == 3 {
+			size = int64(binary.BigEndian.Uint32(header[4:8]))
+		} else if size, ok = syncsafe(header[4:8]); !ok {
+			return "", 0, 0, false
+		}
+	}
+	for _, c := range []byte(id) {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return "", 0, 0, false
+		}
+	}
+	return id, size, formatFlags, true
+}
+
+// decodeLyrics decodes an unsynchronised lyrics frame: encoding, language,
+// a terminated content descriptor and the text.
+func decodeLyrics(data []byte) string {
+	if len(data) < 4 {
+		return ""
+	}
+	enc, rest := data[0], data[4:]
+	_, text := splitTerminated(enc, rest)
+	return clean(decodeText(enc, text))
+}
+
+// decodeText decodes ID3 text in the given encoding: ISO-8859-1, UTF-16
+// with a byte order mark, UTF-16BE or UTF-8.
+func decodeText(enc byte, b []byte) string {
+	switch enc {
+	case 0:
+		runes := make([]rune, len(b))
+		for i, c := range b {
+			runes[i] = rune(c)
+		}
+		return string(runes)
+	case 1, 2:
+		bigEndian := enc == 2
+		units := make([]uint16, 0, len(b)/2)
+		for i := 0; i+1 < len(b); i += 2 {
+			u := binary.LittleEndian.Uint16(b[i:])
+			if bigEndian {
+				u = binary.BigEndian.Uint16(b[i:])
+			}
+			switch u {
+			case 0xfeff:
+			case 0xfffe:
+				// A byte order mark read the wrong way round.
+				bigEndian = !bigEndian
+			default:
+				units = append(units, u)
+			}
+		}
+		return string(utf16.Decode(units))
+	}
+	return string(b)
+}
+
+// splitTerminated splits b after the first NUL terminator of the encoding.
+func splitTerminated(enc byte, b []byte) (before, after []byte) {
+	if enc == 1 || enc == 2 {
+		for i := 0; i+1 < len(b); i += 2 {
+			if b[i] == 0 && b[i+1] == 0 {
+				return b[:i], b[i+2:]
+			}
+		}
+		return b, nil
+	}
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		return b[:i], b[i+1:]
+	}
+	return b, nil
+}
+
+func syncsafe(b []byte) (int64, bool) {
+	var n int64
+	for _, c := range b {
+		if c&0x80 != 0 {
+			return 0, false
+		}
+		n = n<<7 | int64(c)
+	}
+	return n, true
+}
+
+func trimPrefix(b []byte, n int) []byte {
+	if len(b) < n {
+		return nil
+	}
+	return b[n:]
+}
+
+// unsyncReader undoes ID3 unsynchronisation, which inserts a zero byte after
+// every 0xFF.
+type unsyncReader struct {
+	r      *bufio.Reader
+	prevFF bool
+}
+
+func (u *unsyncReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		c, err := u.r.ReadByte()
+		if err != nil {
+			return n, err
+		}
+		if u.prevFF && c == 0 {
+			u.prevFF = false
+			continue
+		}
+		u.prevFF = c == 0xff
+		p[n] = c
+		n++
+	}
+	return n, nil
+}
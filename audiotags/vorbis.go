@@ -0,0 +1,210 @@
+package audiotags
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// readFLAC reads the Vorbis comment block among a FLAC stream's metadata
+// blocks.
+func readFLAC(r *bufio.Reader) (Tags, error) {
+	if err := skip(r, 4); err != nil {
+		return Tags{}, err
+	}
+	tags := Tags{Format: "flac"}
+	for {
+		var header [4]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return Tags{}, fmt.Errorf("read FLAC metadata: %w", err)
+		}
+		last, blockType := header[0]&0x80 != 0, header[0]&0x7f
+		size := int64(header[1])<<16 | int64(header[2])<<8 | int64(header[3])
+
+		if blockType == 4 {
+			block := make([]byte, size)
+			if _, err := io.ReadFull(r, block); err != nil {
+				return Tags{}, fmt.Errorf("read FLAC comments: %w", err)
+			}
+			comments, err := parseVorbisComment(block)
+			if err != nil {
+				return Tags{}, err
+			}
+			tags.fill(comments)
+		} else if err := skip(r, size); err != nil {
+			return Tags{}, fmt.Errorf("read FLAC metadata: %w", err)
+		}
+		if last {
+			return tags, nil
+		}
+	}
+}
+
+// readOgg reads the comment header, the second packet of the first logical
+// stream of an Ogg Vorbis or Opus file.
+func readOgg(r *bufio.Reader) (Tags, error) {
+	pages := oggPackets{r: r}
+	id, err := pages.next()
+	if err != nil {
+		return Tags{}, err
+	}
+
+	var format string
+	var prefix []byte
+	switch {
+	case bytes.HasPrefix(id, []byte("\x01vorbis")):
+		format, prefix = "ogg", []byte("\x03vorbis")
+	case bytes.HasPrefix(id, []byte("OpusHead")):
+		format, prefix = "opus", []byte("OpusTags")
+	default:
+		return Tags{}, ErrUnsupported
+	}
+
+	packet, err := pages.next()
+	if err != nil {
+		return Tags{}, err
+	}
+	if !bytes.HasPrefix(packet, prefix) {
+		return Tags{}, errors.New("missing Ogg comment header")
+	}
+	tags, err := parseVorbisComment(packet[len(prefix):])
+	if err != nil {
+		return Tags{}, err
+	}
+	tags.Format = format
+	return tags, nil
+}
+
+// oggPackets reassembles packets from the pages of the first logical
+// stream, ignoring pages of other streams.
+type oggPackets struct {
+	r       *bufio.Reader
+	serial  uint32
+	started bool
+	// segments and data hold the unread lacing values and packet data of
+	// the current page.
+	segments []byte
+	data     []byte
+}
+
+func (p *oggPackets) next() ([]byte, error) {
+	var packet []byte
+	for {
+		for len(p.segments) > 0 {
+			n := int(p.segments[0])
+			p.segments = p.segments[1:]
+			if n > len(p.data) {
+				return nil, errors.New("truncated Ogg page")
+			}
+			packet = append(packet, p.data[:n]...)
+			p.data = p.data[n:]
+			if len(packet) > maxField {
+				return nil, errors.New("Ogg header packet too large")
+			}
+			if n < 255 {
+				return packet, nil
+			}
+		}
+		if err := p.page(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// page reads the next page of the stream.
+func (p *oggPackets) page() error {
+	for {
+		var header [27]byte
+		if _, err := io.ReadFull(p.r, header[:]); err != nil {
+			return fmt.Errorf("read Ogg page: %w", err)
+		}
+		if string(header[:4]) != "OggS" {
+			return errors.New("invalid Ogg page")
+		}
+		serial := binary.LittleEndian.Uint32(header[14:18])
+		segments := make([]byte, header[26])
+		if _, err := io.ReadFull(p.r, segments); err != nil {
+			return fmt.Errorf("read Ogg page: %w", err)
+		}
+		size := 0
+		for _, n := range segments {
+			size += int(n)
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(p.r, data); err != nil {
+			return fmt.Errorf("read Ogg page: %w", err)
+		}
+
+		if !p.started {
+			p.serial, p.started = serial, true
+		}
+		if serial == p.serial {
+			p.segments, p.data = segments, data
+			return nil
+		}
+	}
+}
+
+// parseVorbisComment parses a Vorbis comment structure: a vendor string and
+// a list of KEY=value comments, all length-prefixed little-endian.
+func parseVorbisComment(b []byte) (Tags, error) {
+	errInvalid := errors.New("invalid Vorbis comment")
+	read := func() ([]byte, bool) {
+		if len(b) < 4 {
+			return nil, false
+		}
+		n := binary.LittleEndian.Uint32(b)
+		if uint64(n) > uint64(len(b)-4) {
+			return nil, false
+		}
+		s := b[4 : 4+n]
+		b = b[4+n:]
+		return s, true
+	}
+
+	if _, ok := read(); !ok {
+		return Tags{}, errInvalid
+	}
+	if len(b) < 4 {
+		return Tags{}, errInvalid
+	}
+	count := binary.LittleEndian.Uint32(b)
+	b = b[4:]
+
+	var tags, albumArtist Tags
+	for i := uint32(0); i < count; i++ {
+		comment, ok := read()
+		if !ok {
+			return Tags{}, errInvalid
+		}
+		key, value, ok := strings.Cut(string(comment), "=")
+		if !ok {
+			continue
+		}
+		value = clean(value)
+		var field *string
+		switch strings.ToUpper(key) {
+		case "ARTIST":
+			field = &tags.Artist
+		case "ALBUMARTIST", "ALBUM ARTIST":
+			field = &albumArtist.Artist
+		case "TITLE":
+			field = &tags.Title
+		case "DATE":
+			field = &tags.Date
+		case "LYRICS", "UNSYNCEDLYRICS":
+			field = &tags.Lyrics
+		default:
+			continue
+		}
+		if *field == "" {
+			*field = value
+		}
+	}
+	tags.fill(albumArtist)
+	return tags, nil
+}
@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"awesomeProject/tenant"
+	"fmt"
+	"strings"
+)
+
+type Role string
+
+const (
+	RoleReader Role = "reader"
+	RoleEditor Role = "editor"
+	RoleAdmin  Role = "admin"
+)
+
+type Permission string
+
+const (
+	PermissionRead   Permission = "songs:read"
+	PermissionWrite  Permission = "songs:write"
+	PermissionDelete Permission = "songs:delete"
+	// PermissionAdmin guards destructive maintenance such as purging data.
+	PermissionAdmin Permission = "admin"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleReader: {PermissionRead},
+	RoleEditor: {PermissionRead, PermissionWrite},
+	RoleAdmin:  {PermissionRead, PermissionWrite, PermissionDelete, PermissionAdmin},
+}
+
+func ParseRole(s string) (Role, error) {
+	role := Role(strings.ToLower(strings.TrimSpace(s)))
+	if _, ok := rolePermissions[role]; !ok {
+		return "", fmt.Errorf("unknown role %q", s)
+	}
+	return role, nil
+}
+
+func (r Role) Can(p Permission) bool {
+	for _, granted := range rolePermissions[r] {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
+
+type Principal struct {
+	Subject string
+	Role    Role
+	// Tenant binds the principal to a single library; empty means the
+	// principal may select a tenant per request.
+	Tenant string
+}
+
+func (p Principal) Can(perm Permission) bool {
+	return p.Role.Can(perm)
+}
+
+// KeyStore maps API keys to the principals they identify.
+type KeyStore map[string]Principal
+
+// ParseKeys reads a comma separated list of "subject:role:key[:tenant]"
+// entries, e.g. "alice:admin:s3cr3t,bob:reader:t0ken:acme".
+func ParseKeys(spec string) (KeyStore, error) {
+	keys := KeyStore{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) < 3 || len(parts) > 4 || parts[0] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("invalid API key entry %q, expected subject:role:key[:tenant]", entry)
+		}
+
+		role, err := ParseRole(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := keys[parts[2]]; exists {
+			return nil, fmt.Errorf("duplicate API key for subject %q", parts[0])
+		}
+
+		principal := Principal{Subject: parts[0], Role: role}
+		if len(parts) == 4 {
+			if !tenant.ValidID(parts[3]) {
+				return nil, fmt.Errorf("invalid tenant %q for subject %q", parts[3], parts[0])
+			}
+			principal.Tenant = parts[3]
+		}
+		keys[parts[2]] = principal
+	}
+	return keys, nil
+}
+
+func (k KeyStore) Lookup(key string) (Principal, bool) {
+	p, ok := k[key]
+	return p, ok
+}
@@ -0,0 +1,22 @@
+package auth
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseKeys(t *testing.T) {
+	keys, err := ParseKeys("alice:admin:k1, bob:Reader:k2:acme")
+	assert.NoError(t, err)
+	assert.Equal(t, Principal{Subject: "alice", Role: RoleAdmin}, keys["k1"])
+	assert.Equal(t, Principal{Subject: "bob", Role: RoleReader, Tenant: "acme"}, keys["k2"])
+
+	_, err = ParseKeys("alice:owner:k1")
+	assert.Error(t, err)
+
+	_, err = ParseKeys("alice:admin")
+	assert.Error(t, err)
+
+	_, err = ParseKeys("alice:admin:k1:acme corp")
+	assert.Error(t, err)
+}
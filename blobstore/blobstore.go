@@ -0,0 +1,66 @@
+// Package blobstore stores uploaded files by key, on the local filesystem or
+// in an S3-compatible bucket.
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+// Store keeps opaque blobs by key. Keys are slash-separated relative paths.
+type Store interface {
+	// Put stores size bytes from r under key, replacing any existing blob.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the blob stored under key, or returns ErrNotFound.
+	Get(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	// Delete removes the blob under key. Deleting a missing blob is not an
+	// error.
+	Delete(ctx context.Context, key string) error
+}
+
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+type Config struct {
+	// Driver selects the store: local or s3.
+	Driver string
+	// Path is the root directory of the local store.
+	Path string
+	S3   S3Config
+}
+
+type S3Config struct {
+	// Endpoint is host[:port] of the S3-compatible service, without scheme.
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+}
+
+func Open(cfg Config) (Store, error) {
+	switch cfg.Driver {
+	case DriverLocal:
+		return NewLocal(cfg.Path)
+	case DriverS3:
+		return NewS3(cfg.S3)
+	}
+	return nil, fmt.Errorf("unknown blob store driver %q", cfg.Driver)
+}
+
+// validKey rejects keys that could escape the store's root.
+func validKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) || path.Clean(key) != key || strings.HasPrefix(key, "../") || key == ".." {
+		return fmt.Errorf("invalid blob key %q", key)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package blobstore
+
+import (
+	"context"
+	"encoding/xml"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testStore(t *testing.T, store Store) {
+	ctx := context.Background()
+
+	read := func(t *testing.T, key string) string {
+		blob, err := store.Get(ctx, key)
+		require.NoError(t, err)
+		defer blob.Close()
+		data, err := io.ReadAll(blob)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	t.Run("Put, Get and Delete", func(t *testing.T) {
+		require.NoError(t, store.Put(ctx, "songs/1/cover", strings.NewReader("first"), 5, "image/png"))
+		assert.Equal(t, "first", read(t, "songs/1/cover"))
+
+		require.NoError(t, store.Put(ctx, "songs/1/cover", strings.NewReader("second"), 6, "image/png"))
+		assert.Equal(t, "second", read(t, "songs/1/cover"), "Put replaces")
+
+		blob, err := store.Get(ctx, "songs/1/cover")
+		require.NoError(t, err)
+		_, err = blob.Seek(3, io.SeekStart)
+		require.NoError(t, err)
+		rest, err := io.ReadAll(blob)
+		require.NoError(t, err)
+		assert.Equal(t, "ond", string(rest), "blobs are seekable")
+		require.NoError(t, blob.Close())
+
+		require.NoError(t, store.Delete(ctx, "songs/1/cover"))
+		_, err = store.Get(ctx, "songs/1/cover")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, store.Delete(ctx, "songs/1/cover"))
+	})
+
+	t.Run("rejects unsafe keys", func(t *testing.T) {
+		for _, key := range []string{"", "../escape", "a/../../b", "/abs", `a\b`, "a//b"} {
+			assert.Error(t, store.Put(ctx, key, strings.NewReader("x"), 1, "text/plain"), key)
+		}
+	})
+}
+
+func TestLocal(t *testing.T) {
+	store, err := NewLocal(t.TempDir())
+	require.NoError(t, err)
+	testStore(t, store)
+
+	t.Run("short writes leave nothing behind", func(t *testing.T) {
+		err := store.Put(context.Background(), "short", strings.NewReader("abc"), 10, "text/plain")
+		assert.Error(t, err)
+		_, err = store.Get(context.Background(), "short")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+// fakeS3 implements the object calls the S3 store makes, with path-style
+// bucket addressing.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := r.URL.Path
+	switch r.Method {
+	case http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		f.objects[key] = data
+		w.Header().Set("ETag", `"etag"`)
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodGet, http.MethodHead:
+		data, ok := f.objects[key]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			if r.Method == http.MethodGet {
+				_ = xml.NewEncoder(w).Encode(struct {
+					XMLName xml.Name `xml:"Error"`
+					Code    string
+				}{Code: "NoSuchKey"})
+			}
+			return
+		}
+		w.Header().Set("ETag", `"etag"`)
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(string(data)))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestS3(t *testing.T) {
+	srv := httptest.NewTLSServer(&fakeS3{objects: make(map[string][]byte)})
+	t.Cleanup(srv.Close)
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	client, err := minio.New(u.Host, &minio.Options{
+		Creds:     credentials.NewStaticV4("key", "secret", ""),
+		Secure:    true,
+		Region:    "us-east-1",
+		Transport: srv.Client().Transport,
+	})
+	require.NoError(t, err)
+	testStore(t, &S3{client: client, bucket: "music"})
+}
+
+func TestOpen(t *testing.T) {
+	store, err := Open(Config{Driver: DriverLocal, Path: t.TempDir()})
+	require.NoError(t, err)
+	assert.IsType(t, &Local{}, store)
+
+	_, err = Open(Config{Driver: DriverS3})
+	assert.Error(t, err, "S3 needs an endpoint and bucket")
+	_, err = Open(Config{Driver: "ftp"})
+	assert.Error(t, err)
+}
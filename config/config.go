@@ -0,0 +1,405 @@
+package config
+
+import (
+	"awesomeProject/auth"
+	"awesomeProject/blobstore"
+	"awesomeProject/linkcheck"
+	"awesomeProject/logger"
+	"awesomeProject/middleware"
+	"awesomeProject/ratelimit"
+	"awesomeProject/server"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+type Config struct {
+	Database  DatabaseConfig  `yaml:"database"`
+	HTTP      HTTPConfig      `yaml:"http"`
+	Upstream  UpstreamConfig  `yaml:"upstream"`
+	Log       LogConfig       `yaml:"log"`
+	Auth      AuthConfig      `yaml:"auth"`
+	CORS      CORSConfig      `yaml:"cors"`
+	RateLimit RateLimitConfig `yaml:"rateLimit"`
+	AccessLog AccessLogConfig `yaml:"accessLog"`
+	Tracing   TracingConfig   `yaml:"tracing"`
+	Stats     StatsConfig     `yaml:"stats"`
+	LinkCheck LinkCheckConfig `yaml:"linkCheck"`
+	BlobStore BlobStoreConfig `yaml:"blobStore"`
+}
+
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+	DriverMemory   = "memory"
+)
+
+type DatabaseConfig struct {
+	// Driver selects the storage backend: postgres, sqlite or memory.
+	Driver string `yaml:"driver" env:"DB_DRIVER"`
+	// URL is a Postgres DSN or an SQLite file name; memory ignores it.
+	URL string `yaml:"url" env:"DATABASE_URL" secret:"true"`
+	// ReplicaURLs are optional read replicas used for List and GetByID.
+	ReplicaURLs     []string      `yaml:"replicaUrls" env:"DATABASE_REPLICA_URLS" secret:"true"`
+	MaxOpenConns    int           `yaml:"maxOpenConns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int           `yaml:"maxIdleConns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime time.Duration `yaml:"connMaxLifetime" env:"DB_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime time.Duration `yaml:"connMaxIdleTime" env:"DB_CONN_MAX_IDLE_TIME"`
+}
+
+type HTTPConfig struct {
+	Port              int           `yaml:"port" env:"PORT"`
+	ReadTimeout       time.Duration `yaml:"readTimeout" env:"HTTP_READ_TIMEOUT"`
+	ReadHeaderTimeout time.Duration `yaml:"readHeaderTimeout" env:"HTTP_READ_HEADER_TIMEOUT"`
+	WriteTimeout      time.Duration `yaml:"writeTimeout" env:"HTTP_WRITE_TIMEOUT"`
+	IdleTimeout       time.Duration `yaml:"idleTimeout" env:"HTTP_IDLE_TIMEOUT"`
+	MaxHeaderBytes    int           `yaml:"maxHeaderBytes" env:"HTTP_MAX_HEADER_BYTES"`
+	ShutdownTimeout   time.Duration `yaml:"shutdownTimeout" env:"SHUTDOWN_TIMEOUT"`
+	DrainDelay        time.Duration `yaml:"drainDelay" env:"SHUTDOWN_DRAIN_DELAY"`
+	// TrustedProxies lists the proxy IPs or CIDRs whose X-Forwarded-For
+	// header names the client. None by default.
+	TrustedProxies []string `yaml:"trustedProxies" env:"HTTP_TRUSTED_PROXIES"`
+}
+
+type UpstreamConfig struct {
+	URL            string        `yaml:"url" env:"EXTERNAL_API_URL"`
+	Timeout        time.Duration `yaml:"timeout" env:"EXTERNAL_API_TIMEOUT"`
+	ReadinessCheck bool          `yaml:"readinessCheck" env:"READINESS_CHECK_MUSIC_API"`
+}
+
+type LogConfig struct {
+	Level    string   `yaml:"level" env:"LOG_LEVEL"`
+	Format   string   `yaml:"format" env:"LOG_FORMAT"`
+	Outputs  []string `yaml:"outputs" env:"LOG_OUTPUT"`
+	Sampling bool     `yaml:"sampling" env:"LOG_SAMPLING"`
+}
+
+type AuthConfig struct {
+	APIKeys string `yaml:"apiKeys" env:"API_KEYS" secret:"true"`
+}
+
+type CORSConfig struct {
+	AllowedOrigins   []string `yaml:"allowedOrigins" env:"CORS_ALLOWED_ORIGINS"`
+	AllowedMethods   []string `yaml:"allowedMethods" env:"CORS_ALLOWED_METHODS"`
+	AllowedHeaders   []string `yaml:"allowedHeaders" env:"CORS_ALLOWED_HEADERS"`
+	ExposedHeaders   []string `yaml:"exposedHeaders" env:"CORS_EXPOSED_HEADERS"`
+	AllowCredentials bool     `yaml:"allowCredentials" env:"CORS_ALLOW_CREDENTIALS"`
+	MaxAge           int      `yaml:"maxAge" env:"CORS_MAX_AGE"`
+}
+
+type RateLimitConfig struct {
+	Default string `yaml:"default" env:"RATE_LIMIT_DEFAULT"`
+	// Routes holds "METHOD /route/:template=N/period" entries.
+	Routes []string `yaml:"routes" env:"RATE_LIMIT_ROUTES"`
+}
+
+type AccessLogConfig struct {
+	RedactHeaders []string `yaml:"redactHeaders" env:"ACCESS_LOG_REDACT_HEADERS"`
+}
+
+type TracingConfig struct {
+	Exporter string `yaml:"exporter" env:"OTEL_TRACES_EXPORTER"`
+}
+
+type StatsConfig struct {
+	// CacheTTL is how long library stats are reused; zero disables caching.
+	CacheTTL time.Duration `yaml:"cacheTtl" env:"STATS_CACHE_TTL"`
+}
+
+type LinkCheckConfig struct {
+	Enabled      bool          `yaml:"enabled" env:"LINK_CHECK_ENABLED"`
+	Interval     time.Duration `yaml:"interval" env:"LINK_CHECK_INTERVAL"`
+	RecheckAfter time.Duration `yaml:"recheckAfter" env:"LINK_CHECK_RECHECK_AFTER"`
+	BatchSize    int           `yaml:"batchSize" env:"LINK_CHECK_BATCH_SIZE"`
+	Concurrency  int           `yaml:"concurrency" env:"LINK_CHECK_CONCURRENCY"`
+	HostDelay    time.Duration `yaml:"hostDelay" env:"LINK_CHECK_HOST_DELAY"`
+	Timeout      time.Duration `yaml:"timeout" env:"LINK_CHECK_TIMEOUT"`
+	AllowPrivate bool          `yaml:"allowPrivate" env:"LINK_CHECK_ALLOW_PRIVATE"`
+}
+
+// BlobStoreConfig selects where song attachments are stored.
+type BlobStoreConfig struct {
+	// Driver is local or s3.
+	Driver string `yaml:"driver" env:"BLOB_STORE"`
+	// Path is the root directory of the local store.
+	Path string `yaml:"path" env:"BLOB_STORE_PATH"`
+	// S3Endpoint is host[:port] of an S3-compatible service, without scheme.
+	S3Endpoint  string `yaml:"s3Endpoint" env:"S3_ENDPOINT"`
+	S3Region    string `yaml:"s3Region" env:"S3_REGION"`
+	S3Bucket    string `yaml:"s3Bucket" env:"S3_BUCKET"`
+	S3AccessKey string `yaml:"s3AccessKey" env:"S3_ACCESS_KEY"`
+	S3SecretKey string `yaml:"s3SecretKey" env:"S3_SECRET_KEY" secret:"true"`
+	S3UseSSL    bool   `yaml:"s3UseSsl" env:"S3_USE_SSL"`
+}
+
+func Default() Config {
+	srv := server.DefaultConfig()
+	linkCheck := linkcheck.DefaultConfig()
+	cors := middleware.DefaultCORSConfig()
+	log := logger.DefaultConfig()
+
+	return Config{
+		Database: DatabaseConfig{
+			Driver:          DriverPostgres,
+			MaxOpenConns:    25,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+		},
+		HTTP: HTTPConfig{
+			ReadTimeout:       srv.ReadTimeout,
+			ReadHeaderTimeout: srv.ReadHeaderTimeout,
+			WriteTimeout:      srv.WriteTimeout,
+			IdleTimeout:       srv.IdleTimeout,
+			MaxHeaderBytes:    srv.MaxHeaderBytes,
+			ShutdownTimeout:   srv.ShutdownTimeout,
+			DrainDelay:        srv.DrainDelay,
+		},
+		Upstream: UpstreamConfig{Timeout: 10 * time.Second},
+		Log: LogConfig{
+			Level:    log.Level,
+			Format:   log.Format,
+			Outputs:  log.Outputs,
+			Sampling: log.Sampling,
+		},
+		CORS: CORSConfig{
+			AllowedOrigins:   cors.AllowedOrigins,
+			AllowedMethods:   cors.AllowedMethods,
+			AllowedHeaders:   cors.AllowedHeaders,
+			ExposedHeaders:   cors.ExposedHeaders,
+			AllowCredentials: cors.AllowCredentials,
+			MaxAge:           cors.MaxAge,
+		},
+		RateLimit: RateLimitConfig{
+			Default: "120/m",
+			Routes:  []string{"POST /api/v1/song=20/m"},
+		},
+		AccessLog: AccessLogConfig{RedactHeaders: middleware.DefaultAccessLogConfig().RedactHeaders},
+		Tracing:   TracingConfig{Exporter: "none"},
+		Stats:     StatsConfig{CacheTTL: 30 * time.Second},
+		LinkCheck: LinkCheckConfig{
+			Interval:     linkCheck.Interval,
+			RecheckAfter: linkCheck.RecheckAfter,
+			BatchSize:    linkCheck.BatchSize,
+			Concurrency:  linkCheck.Concurrency,
+			HostDelay:    linkCheck.HostDelay,
+			Timeout:      linkCheck.Timeout,
+		},
+		BlobStore: BlobStoreConfig{
+			Driver:   blobstore.DriverLocal,
+			Path:     "data/attachments",
+			S3Region: "us-east-1",
+			S3UseSSL: true,
+		},
+	}
+}
+
+// Validate checks every setting and reports all problems at once.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	switch c.Database.Driver {
+	case DriverPostgres, DriverSQLite:
+		check(c.Database.URL != "", "DATABASE_URL is required for DB_DRIVER %s", c.Database.Driver)
+	case DriverMemory:
+		check(len(c.Database.ReplicaURLs) == 0, "DATABASE_REPLICA_URLS is not supported with DB_DRIVER memory")
+	default:
+		errs = append(errs, fmt.Errorf("DB_DRIVER %q must be postgres, sqlite or memory", c.Database.Driver))
+	}
+	check(c.Database.MaxOpenConns >= 0, "DB_MAX_OPEN_CONNS must not be negative")
+	check(c.Database.MaxIdleConns >= 0, "DB_MAX_IDLE_CONNS must not be negative")
+	check(c.Database.MaxOpenConns == 0 || c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+		"DB_MAX_IDLE_CONNS (%d) must not exceed DB_MAX_OPEN_CONNS (%d)", c.Database.MaxIdleConns, c.Database.MaxOpenConns)
+	check(c.Database.ConnMaxLifetime >= 0, "DB_CONN_MAX_LIFETIME must not be negative")
+	check(c.Database.ConnMaxIdleTime >= 0, "DB_CONN_MAX_IDLE_TIME must not be negative")
+
+	check(c.HTTP.Port > 0 && c.HTTP.Port <= 65535, "PORT must be between 1 and 65535")
+	for name, d := range map[string]time.Duration{
+		"HTTP_READ_TIMEOUT":        c.HTTP.ReadTimeout,
+		"HTTP_READ_HEADER_TIMEOUT": c.HTTP.ReadHeaderTimeout,
+		"HTTP_WRITE_TIMEOUT":       c.HTTP.WriteTimeout,
+		"HTTP_IDLE_TIMEOUT":        c.HTTP.IdleTimeout,
+		"SHUTDOWN_TIMEOUT":         c.HTTP.ShutdownTimeout,
+	} {
+		check(d > 0, "%s must be positive", name)
+	}
+	check(c.HTTP.DrainDelay >= 0, "SHUTDOWN_DRAIN_DELAY must not be negative")
+	check(c.HTTP.MaxHeaderBytes > 0, "HTTP_MAX_HEADER_BYTES must be positive")
+	for _, proxy := range c.HTTP.TrustedProxies {
+		_, _, cidrErr := net.ParseCIDR(proxy)
+		check(cidrErr == nil || net.ParseIP(proxy) != nil, "HTTP_TRUSTED_PROXIES entry %q must be an IP or CIDR", proxy)
+	}
+
+	if u, err := url.Parse(c.Upstream.URL); c.Upstream.URL == "" {
+		errs = append(errs, errors.New("EXTERNAL_API_URL is required"))
+	} else if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, fmt.Errorf("EXTERNAL_API_URL %q must be an absolute http(s) URL", c.Upstream.URL))
+	}
+	check(c.Upstream.Timeout > 0, "EXTERNAL_API_TIMEOUT must be positive")
+
+	if err := logger.ValidateLevel(c.Log.Level); err != nil {
+		errs = append(errs, fmt.Errorf("LOG_LEVEL: %w", err))
+	}
+	check(c.Log.Format == "json" || c.Log.Format == "console", "LOG_FORMAT must be json or console")
+	check(len(c.Log.Outputs) > 0, "LOG_OUTPUT must name at least one destination")
+
+	if c.Auth.APIKeys == "" {
+		errs = append(errs, errors.New("API_KEYS is required"))
+	} else if _, err := auth.ParseKeys(c.Auth.APIKeys); err != nil {
+		errs = append(errs, fmt.Errorf("API_KEYS: %w", err))
+	}
+
+	check(len(c.CORS.AllowedOrigins) > 0, "CORS_ALLOWED_ORIGINS must not be empty")
+	check(c.CORS.MaxAge >= 0, "CORS_MAX_AGE must not be negative")
+	check(!c.CORS.AllowCredentials || !slices.Contains(c.CORS.AllowedOrigins, "*"),
+		"CORS_ALLOW_CREDENTIALS cannot be combined with CORS_ALLOWED_ORIGINS=*; list the origins")
+
+	if _, err := c.RateLimitPolicy(); err != nil {
+		errs = append(errs, err)
+	}
+
+	switch c.Tracing.Exporter {
+	case "", "none", "otlp", "stdout":
+	default:
+		errs = append(errs, fmt.Errorf("OTEL_TRACES_EXPORTER %q must be otlp, stdout or none", c.Tracing.Exporter))
+	}
+
+	check(c.Stats.CacheTTL >= 0, "STATS_CACHE_TTL must not be negative")
+
+	if c.LinkCheck.Enabled {
+		for name, d := range map[string]time.Duration{
+			"LINK_CHECK_INTERVAL":      c.LinkCheck.Interval,
+			"LINK_CHECK_RECHECK_AFTER": c.LinkCheck.RecheckAfter,
+			"LINK_CHECK_TIMEOUT":       c.LinkCheck.Timeout,
+		} {
+			check(d > 0, "%s must be positive", name)
+		}
+		check(c.LinkCheck.BatchSize > 0, "LINK_CHECK_BATCH_SIZE must be positive")
+		check(c.LinkCheck.Concurrency > 0, "LINK_CHECK_CONCURRENCY must be positive")
+		check(c.LinkCheck.HostDelay >= 0, "LINK_CHECK_HOST_DELAY must not be negative")
+	}
+
+	switch c.BlobStore.Driver {
+	case blobstore.DriverLocal:
+		check(c.BlobStore.Path != "", "BLOB_STORE_PATH is required for BLOB_STORE local")
+	case blobstore.DriverS3:
+		for name, value := range map[string]string{
+			"S3_ENDPOINT":   c.BlobStore.S3Endpoint,
+			"S3_BUCKET":     c.BlobStore.S3Bucket,
+			"S3_ACCESS_KEY": c.BlobStore.S3AccessKey,
+			"S3_SECRET_KEY": c.BlobStore.S3SecretKey,
+		} {
+			check(value != "", "%s is required for BLOB_STORE s3", name)
+		}
+		check(!strings.Contains(c.BlobStore.S3Endpoint, "://"), "S3_ENDPOINT must be host[:port] without a scheme")
+	default:
+		errs = append(errs, fmt.Errorf("BLOB_STORE %q must be local or s3", c.BlobStore.Driver))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c Config) APIKeys() (auth.KeyStore, error) {
+	return auth.ParseKeys(c.Auth.APIKeys)
+}
+
+func (c Config) ServerConfig() server.Config {
+	return server.Config{
+		Addr:              fmt.Sprintf(":%d", c.HTTP.Port),
+		ReadTimeout:       c.HTTP.ReadTimeout,
+		ReadHeaderTimeout: c.HTTP.ReadHeaderTimeout,
+		WriteTimeout:      c.HTTP.WriteTimeout,
+		IdleTimeout:       c.HTTP.IdleTimeout,
+		MaxHeaderBytes:    c.HTTP.MaxHeaderBytes,
+		ShutdownTimeout:   c.HTTP.ShutdownTimeout,
+		DrainDelay:        c.HTTP.DrainDelay,
+	}
+}
+
+func (c Config) LinkCheckerConfig() linkcheck.Config {
+	return linkcheck.Config{
+		Interval:     c.LinkCheck.Interval,
+		RecheckAfter: c.LinkCheck.RecheckAfter,
+		BatchSize:    c.LinkCheck.BatchSize,
+		Concurrency:  c.LinkCheck.Concurrency,
+		HostDelay:    c.LinkCheck.HostDelay,
+		Timeout:      c.LinkCheck.Timeout,
+		AllowPrivate: c.LinkCheck.AllowPrivate,
+	}
+}
+
+func (c Config) BlobStoreConfig() blobstore.Config {
+	return blobstore.Config{
+		Driver: c.BlobStore.Driver,
+		Path:   c.BlobStore.Path,
+		S3: blobstore.S3Config{
+			Endpoint:  c.BlobStore.S3Endpoint,
+			Region:    c.BlobStore.S3Region,
+			Bucket:    c.BlobStore.S3Bucket,
+			AccessKey: c.BlobStore.S3AccessKey,
+			SecretKey: c.BlobStore.S3SecretKey,
+			UseSSL:    c.BlobStore.S3UseSSL,
+		},
+	}
+}
+
+func (c Config) LoggerConfig() logger.Config {
+	return logger.Config{
+		Level:    c.Log.Level,
+		Format:   c.Log.Format,
+		Outputs:  c.Log.Outputs,
+		Sampling: c.Log.Sampling,
+	}
+}
+
+func (c Config) CORSConfig() middleware.CORSConfig {
+	methods := make([]string, len(c.CORS.AllowedMethods))
+	for i, m := range c.CORS.AllowedMethods {
+		methods[i] = strings.ToUpper(m)
+	}
+
+	return middleware.CORSConfig{
+		AllowedOrigins:   c.CORS.AllowedOrigins,
+		AllowedMethods:   methods,
+		AllowedHeaders:   c.CORS.AllowedHeaders,
+		ExposedHeaders:   c.CORS.ExposedHeaders,
+		AllowCredentials: c.CORS.AllowCredentials,
+		MaxAge:           c.CORS.MaxAge,
+	}
+}
+
+func (c Config) AccessLogConfig() middleware.AccessLogConfig {
+	return middleware.AccessLogConfig{RedactHeaders: c.AccessLog.RedactHeaders}
+}
+
+func (c Config) RateLimitPolicy() (middleware.RateLimitPolicy, error) {
+	policy := middleware.RateLimitPolicy{Routes: map[string]ratelimit.Limit{}}
+
+	limit, err := ratelimit.ParseLimit(c.RateLimit.Default)
+	if err != nil {
+		return policy, fmt.Errorf("RATE_LIMIT_DEFAULT: %w", err)
+	}
+	policy.Default = limit
+
+	for _, entry := range c.RateLimit.Routes {
+		route, spec, ok := strings.Cut(entry, "=")
+		if !ok {
+			return policy, fmt.Errorf("RATE_LIMIT_ROUTES: invalid entry %q, expected METHOD /path=N/period", entry)
+		}
+		limit, err := ratelimit.ParseLimit(spec)
+		if err != nil {
+			return policy, fmt.Errorf("RATE_LIMIT_ROUTES: %w", err)
+		}
+		policy.Routes[strings.TrimSpace(route)] = limit
+	}
+	return policy, nil
+}
@@ -0,0 +1,186 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+	"io"
+	"io/fs"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Options struct {
+	// YAMLFile is optional; an empty path skips it.
+	YAMLFile string
+	// EnvFile is optional; a missing file at this path is ignored.
+	EnvFile string
+	// Lookup reads real environment variables, os.LookupEnv by default.
+	// A custom Lookup also keeps .env entries out of the process environment.
+	Lookup func(key string) (string, bool)
+}
+
+// Load builds the configuration with increasing precedence from the
+// defaults, the YAML file, the .env file and the real environment, then
+// validates the result. .env never overrides variables that are already set,
+// so container deployments can rely on the environment alone. Without a
+// custom Lookup, .env entries are also exported to the process environment.
+func Load(opts Options) (Config, error) {
+	cfg := Default()
+
+	if opts.YAMLFile != "" {
+		data, err := os.ReadFile(opts.YAMLFile)
+		if err != nil {
+			return cfg, fmt.Errorf("read config file: %w", err)
+		}
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+			return cfg, fmt.Errorf("parse config file %s: %w", opts.YAMLFile, err)
+		}
+	}
+
+	dotenv := map[string]string{}
+	if opts.EnvFile != "" {
+		values, err := godotenv.Read(opts.EnvFile)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return cfg, fmt.Errorf("read env file: %w", err)
+		}
+		if values != nil {
+			dotenv = values
+		}
+	}
+
+	lookup := opts.Lookup
+	if lookup == nil {
+		lookup = os.LookupEnv
+		// Like godotenv.Load, export what the environment lacks, for
+		// libraries that read it themselves such as the OpenTelemetry SDK.
+		for key, value := range dotenv {
+			if _, ok := os.LookupEnv(key); ok {
+				continue
+			}
+			if err := os.Setenv(key, value); err != nil {
+				return cfg, fmt.Errorf("export %s: %w", key, err)
+			}
+		}
+	}
+
+	var errs []error
+	applyEnv(reflect.ValueOf(&cfg).Elem(), func(key string) (string, bool) {
+		if v, ok := lookup(key); ok {
+			return v, true
+		}
+		v, ok := dotenv[key]
+		return v, ok
+	}, &errs)
+	if len(errs) > 0 {
+		return cfg, errors.Join(errs...)
+	}
+
+	return cfg, cfg.Validate()
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func applyEnv(v reflect.Value, lookup func(string) (string, bool), errs *[]error) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			applyEnv(value, lookup, errs)
+			continue
+		}
+
+		key := field.Tag.Get("env")
+		raw, ok := lookup(key)
+		if key == "" || !ok {
+			continue
+		}
+		if err := setValue(value, strings.TrimSpace(raw)); err != nil {
+			*errs = append(*errs, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+}
+
+func setValue(v reflect.Value, raw string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// Redacted renders the effective configuration as YAML with secrets masked.
+func (c Config) Redacted() string {
+	masked := c
+	redact(reflect.ValueOf(&masked).Elem())
+
+	out, err := yaml.Marshal(masked)
+	if err != nil {
+		return err.Error()
+	}
+	return string(out)
+}
+
+func redact(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			redact(value)
+			continue
+		}
+		if field.Tag.Get("secret") != "true" {
+			continue
+		}
+		switch value.Kind() {
+		case reflect.String:
+			if value.String() != "" {
+				value.SetString("[REDACTED]")
+			}
+		case reflect.Slice:
+			masked := make([]string, value.Len())
+			for i := range masked {
+				masked[i] = "[REDACTED]"
+			}
+			value.Set(reflect.ValueOf(masked))
+		}
+	}
+}
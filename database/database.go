@@ -0,0 +1,80 @@
+package database
+
+import (
+	"awesomeProject/config"
+	"awesomeProject/metrics"
+	"awesomeProject/repositories"
+	"awesomeProject/sqlitedb"
+	"fmt"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// Open connects to the primary, migrates the schema and, when replicas are
+// configured, routes reads to them while writes stay on the primary.
+func Open(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	dialector, err := dialect(cfg.Driver, cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Use(metrics.GormPlugin{}); err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	// Migrate before registering replicas so schema introspection reads the
+	// primary rather than a lagging replica.
+	if err := repositories.Migrate(db); err != nil {
+		return nil, err
+	}
+
+	if len(cfg.ReplicaURLs) == 0 {
+		return db, nil
+	}
+
+	replicas := make([]gorm.Dialector, len(cfg.ReplicaURLs))
+	for i, dsn := range cfg.ReplicaURLs {
+		if replicas[i], err = dialect(cfg.Driver, dsn); err != nil {
+			return nil, err
+		}
+	}
+
+	resolver := dbresolver.Register(dbresolver.Config{
+		Replicas: replicas,
+		Policy:   dbresolver.RandomPolicy{},
+	}).
+		SetMaxOpenConns(cfg.MaxOpenConns).
+		SetMaxIdleConns(cfg.MaxIdleConns).
+		SetConnMaxLifetime(cfg.ConnMaxLifetime).
+		SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	if err := db.Use(resolver); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func dialect(driver, dsn string) (gorm.Dialector, error) {
+	switch driver {
+	case config.DriverPostgres:
+		return postgres.Open(dsn), nil
+	case config.DriverSQLite:
+		return sqlitedb.Open(dsn), nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", driver)
+	}
+}
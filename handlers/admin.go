@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"awesomeProject/logger"
+	"awesomeProject/middleware"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type LogLevelRequest struct {
+	Level string `json:"level" binding:"required"`
+}
+
+type AdminHandler struct{}
+
+func NewAdminHandler() *AdminHandler {
+	return &AdminHandler{}
+}
+
+// @Summary Get log level
+// @Tags admin
+// @Produce json
+// @Success 200 {object} LogLevelRequest
+// @Router /api/v1/admin/log-level [get]
+func (h *AdminHandler) GetLogLevel(c *gin.Context) {
+	c.JSON(200, gin.H{"level": logger.Level()})
+}
+
+// @Summary Change log level at runtime
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body LogLevelRequest true "New level"
+// @Success 200 {object} LogLevelRequest
+// @Router /api/v1/admin/log-level [put]
+func (h *AdminHandler) SetLogLevel(c *gin.Context) {
+	log := middleware.Logger(c)
+
+	var req LogLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Info("Invalid request", zap.Error(err))
+		middleware.RespondError(c, 400, err.Error())
+		return
+	}
+
+	previous := logger.Level()
+	if err := logger.SetLevel(req.Level); err != nil {
+		log.Info("Invalid log level", zap.Error(err))
+		middleware.RespondError(c, 400, err.Error())
+		return
+	}
+
+	log.Warn("Log level changed", zap.String("from", previous), zap.String("to", logger.Level()))
+	c.JSON(200, gin.H{"level": logger.Level()})
+}
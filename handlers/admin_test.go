@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"awesomeProject/logger"
+	"bytes"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAdminHandler_SetLogLevel(t *testing.T) {
+	logger.Init()
+	gin.SetMode(gin.TestMode)
+	defer logger.SetLevel("info")
+
+	handler := NewAdminHandler()
+	r := gin.New()
+	r.GET("/api/v1/admin/log-level", handler.GetLogLevel)
+	r.PUT("/api/v1/admin/log-level", handler.SetLogLevel)
+
+	t.Run("Change level", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("PUT", "/api/v1/admin/log-level", bytes.NewBufferString(`{"level":"debug"}`))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "debug", logger.Level())
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/admin/log-level", nil))
+		assert.JSONEq(t, `{"level":"debug"}`, w.Body.String())
+	})
+
+	t.Run("Unknown level", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("PUT", "/api/v1/admin/log-level", bytes.NewBufferString(`{"level":"loud"}`))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "debug", logger.Level())
+	})
+}
@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"awesomeProject/blobstore"
+	"awesomeProject/middleware"
+	"awesomeProject/models"
+	"awesomeProject/repositories"
+	"awesomeProject/thumbnail"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ThumbnailSize is the longest side of a cover thumbnail in pixels.
+const ThumbnailSize = 300
+
+// attachmentCacheControl lets clients reuse a download for an hour and then
+// revalidate it with its ETag.
+const attachmentCacheControl = "private, max-age=3600"
+
+const maxFileNameLength = 255
+
+// maxUploadSize bounds a whole upload request: the largest attachment plus
+// room for the multipart framing and form fields.
+var maxUploadSize = func() int64 {
+	var size int64
+	for _, kind := range models.AttachmentKinds {
+		size = max(size, kind.MaxSize)
+	}
+	return size + 1<<20
+}()
+
+// AttachmentHandler uploads and serves files stored with songs.
+type AttachmentHandler struct {
+	repo  repositories.AttachmentRepository
+	blobs blobstore.Store
+}
+
+func NewAttachmentHandler(repo repositories.AttachmentRepository, blobs blobstore.Store) *AttachmentHandler {
+	return &AttachmentHandler{repo: repo, blobs: blobs}
+}
+
+func (h *AttachmentHandler) List(c *gin.Context) {
+	songID, ok := songIDParam(c)
+	if !ok {
+		return
+	}
+	attachments, err := h.repo.List(c.Request.Context(), songID)
+	if err != nil {
+		h.respondError(c, err, "Failed to fetch attachments")
+		return
+	}
+	c.JSON(200, gin.H{"items": attachments})
+}
+
+// Upload stores the multipart file field "file" as an attachment of the
+// kind given in the "kind" field. The content type is sniffed from the file.
+func (h *AttachmentHandler) Upload(c *gin.Context) {
+	log := middleware.Logger(c)
+	songID, ok := songIDParam(c)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadSize)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			middleware.RespondError(c, 413, "Upload too large")
+			return
+		}
+		log.Info("Invalid upload", zap.Error(err))
+		middleware.RespondError(c, 400, "Missing file")
+		return
+	}
+	kindName := c.PostForm("kind")
+	kind, ok := models.AttachmentKinds[kindName]
+	if !ok {
+		middleware.RespondError(c, 400, fmt.Sprintf("Unknown attachment kind %q", kindName))
+		return
+	}
+	if header.Size > kind.MaxSize {
+		middleware.RespondError(c, 413, fmt.Sprintf("A %s may be at most %d bytes", kindName, kind.MaxSize))
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		log.Info("Failed to open upload", zap.Error(err))
+		middleware.RespondError(c, 500, "Failed to store attachment")
+		return
+	}
+	defer file.Close()
+
+	contentType, err := sniff(file, kind)
+	if err != nil {
+		var unsupported unsupportedTypeError
+		if errors.As(err, &unsupported) {
+			middleware.RespondError(c, 415, "Unsupported content type "+string(unsupported))
+			return
+		}
+		log.Info("Failed to read upload", zap.Error(err))
+		middleware.RespondError(c, 500, "Failed to store attachment")
+		return
+	}
+
+	var thumb bytes.Buffer
+	if kindName == models.AttachmentCover {
+		if err := thumbnail.Generate(file, &thumb, ThumbnailSize); err != nil {
+			if errors.Is(err, thumbnail.ErrTooLarge) {
+				middleware.RespondError(c, 413, "Image dimensions too large")
+				return
+			}
+			log.Info("Unreadable cover image", zap.Error(err))
+			middleware.RespondError(c, 415, "Unreadable image")
+			return
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			log.Info("Failed to rewind upload", zap.Error(err))
+			middleware.RespondError(c, 500, "Failed to store attachment")
+			return
+		}
+	}
+
+	fileName := header.Filename
+	if len(fileName) > maxFileNameLength {
+		fileName = strings.ToValidUTF8(fileName[:maxFileNameLength], "")
+	}
+	attachment := models.Attachment{
+		SongID:      songID,
+		Kind:        kindName,
+		FileName:    fileName,
+		ContentType: contentType,
+		Size:        header.Size,
+		Key:         fmt.Sprintf("songs/%d/%s", songID, uuid.NewString()),
+		Thumbnail:   thumb.Len() > 0,
+	}
+	ctx := c.Request.Context()
+	hash := sha256.New()
+	if err := h.blobs.Put(ctx, attachment.Key, io.TeeReader(file, hash), attachment.Size, contentType); err != nil {
+		log.Info("Failed to store attachment", zap.Error(err))
+		middleware.RespondError(c, 500, "Failed to store attachment")
+		return
+	}
+	attachment.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	if attachment.Thumbnail {
+		err = h.blobs.Put(ctx, attachment.ThumbnailKey(), &thumb, int64(thumb.Len()), "image/jpeg")
+	}
+	if err == nil {
+		err = h.repo.Create(ctx, &attachment)
+	}
+	if err != nil {
+		h.deleteBlobs(c, attachment)
+		h.respondError(c, err, "Failed to store attachment")
+		return
+	}
+
+	log.Info("Attachment stored",
+		zap.Uint("songId", songID), zap.Uint("attachmentId", attachment.ID), zap.String("kind", kindName))
+	c.JSON(201, attachment)
+}
+
+func (h *AttachmentHandler) Download(c *gin.Context) {
+	attachment, ok := h.load(c)
+	if !ok {
+		return
+	}
+	h.serve(c, attachment.Key, attachment.ContentType, `"`+attachment.SHA256+`"`, attachment)
+}
+
+func (h *AttachmentHandler) Thumbnail(c *gin.Context) {
+	attachment, ok := h.load(c)
+	if !ok {
+		return
+	}
+	if !attachment.Thumbnail {
+		middleware.RespondError(c, 404, "Attachment has no thumbnail")
+		return
+	}
+	h.serve(c, attachment.ThumbnailKey(), "image/jpeg", `"`+attachment.SHA256+`-thumb"`, attachment)
+}
+
+func (h *AttachmentHandler) Delete(c *gin.Context) {
+	attachment, ok := h.load(c)
+	if !ok {
+		return
+	}
+	if err := h.repo.Delete(c.Request.Context(), attachment.SongID, attachment.ID); err != nil {
+		h.respondError(c, err, "Failed to delete attachment")
+		return
+	}
+	h.deleteBlobs(c, *attachment)
+	c.Status(204)
+}
+
+// serve streams a blob with validators so clients can revalidate and
+// request ranges.
+func (h *AttachmentHandler) serve(c *gin.Context, key, contentType, etag string, attachment *models.Attachment) {
+	blob, err := h.blobs.Get(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, blobstore.ErrNotFound) {
+			middleware.Logger(c).Warn("Attachment blob missing", zap.String("key", key))
+			middleware.RespondError(c, 404, "Attachment not found")
+			return
+		}
+		middleware.Logger(c).Info("Failed to read attachment", zap.Error(err))
+		middleware.RespondError(c, 500, "Failed to read attachment")
+		return
+	}
+	defer blob.Close()
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", contentType)
+	header.Set("ETag", etag)
+	header.Set("Cache-Control", attachmentCacheControl)
+	header.Set("X-Content-Type-Options", "nosniff")
+	if attachment.FileName != "" {
+		header.Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": attachment.FileName}))
+	}
+	http.ServeContent(c.Writer, c.Request, "", attachment.CreatedAt, blob)
+}
+
+func (h *AttachmentHandler) load(c *gin.Context) (*models.Attachment, bool) {
+	songID, ok := songIDParam(c)
+	if !ok {
+		return nil, false
+	}
+	id, err := strconv.ParseUint(c.Param("attachmentId"), 10, 0)
+	if err != nil {
+		middleware.RespondError(c, 404, "Attachment not found")
+		return nil, false
+	}
+	attachment, err := h.repo.Get(c.Request.Context(), songID, uint(id))
+	if err != nil {
+		h.respondError(c, err, "Failed to fetch attachment")
+		return nil, false
+	}
+	return attachment, true
+}
+
+// deleteBlobs removes an attachment's blobs, logging failures; an orphaned
+// blob is harmless.
+func (h *AttachmentHandler) deleteBlobs(c *gin.Context, attachment models.Attachment) {
+	ctx := context.WithoutCancel(c.Request.Context())
+	for _, key := range attachment.BlobKeys() {
+		if err := h.blobs.Delete(ctx, key); err != nil {
+			middleware.Logger(c).Warn("Failed to delete attachment blob", zap.String("key", key), zap.Error(err))
+		}
+	}
+}
+
+func (h *AttachmentHandler) respondError(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, repositories.ErrSongNotFound):
+		middleware.RespondError(c, 404, "Song not found")
+	case errors.Is(err, repositories.ErrAttachmentNotFound):
+		middleware.RespondError(c, 404, "Attachment not found")
+	default:
+		middleware.Logger(c).Info(msg, zap.Error(err))
+		middleware.RespondError(c, 500, msg)
+	}
+}
+
+func songIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 0)
+	if err != nil {
+		middleware.RespondError(c, 404, "Song not found")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// sniff detects the file's content type and checks it against the kind,
+// leaving the file rewound.
+func sniff(file multipart.File, kind models.AttachmentKind) (string, error) {
+	detected, err := mimetype.DetectReader(file)
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	for _, allowed := range kind.ContentTypes {
+		if detected.Is(allowed) {
+			return allowed, nil
+		}
+	}
+	return "", unsupportedTypeError(detected.String())
+}
+
+type unsupportedTypeError string
+
+func (e unsupportedTypeError) Error() string {
+	return "unsupported content type " + string(e)
+}
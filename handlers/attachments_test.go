@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"awesomeProject/auth"
+	"awesomeProject/blobstore"
+	"awesomeProject/logger"
+	"awesomeProject/middleware"
+	"awesomeProject/models"
+	"awesomeProject/repositories"
+	"awesomeProject/tenant"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func upload(r *gin.Engine, path, key, kind, fileName string, data []byte) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if kind != "" {
+		_ = form.WriteField("kind", kind)
+	}
+	if data != nil {
+		part, _ := form.CreateFormFile("file", fileName)
+		_, _ = part.Write(data)
+	}
+	_ = form.Close()
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", path, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("X-API-Key", key)
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func doGet(r *gin.Engine, path, key string, headers map[string]string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", path, nil)
+	req.Header.Set("X-API-Key", key)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAttachmentHandler(t *testing.T) {
+	logger.Init()
+	gin.SetMode(gin.TestMode)
+
+	songs := repositories.NewMemorySongRepository()
+	ctx := tenant.NewContext(context.Background(), tenant.Default)
+	song := models.Song{Group: "Muse", Name: "Uprising"}
+	require.NoError(t, songs.Create(ctx, &song))
+
+	blobs, err := blobstore.NewLocal(t.TempDir())
+	require.NoError(t, err)
+	keys := auth.KeyStore{
+		"reader-key": {Subject: "reader", Role: auth.RoleReader},
+		"editor-key": {Subject: "editor", Role: auth.RoleEditor},
+	}
+	r := gin.New()
+	api := r.Group("", middleware.Authenticate(keys), middleware.Tenant())
+	RegisterAttachmentRoutes(api, NewAttachmentHandler(repositories.NewMemoryAttachmentRepository(songs), blobs))
+
+	base := fmt.Sprintf("/api/v1/song/%d/attachments", song.ID)
+	var cover bytes.Buffer
+	require.NoError(t, png.Encode(&cover, image.NewRGBA(image.Rect(0, 0, 600, 400))))
+	pdf := []byte("%PDF-1.7\n1 0 obj\n<<>>\nendobj\ntrailer\n<<>>\n%%EOF\n")
+
+	decode := func(t *testing.T, w *httptest.ResponseRecorder) models.Attachment {
+		var attachment models.Attachment
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &attachment))
+		return attachment
+	}
+
+	t.Run("upload and download a cover", func(t *testing.T) {
+		w := upload(r, base, "editor-key", models.AttachmentCover, "cover.png", cover.Bytes())
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		attachment := decode(t, w)
+		sum := sha256.Sum256(cover.Bytes())
+		assert.Equal(t, "image/png", attachment.ContentType)
+		assert.Equal(t, int64(cover.Len()), attachment.Size)
+		assert.Equal(t, hex.EncodeToString(sum[:]), attachment.SHA256)
+		assert.True(t, attachment.Thumbnail)
+		assert.NotContains(t, w.Body.String(), "songs/", "storage keys are not exposed")
+
+		path := fmt.Sprintf("%s/%d", base, attachment.ID)
+		w = doGet(r, path, "reader-key", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, cover.Bytes(), w.Body.Bytes())
+		assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+		assert.Equal(t, `"`+attachment.SHA256+`"`, w.Header().Get("ETag"))
+		assert.Equal(t, "private, max-age=3600", w.Header().Get("Cache-Control"))
+		assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+		assert.Equal(t, `inline; filename=cover.png`, w.Header().Get("Content-Disposition"))
+
+		w = doGet(r, path, "reader-key", map[string]string{"If-None-Match": `"` + attachment.SHA256 + `"`})
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		w = doGet(r, path, "reader-key", map[string]string{"Range": "bytes=0-3"})
+		assert.Equal(t, http.StatusPartialContent, w.Code)
+		assert.Equal(t, cover.Bytes()[:4], w.Body.Bytes())
+
+		w = doGet(r, path+"/thumbnail", "reader-key", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "image/jpeg", w.Header().Get("Content-Type"))
+		thumb, err := jpeg.Decode(w.Body)
+		require.NoError(t, err)
+		assert.Equal(t, image.Pt(300, 200), thumb.Bounds().Size())
+
+		w = doGet(r, base, "reader-key", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var list struct{ Items []models.Attachment }
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		require.Len(t, list.Items, 1)
+		assert.Equal(t, attachment.ID, list.Items[0].ID)
+	})
+
+	t.Run("sheet music has no thumbnail", func(t *testing.T) {
+		w := upload(r, base, "editor-key", models.AttachmentSheetMusic, "score.pdf", pdf)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		attachment := decode(t, w)
+		assert.Equal(t, "application/pdf", attachment.ContentType)
+		assert.False(t, attachment.Thumbnail)
+
+		w = doGet(r, fmt.Sprintf("%s/%d/thumbnail", base, attachment.ID), "reader-key", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("validates uploads", func(t *testing.T) {
+		for _, tc := range []struct {
+			name string
+			kind string
+			data []byte
+			want int
+		}{
+			{"content type is sniffed", models.AttachmentCover, pdf, http.StatusUnsupportedMediaType},
+			{"preview must be audio", models.AttachmentPreview, cover.Bytes(), http.StatusUnsupportedMediaType},
+			{"unknown kind", "lyrics", pdf, http.StatusBadRequest},
+			{"missing file", models.AttachmentCover, nil, http.StatusBadRequest},
+			{"too large for the kind", models.AttachmentCover, make([]byte, models.AttachmentKinds[models.AttachmentCover].MaxSize+1), http.StatusRequestEntityTooLarge},
+			{"too large a request", models.AttachmentPreview, make([]byte, maxUploadSize), http.StatusRequestEntityTooLarge},
+			{"broken image", models.AttachmentCover, cover.Bytes()[:64], http.StatusUnsupportedMediaType},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				w := upload(r, base, "editor-key", tc.kind, "file", tc.data)
+				assert.Equal(t, tc.want, w.Code, w.Body.String())
+			})
+		}
+	})
+
+	t.Run("permissions and missing songs", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, upload(r, base, "reader-key", models.AttachmentSheetMusic, "score.pdf", pdf).Code)
+		assert.Equal(t, http.StatusNotFound, upload(r, "/api/v1/song/999/attachments", "editor-key", models.AttachmentSheetMusic, "score.pdf", pdf).Code)
+		assert.Equal(t, http.StatusNotFound, doGet(r, "/api/v1/song/999/attachments", "reader-key", nil).Code)
+		assert.Equal(t, http.StatusNotFound, doGet(r, base+"/999", "reader-key", nil).Code)
+	})
+
+	t.Run("delete removes the blobs", func(t *testing.T) {
+		w := upload(r, base, "editor-key", models.AttachmentCover, "cover.png", cover.Bytes())
+		require.Equal(t, http.StatusCreated, w.Code)
+		attachment := decode(t, w)
+		stored, err := repositories.NewMemoryAttachmentRepository(songs).Get(ctx, song.ID, attachment.ID)
+		require.NoError(t, err)
+
+		path := fmt.Sprintf("%s/%d", base, attachment.ID)
+		assert.Equal(t, http.StatusForbidden, doJSON(r, "DELETE", path, "reader-key", nil).Code)
+		assert.Equal(t, http.StatusNoContent, doJSON(r, "DELETE", path, "editor-key", nil).Code)
+		assert.Equal(t, http.StatusNotFound, doGet(r, path, "reader-key", nil).Code)
+		for _, key := range stored.BlobKeys() {
+			_, err := blobs.Get(ctx, key)
+			assert.ErrorIs(t, err, blobstore.ErrNotFound)
+		}
+	})
+}
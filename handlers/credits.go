@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"awesomeProject/middleware"
+	"awesomeProject/models"
+	"awesomeProject/repositories"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"strconv"
+)
+
+type CreditHandler struct {
+	repo repositories.CreditRepository
+}
+
+func NewCreditHandler(repo repositories.CreditRepository) *CreditHandler {
+	return &CreditHandler{repo: repo}
+}
+
+func (h *CreditHandler) People(c *gin.Context) {
+	role := c.Query("role")
+	if role != "" && !models.ValidCreditRole(role) {
+		middleware.RespondError(c, 400, "Unknown role")
+		return
+	}
+
+	people, err := h.repo.People(c.Request.Context(), role)
+	if err != nil {
+		middleware.Logger(c).Info("Failed to fetch people", zap.Error(err))
+		middleware.RespondError(c, 500, "Failed to fetch people")
+		return
+	}
+	c.JSON(200, gin.H{"items": people})
+}
+
+func (h *CreditHandler) SetCredits(c *gin.Context) {
+	log := middleware.Logger(c)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 0)
+	if err != nil {
+		middleware.RespondError(c, 404, "Song not found")
+		return
+	}
+
+	var req models.SetCreditsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Info("Invalid request", zap.Error(err))
+		middleware.RespondError(c, 400, err.Error())
+		return
+	}
+
+	if err := h.repo.SetCredits(c.Request.Context(), uint(id), req.Credits); err != nil {
+		if errors.Is(err, repositories.ErrSongNotFound) {
+			middleware.RespondError(c, 404, "Song not found")
+			return
+		}
+		log.Info("Failed to set credits", zap.Error(err))
+		middleware.RespondError(c, 500, "Failed to set credits")
+		return
+	}
+	c.Status(204)
+}
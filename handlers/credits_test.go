@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"awesomeProject/auth"
+	"awesomeProject/logger"
+	"awesomeProject/middleware"
+	"awesomeProject/models"
+	"awesomeProject/repositories"
+	"awesomeProject/tenant"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func TestCreditHandler(t *testing.T) {
+	logger.Init()
+	gin.SetMode(gin.TestMode)
+
+	songs := repositories.NewMemorySongRepository()
+	song := models.Song{Group: "Queen", Name: "Under Pressure"}
+	require.NoError(t, songs.Create(tenant.NewContext(context.Background(), tenant.Default), &song))
+
+	keys := auth.KeyStore{
+		"reader-key": {Subject: "reader", Role: auth.RoleReader},
+		"editor-key": {Subject: "editor", Role: auth.RoleEditor},
+	}
+	r := gin.New()
+	api := r.Group("", middleware.Authenticate(keys), middleware.Tenant())
+	RegisterCreditRoutes(api, NewCreditHandler(repositories.NewMemoryCreditRepository(songs)))
+
+	path := fmt.Sprintf("/api/v1/song/%d/credits", song.ID)
+	credits := models.SetCreditsRequest{Credits: []models.SongCredit{
+		{Person: "Queen", Role: models.CreditRolePerformer},
+		{Person: "David Bowie", Role: models.CreditRoleFeatured},
+	}}
+
+	w := doJSON(r, "PUT", path, "reader-key", credits)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = doJSON(r, "PUT", path, "editor-key", credits)
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+
+	w = doJSON(r, "PUT", path, "editor-key", models.SetCreditsRequest{Credits: []models.SongCredit{{Person: "Queen", Role: "drummer"}}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doJSON(r, "PUT", "/api/v1/song/999/credits", "editor-key", credits)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = doJSON(r, "GET", "/api/v1/people?role=featured", "reader-key", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var response struct{ Items []models.PersonCredits }
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, []models.PersonCredits{{Name: "David Bowie", Songs: 1}}, response.Items)
+
+	w = doJSON(r, "GET", "/api/v1/people?role=drummer", "reader-key", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"awesomeProject/middleware"
+	"awesomeProject/models"
+	"awesomeProject/repositories"
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"strconv"
+)
+
+// EngagementHandler records what the calling principal does with songs.
+type EngagementHandler struct {
+	repo repositories.EngagementRepository
+}
+
+func NewEngagementHandler(repo repositories.EngagementRepository) *EngagementHandler {
+	return &EngagementHandler{repo: repo}
+}
+
+func (h *EngagementHandler) Favorite(c *gin.Context) {
+	h.songAction(c, h.repo.Favorite, "Failed to favorite song")
+}
+
+func (h *EngagementHandler) Unfavorite(c *gin.Context) {
+	h.songAction(c, h.repo.Unfavorite, "Failed to unfavorite song")
+}
+
+func (h *EngagementHandler) Rate(c *gin.Context) {
+	var req models.RateSongRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.Logger(c).Info("Invalid request", zap.Error(err))
+		middleware.RespondError(c, 400, err.Error())
+		return
+	}
+
+	h.songAction(c, func(ctx context.Context, userID string, songID uint) error {
+		return h.repo.Rate(ctx, userID, songID, req.Stars)
+	}, "Failed to rate song")
+}
+
+func (h *EngagementHandler) Unrate(c *gin.Context) {
+	h.songAction(c, h.repo.Unrate, "Failed to remove rating")
+}
+
+func (h *EngagementHandler) RecordPlay(c *gin.Context) {
+	h.songAction(c, h.repo.RecordPlay, "Failed to record play")
+}
+
+func (h *EngagementHandler) Favorites(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	principal, _ := middleware.CurrentPrincipal(c)
+	songs, total, err := h.repo.Favorites(c.Request.Context(), principal.Subject, page, limit)
+	if err != nil {
+		middleware.Logger(c).Info("Failed to fetch favorites", zap.Error(err))
+		middleware.RespondError(c, 500, "Failed to fetch favorites")
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"total": total,
+		"items": songs,
+	})
+}
+
+func (h *EngagementHandler) TopPlayed(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	principal, _ := middleware.CurrentPrincipal(c)
+	played, err := h.repo.TopPlayed(c.Request.Context(), principal.Subject, limit)
+	if err != nil {
+		middleware.Logger(c).Info("Failed to fetch top played songs", zap.Error(err))
+		middleware.RespondError(c, 500, "Failed to fetch top played songs")
+		return
+	}
+	c.JSON(200, gin.H{"items": played})
+}
+
+func (h *EngagementHandler) songAction(c *gin.Context, action func(ctx context.Context, userID string, songID uint) error, msg string) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 0)
+	if err != nil {
+		middleware.RespondError(c, 404, "Song not found")
+		return
+	}
+
+	principal, _ := middleware.CurrentPrincipal(c)
+	if err := action(c.Request.Context(), principal.Subject, uint(id)); err != nil {
+		if errors.Is(err, repositories.ErrSongNotFound) {
+			middleware.RespondError(c, 404, "Song not found")
+			return
+		}
+		middleware.Logger(c).Info(msg, zap.Error(err))
+		middleware.RespondError(c, 500, msg)
+		return
+	}
+	c.Status(204)
+}
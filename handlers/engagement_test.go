@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"awesomeProject/auth"
+	"awesomeProject/logger"
+	"awesomeProject/middleware"
+	"awesomeProject/models"
+	"awesomeProject/repositories"
+	"awesomeProject/tenant"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func TestEngagementHandler(t *testing.T) {
+	logger.Init()
+	gin.SetMode(gin.TestMode)
+
+	songs := repositories.NewMemorySongRepository()
+	ctx := tenant.NewContext(context.Background(), tenant.Default)
+	seeded := []models.Song{{Group: "Muse", Name: "Uprising"}, {Group: "Muse", Name: "Starlight"}}
+	for i := range seeded {
+		require.NoError(t, songs.Create(ctx, &seeded[i]))
+	}
+
+	keys := auth.KeyStore{
+		"alice-key": {Subject: "alice", Role: auth.RoleReader},
+		"bob-key":   {Subject: "bob", Role: auth.RoleReader},
+	}
+	r := gin.New()
+	api := r.Group("", middleware.Authenticate(keys), middleware.Tenant())
+	RegisterEngagementRoutes(api, NewEngagementHandler(repositories.NewMemoryEngagementRepository(songs)))
+
+	first := fmt.Sprintf("/api/v1/song/%d", seeded[0].ID)
+	second := fmt.Sprintf("/api/v1/song/%d", seeded[1].ID)
+
+	t.Run("favorites are per user", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, doJSON(r, "PUT", first+"/favorite", "alice-key", nil).Code)
+		assert.Equal(t, http.StatusNotFound, doJSON(r, "PUT", "/api/v1/song/999/favorite", "alice-key", nil).Code)
+
+		w := doJSON(r, "GET", "/api/v1/me/favorites", "alice-key", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Total int64
+			Items []models.Song
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, int64(1), response.Total)
+		require.Len(t, response.Items, 1)
+		assert.Equal(t, int64(1), response.Items[0].Stats.Favorites)
+
+		w = doJSON(r, "GET", "/api/v1/me/favorites", "bob-key", nil)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Zero(t, response.Total)
+	})
+
+	t.Run("ratings are validated", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, doJSON(r, "PUT", first+"/rating", "alice-key", models.RateSongRequest{Stars: 6}).Code)
+		assert.Equal(t, http.StatusBadRequest, doJSON(r, "PUT", first+"/rating", "alice-key", gin.H{}).Code)
+		assert.Equal(t, http.StatusNoContent, doJSON(r, "PUT", first+"/rating", "alice-key", models.RateSongRequest{Stars: 4}).Code)
+		assert.Equal(t, http.StatusNoContent, doJSON(r, "PUT", first+"/rating", "bob-key", models.RateSongRequest{Stars: 5}).Code)
+
+		song, err := songs.GetByID(ctx, fmt.Sprint(seeded[0].ID))
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), song.Stats.Ratings)
+		assert.InDelta(t, 4.5, song.Stats.AverageRating, 0.001)
+	})
+
+	t.Run("top played", func(t *testing.T) {
+		for _, path := range []string{second, first, second} {
+			assert.Equal(t, http.StatusNoContent, doJSON(r, "POST", path+"/plays", "alice-key", nil).Code)
+		}
+
+		w := doJSON(r, "GET", "/api/v1/me/top-played?limit=1", "alice-key", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var response struct{ Items []models.PlayedSong }
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Items, 1)
+		assert.Equal(t, "Starlight", response.Items[0].Song.Name)
+		assert.Equal(t, int64(2), response.Items[0].Plays)
+	})
+}
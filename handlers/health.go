@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"awesomeProject/health"
+	"awesomeProject/middleware"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{checker: checker}
+}
+
+// @Summary Liveness probe
+// @Tags health
+// @Produce json
+// @Success 200
+// @Router /healthz [get]
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(200, gin.H{"status": health.StatusOK})
+}
+
+// @Summary Readiness probe
+// @Tags health
+// @Produce json
+// @Success 200
+// @Failure 503
+// @Router /readyz [get]
+func (h *HealthHandler) Ready(c *gin.Context) {
+	report := h.checker.Run(c.Request.Context())
+	logFailures(c, report)
+	if !report.Ready() {
+		c.JSON(503, gin.H{"status": report.Status, "shuttingDown": report.ShuttingDown})
+		return
+	}
+	c.JSON(200, gin.H{"status": report.Status})
+}
+
+// @Summary Detailed health report
+// @Tags health
+// @Produce json
+// @Success 200 {object} health.Report
+// @Failure 503 {object} health.Report
+// @Router /health [get]
+func (h *HealthHandler) Report(c *gin.Context) {
+	report := h.checker.Run(c.Request.Context())
+	logFailures(c, report)
+	status := 200
+	if !report.Ready() {
+		status = 503
+	}
+	c.JSON(status, report)
+}
+
+// logFailures logs why checks failed; the responses only say that they did.
+func logFailures(c *gin.Context, report health.Report) {
+	for name, result := range report.Checks {
+		if result.Status != health.StatusUp {
+			middleware.Logger(c).Warn("Health check failed", zap.String("check", name), zap.String("error", result.Error))
+		}
+	}
+}
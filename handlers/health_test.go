@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"awesomeProject/health"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func setupHealthTest(db, api error) (*health.Checker, *gin.Engine) {
+	gin.SetMode(gin.TestMode)
+
+	checker := health.NewChecker(50*time.Millisecond,
+		health.Check{Name: "database", Critical: true, Run: func(ctx context.Context) error { return db }},
+		health.Check{Name: "musicApi", Run: func(ctx context.Context) error { return api }},
+	)
+
+	r := gin.New()
+	RegisterHealthRoutes(r, NewHealthHandler(checker))
+	return checker, r
+}
+
+func get(r *gin.Engine, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+	return w
+}
+
+func TestHealthHandler(t *testing.T) {
+	t.Run("All dependencies up", func(t *testing.T) {
+		_, r := setupHealthTest(nil, nil)
+
+		assert.Equal(t, http.StatusOK, get(r, "/healthz").Code)
+		assert.Equal(t, http.StatusOK, get(r, "/readyz").Code)
+
+		w := get(r, "/health")
+		assert.Equal(t, http.StatusOK, w.Code)
+		var report health.Report
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+		assert.Equal(t, health.StatusOK, report.Status)
+		assert.Equal(t, health.StatusUp, report.Checks["database"].Status)
+		assert.Equal(t, health.StatusUp, report.Checks["musicApi"].Status)
+	})
+
+	t.Run("Optional dependency down degrades but stays ready", func(t *testing.T) {
+		_, r := setupHealthTest(nil, errors.New("connection refused"))
+
+		assert.Equal(t, http.StatusOK, get(r, "/readyz").Code)
+
+		w := get(r, "/health")
+		var report health.Report
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+		assert.Equal(t, health.StatusDegraded, report.Status)
+		assert.Equal(t, health.StatusDown, report.Checks["musicApi"].Status)
+		assert.NotContains(t, w.Body.String(), "connection refused", "errors are logged, not served")
+	})
+
+	t.Run("Database down fails readiness but not liveness", func(t *testing.T) {
+		_, r := setupHealthTest(errors.New("ping failed"), nil)
+
+		assert.Equal(t, http.StatusOK, get(r, "/healthz").Code)
+		assert.Equal(t, http.StatusServiceUnavailable, get(r, "/readyz").Code)
+		assert.Equal(t, http.StatusServiceUnavailable, get(r, "/health").Code)
+	})
+
+	t.Run("Readiness fails during shutdown", func(t *testing.T) {
+		checker, r := setupHealthTest(nil, nil)
+		checker.SetShuttingDown()
+
+		assert.Equal(t, http.StatusOK, get(r, "/healthz").Code)
+		w := get(r, "/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.JSONEq(t, `{"status":"down","shuttingDown":true}`, w.Body.String())
+	})
+
+	t.Run("Slow checks time out", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		checker := health.NewChecker(10*time.Millisecond, health.Check{Name: "database", Critical: true,
+			Run: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			}})
+		r := gin.New()
+		RegisterHealthRoutes(r, NewHealthHandler(checker))
+
+		assert.Equal(t, http.StatusServiceUnavailable, get(r, "/readyz").Code)
+	})
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"awesomeProject/audiotags"
+	"awesomeProject/logger"
+	"awesomeProject/middleware"
+	"awesomeProject/models"
+	"awesomeProject/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Limits of one ingest request. Only the tags at the start of each file are
+// read; the rest of the upload is discarded as it streams in.
+const (
+	MaxIngestFiles = 100
+	maxIngestSize  = 1 << 30
+)
+
+// Ingest creates a song from the tags of every audio file uploaded in the
+// multipart field "files", the same way Create does, and reports what
+// happened to each file.
+func (h *SongHandler) Ingest(c *gin.Context) {
+	log := middleware.Logger(c)
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxIngestSize)
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		middleware.RespondError(c, 400, "Expected a multipart/form-data upload")
+		return
+	}
+
+	report := models.IngestReport{Items: []models.IngestResult{}}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				middleware.RespondError(c, 413, "Upload too large")
+				return
+			}
+			log.Info("Invalid upload", zap.Error(err))
+			middleware.RespondError(c, 400, "Invalid multipart upload")
+			return
+		}
+		if part.FormName() != "files" {
+			part.Close()
+			continue
+		}
+
+		if len(report.Items) >= MaxIngestFiles {
+			report.Add(models.IngestResult{
+				File:   part.FileName(),
+				Status: models.IngestSkipped,
+				Reason: fmt.Sprintf("more than %d files in one request", MaxIngestFiles),
+			})
+		} else {
+			report.Add(h.ingestFile(c.Request.Context(), part.FileName(), part))
+		}
+		part.Close()
+	}
+
+	log.Info("Ingest finished",
+		zap.Int("created", report.Created), zap.Int("skipped", report.Skipped), zap.Int("failed", report.Failed))
+	c.JSON(200, report)
+}
+
+func (h *SongHandler) ingestFile(ctx context.Context, name string, r io.Reader) models.IngestResult {
+	result := models.IngestResult{File: name, Status: models.IngestSkipped}
+
+	tags, err := audiotags.Read(r)
+	switch {
+	case errors.Is(err, audiotags.ErrUnsupported):
+		result.Reason = "not an MP3, FLAC, Ogg Vorbis or Opus file"
+	case errors.Is(err, audiotags.ErrNoTags):
+		result.Reason = "no tags found"
+	case err != nil:
+		result.Reason = "unreadable tags: " + err.Error()
+	case tags.Artist == "":
+		result.Reason = "no artist tag"
+	case tags.Title == "":
+		result.Reason = "no title tag"
+	}
+	if result.Reason != "" {
+		return result
+	}
+
+	// Read from the primary so songs created earlier in the same upload
+	// count as duplicates despite replication lag.
+	existing, err := h.findSong(repositories.ReadFromPrimary(ctx), tags.Artist, tags.Title)
+	if err != nil {
+		logger.FromContext(ctx).Info("Failed to look up existing songs", zap.Error(err))
+		return models.IngestResult{File: name, Status: models.IngestFailed, Reason: "failed to look up existing songs"}
+	}
+	if existing != nil {
+		result.Reason = fmt.Sprintf("already in the library as song %d", existing.ID)
+		return result
+	}
+
+	req := models.CreateSongRequest{Group: tags.Artist, Song: tags.Title}
+	song, err := h.create(ctx, req, models.SongDetail{ReleaseDate: releaseDate(tags.Date), Text: tags.Lyrics})
+	if err != nil {
+		reason := "failed to create song"
+		if errors.Is(err, errSongDetails) {
+			reason = "failed to fetch song details"
+		}
+		return models.IngestResult{File: name, Status: models.IngestFailed, Reason: reason}
+	}
+	return models.IngestResult{File: name, Status: models.IngestCreated, Song: song}
+}
+
+// findSong returns the song with exactly this group and name, ignoring
+// case, or nil.
+func (h *SongHandler) findSong(ctx context.Context, group, name string) (*models.Song, error) {
+	filters := map[string]string{repositories.FilterGroup: group, repositories.FilterSong: name}
+	for page := 1; ; page++ {
+		songs, total, err := h.songRepo.List(ctx, page, 100, filters)
+		if err != nil {
+			return nil, err
+		}
+		for _, song := range songs {
+			if strings.EqualFold(song.Group, group) && strings.EqualFold(song.Name, name) {
+				return &song, nil
+			}
+		}
+		if len(songs) == 0 || int64(page*100) >= total {
+			return nil, nil
+		}
+	}
+}
+
+var isoDate = regexp.MustCompile(`^(\d{4})(?:-(\d{2})(?:-(\d{2}))?)?`)
+
+// releaseDate converts a tag date such as 2006-07-16 or 2006 to the
+// library's day-first form, 16.07.2006 or 2006. Other values are kept.
+func releaseDate(date string) string {
+	m := isoDate.FindStringSubmatch(date)
+	switch {
+	case m == nil:
+		return date
+	case m[3] != "":
+		return m[3] + "." + m[2] + "." + m[1]
+	case m[2] != "":
+		return m[2] + "." + m[1]
+	}
+	return m[1]
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"awesomeProject/auth"
+	"awesomeProject/logger"
+	"awesomeProject/middleware"
+	"awesomeProject/models"
+	"awesomeProject/repositories"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// mp3File builds an ID3v2.3 tag of UTF-8-compatible Latin-1 text frames
+// followed by a little fake audio.
+func mp3File(frames map[string]string) []byte {
+	var body []byte
+	for id, text := range frames {
+		data := append([]byte{0}, text...)
+		if id == "USLT" {
+			data = append([]byte{0, 'e', 'n', 'g', 0}, text...)
+		}
+		body = append(body, id...)
+		body = binary.BigEndian.AppendUint32(body, uint32(len(data)))
+		body = append(body, 0, 0)
+		body = append(body, data...)
+	}
+	n := len(body)
+	tag := []byte{'I', 'D', '3', 3, 0, 0, byte(n >> 21 & 0x7f), byte(n >> 14 & 0x7f), byte(n >> 7 & 0x7f), byte(n & 0x7f)}
+	return append(append(tag, body...), bytes.Repeat([]byte{0xff, 0xfb, 0x90, 0x00}, 1000)...)
+}
+
+func ingest(r *gin.Engine, key string, files map[string][]byte, order ...string) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for _, name := range order {
+		part, _ := form.CreateFormFile("files", name)
+		_, _ = part.Write(files[name])
+	}
+	_ = form.Close()
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/api/v1/song/ingest", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("X-API-Key", key)
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestSongHandler_Ingest(t *testing.T) {
+	logger.Init()
+	gin.SetMode(gin.TestMode)
+
+	songs := repositories.NewMemorySongRepository()
+	api := new(MockMusicAPIService)
+	api.On("GetSongInfo", "Muse", "Uprising").Return(&models.SongDetail{ReleaseDate: "01.01.2000", Text: "From the API", Link: "https://youtu.be/w8KQmps-Sog"}, nil)
+	api.On("GetSongInfo", "Blur", "Song 2").Return(&models.SongDetail{Text: "Woo-hoo"}, nil)
+	api.On("GetSongInfo", "Unknown", "Song").Return(nil, errors.New("upstream down"))
+
+	keys := auth.KeyStore{
+		"reader-key": {Subject: "reader", Role: auth.RoleReader},
+		"editor-key": {Subject: "editor", Role: auth.RoleEditor},
+	}
+	r := gin.New()
+	routes := r.Group("", middleware.Authenticate(keys), middleware.Tenant())
+	RegisterSongRoutes(routes, NewSongHandler(songs, api))
+
+	files := map[string][]byte{
+		"uprising.mp3":  mp3File(map[string]string{"TPE1": "Muse", "TIT2": "Uprising", "TYER": "2009", "TDAT": "0709", "USLT": "Paranoia is in bloom"}),
+		"song2.mp3":     mp3File(map[string]string{"TPE1": "Blur", "TIT2": "Song 2", "TYER": "1997"}),
+		"again.mp3":     mp3File(map[string]string{"TPE1": "MUSE", "TIT2": "uprising"}),
+		"untitled.mp3":  mp3File(map[string]string{"TPE1": "Muse"}),
+		"notags.mp3":    mp3File(nil),
+		"cover.jpg":     {0xff, 0xd8, 0xff, 0xe0},
+		"upstream.mp3":  mp3File(map[string]string{"TPE1": "Unknown", "TIT2": "Song"}),
+		"truncated.mp3": mp3File(map[string]string{"TPE1": "Muse", "TIT2": "Hysteria"})[:20],
+	}
+	order := []string{"uprising.mp3", "song2.mp3", "again.mp3", "untitled.mp3", "notags.mp3", "cover.jpg", "upstream.mp3", "truncated.mp3"}
+
+	assert.Equal(t, http.StatusForbidden, ingest(r, "reader-key", files, order...).Code)
+
+	w := ingest(r, "editor-key", files, order...)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var report models.IngestReport
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, 2, report.Created)
+	assert.Equal(t, 5, report.Skipped)
+	assert.Equal(t, 1, report.Failed)
+	require.Len(t, report.Items, len(order))
+
+	for i, want := range []struct{ status, reason string }{
+		{models.IngestCreated, ""},
+		{models.IngestCreated, ""},
+		{models.IngestSkipped, "already in the library as song "},
+		{models.IngestSkipped, "no title tag"},
+		{models.IngestSkipped, "no tags found"},
+		{models.IngestSkipped, "not an MP3, FLAC, Ogg Vorbis or Opus file"},
+		{models.IngestFailed, "failed to fetch song details"},
+		{models.IngestSkipped, "unreadable tags: "},
+	} {
+		item := report.Items[i]
+		assert.Equal(t, order[i], item.File)
+		assert.Equal(t, want.status, item.Status, item.File)
+		assert.Contains(t, item.Reason, want.reason, item.File)
+	}
+
+	uprising := report.Items[0].Song
+	require.NotNil(t, uprising)
+	assert.Equal(t, "Muse", uprising.Group)
+	assert.Equal(t, "07.09.2009", uprising.ReleaseDate, "file tags win over the music API")
+	assert.Equal(t, "Paranoia is in bloom", uprising.Text)
+	assert.Equal(t, "https://youtu.be/w8KQmps-Sog", uprising.Link, "the music API fills in the rest")
+
+	song2 := report.Items[1].Song
+	require.NotNil(t, song2)
+	assert.Equal(t, "1997", song2.ReleaseDate)
+	assert.Equal(t, "Woo-hoo", song2.Text)
+
+	w = ingest(r, "editor-key", files, "uprising.mp3")
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, 1, report.Skipped, "ingesting a folder twice creates nothing new")
+
+	w = doJSON(r, "POST", "/api/v1/song/ingest", "editor-key", map[string]string{"group": "Muse"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestReleaseDate(t *testing.T) {
+	for in, want := range map[string]string{
+		"2006-07-16":          "16.07.2006",
+		"2006-07-16T10:00:00": "16.07.2006",
+		"2006-07":             "07.2006",
+		"2006":                "2006",
+		"summer '69":          "summer '69",
+		"":                    "",
+	} {
+		assert.Equal(t, want, releaseDate(in), in)
+	}
+}
@@ -0,0 +1,390 @@
+package handlers
+
+import (
+	"awesomeProject/auth"
+	"awesomeProject/middleware"
+	"awesomeProject/models"
+	"awesomeProject/repositories"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"strconv"
+	"strings"
+)
+
+// smartPlaylistLimit caps how many matching songs a smart playlist embeds
+// in its response or export; the songs endpoint pages through all of them.
+const smartPlaylistLimit = 500
+
+type PlaylistHandler struct {
+	repo  repositories.PlaylistRepository
+	songs repositories.SongRepository
+}
+
+func NewPlaylistHandler(repo repositories.PlaylistRepository, songs repositories.SongRepository) *PlaylistHandler {
+	return &PlaylistHandler{repo: repo, songs: songs}
+}
+
+func (h *PlaylistHandler) List(c *gin.Context) {
+	log := middleware.Logger(c)
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	playlists, total, err := h.repo.List(c.Request.Context(), page, limit)
+	if err != nil {
+		log.Info("Failed to fetch playlists", zap.Error(err))
+		middleware.RespondError(c, 500, "Failed to fetch playlists")
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"total": total,
+		"items": playlists,
+	})
+}
+
+func (h *PlaylistHandler) Get(c *gin.Context) {
+	playlist, ok := h.load(c)
+	if !ok {
+		return
+	}
+	if err := h.evaluate(c, playlist); err != nil {
+		h.respondError(c, err, "Failed to evaluate smart playlist")
+		return
+	}
+	c.JSON(200, playlist)
+}
+
+func (h *PlaylistHandler) Create(c *gin.Context) {
+	log := middleware.Logger(c)
+
+	var req models.PlaylistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Info("Invalid request", zap.Error(err))
+		middleware.RespondError(c, 400, err.Error())
+		return
+	}
+
+	if req.Rules != nil {
+		if err := req.Rules.Validate(); err != nil {
+			middleware.RespondError(c, 400, "Invalid rules: "+err.Error())
+			return
+		}
+	}
+
+	principal, _ := middleware.CurrentPrincipal(c)
+	playlist := models.Playlist{Name: req.Name, Description: req.Description, Owner: principal.Subject, Rules: req.Rules}
+	if err := h.repo.Create(c.Request.Context(), &playlist); err != nil {
+		log.Info("Failed to create playlist", zap.Error(err))
+		middleware.RespondError(c, 500, "Failed to create playlist")
+		return
+	}
+
+	log.Debug("Playlist created successfully", zap.Uint("id", playlist.ID))
+	c.JSON(201, playlist)
+}
+
+func (h *PlaylistHandler) Update(c *gin.Context) {
+	log := middleware.Logger(c)
+
+	playlist, ok := h.loadOwned(c)
+	if !ok {
+		return
+	}
+
+	var req models.PlaylistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Info("Invalid request", zap.Error(err))
+		middleware.RespondError(c, 400, err.Error())
+		return
+	}
+
+	if req.Rules != nil {
+		if playlist.Rules == nil {
+			middleware.RespondError(c, 400, "Static playlists cannot have rules")
+			return
+		}
+		if err := req.Rules.Validate(); err != nil {
+			middleware.RespondError(c, 400, "Invalid rules: "+err.Error())
+			return
+		}
+		playlist.Rules = req.Rules
+	}
+
+	playlist.Name = req.Name
+	playlist.Description = req.Description
+	if err := h.repo.Update(c.Request.Context(), playlist); err != nil {
+		h.respondError(c, err, "Failed to update playlist")
+		return
+	}
+	h.respondPlaylist(c, playlist.ID)
+}
+
+func (h *PlaylistHandler) Delete(c *gin.Context) {
+	log := middleware.Logger(c)
+
+	playlist, ok := h.loadOwned(c)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.Delete(c.Request.Context(), playlist.ID); err != nil {
+		log.Info("Failed to delete playlist", zap.Error(err))
+		middleware.RespondError(c, 500, "Failed to delete playlist")
+		return
+	}
+
+	log.Debug("Playlist deleted successfully", zap.Uint("id", playlist.ID))
+	c.Status(204)
+}
+
+func (h *PlaylistHandler) AddSong(c *gin.Context) {
+	log := middleware.Logger(c)
+
+	playlist, ok := h.loadOwned(c)
+	if !ok {
+		return
+	}
+	if playlist.Rules != nil {
+		middleware.RespondError(c, 409, "Smart playlist songs are defined by its rules")
+		return
+	}
+
+	var req models.AddPlaylistSongRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Info("Invalid request", zap.Error(err))
+		middleware.RespondError(c, 400, err.Error())
+		return
+	}
+
+	if err := h.repo.AddSong(c.Request.Context(), playlist.ID, req.SongID, req.Position); err != nil {
+		h.respondError(c, err, "Failed to add song to playlist")
+		return
+	}
+	h.respondPlaylist(c, playlist.ID)
+}
+
+func (h *PlaylistHandler) RemoveSong(c *gin.Context) {
+	playlist, ok := h.loadOwned(c)
+	if !ok {
+		return
+	}
+	if playlist.Rules != nil {
+		middleware.RespondError(c, 409, "Smart playlist songs are defined by its rules")
+		return
+	}
+
+	songID, err := strconv.ParseUint(c.Param("songId"), 10, 0)
+	if err != nil {
+		middleware.RespondError(c, 400, "Invalid song id")
+		return
+	}
+
+	if err := h.repo.RemoveSong(c.Request.Context(), playlist.ID, uint(songID)); err != nil {
+		h.respondError(c, err, "Failed to remove song from playlist")
+		return
+	}
+	h.respondPlaylist(c, playlist.ID)
+}
+
+func (h *PlaylistHandler) Reorder(c *gin.Context) {
+	log := middleware.Logger(c)
+
+	playlist, ok := h.loadOwned(c)
+	if !ok {
+		return
+	}
+	if playlist.Rules != nil {
+		middleware.RespondError(c, 409, "Smart playlist songs are defined by its rules")
+		return
+	}
+
+	var req models.ReorderPlaylistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Info("Invalid request", zap.Error(err))
+		middleware.RespondError(c, 400, err.Error())
+		return
+	}
+
+	if err := h.repo.Reorder(c.Request.Context(), playlist.ID, req.SongIDs); err != nil {
+		h.respondError(c, err, "Failed to reorder playlist")
+		return
+	}
+	h.respondPlaylist(c, playlist.ID)
+}
+
+// Songs pages through a playlist's songs; for smart playlists these are the
+// songs currently matching its rules.
+func (h *PlaylistHandler) Songs(c *gin.Context) {
+	log := middleware.Logger(c)
+
+	playlist, ok := h.load(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	if playlist.Rules != nil {
+		songs, total, err := h.songs.Search(c.Request.Context(), *playlist.Rules, page, limit)
+		if err != nil {
+			log.Info("Failed to evaluate smart playlist", zap.Error(err))
+			middleware.RespondError(c, 500, "Failed to fetch songs")
+			return
+		}
+		c.JSON(200, gin.H{"total": total, "items": songs})
+		return
+	}
+
+	songs := make([]models.Song, 0, len(playlist.Items))
+	for _, item := range playlist.Items {
+		if item.Song != nil {
+			songs = append(songs, *item.Song)
+		}
+	}
+	start := min(max((page-1)*limit, 0), len(songs))
+	end := min(start+max(limit, 0), len(songs))
+	c.JSON(200, gin.H{"total": len(songs), "items": songs[start:end]})
+}
+
+// Preview evaluates rules without saving them, so clients can check what a
+// smart playlist would contain.
+func (h *PlaylistHandler) Preview(c *gin.Context) {
+	log := middleware.Logger(c)
+
+	var req models.PreviewRulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Info("Invalid request", zap.Error(err))
+		middleware.RespondError(c, 400, err.Error())
+		return
+	}
+	if err := req.Rules.Validate(); err != nil {
+		middleware.RespondError(c, 400, "Invalid rules: "+err.Error())
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	songs, total, err := h.songs.Search(c.Request.Context(), *req.Rules, page, limit)
+	if err != nil {
+		log.Info("Failed to evaluate rules", zap.Error(err))
+		middleware.RespondError(c, 500, "Failed to fetch songs")
+		return
+	}
+	c.JSON(200, gin.H{"total": total, "items": songs})
+}
+
+// Export renders the playlist as an extended M3U file pointing at each
+// song's link. Songs without a link are skipped.
+func (h *PlaylistHandler) Export(c *gin.Context) {
+	playlist, ok := h.load(c)
+	if !ok {
+		return
+	}
+	if err := h.evaluate(c, playlist); err != nil {
+		h.respondError(c, err, "Failed to evaluate smart playlist")
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n")
+	fmt.Fprintf(&b, "#PLAYLIST:%s\n", m3uText(playlist.Name))
+	for _, item := range playlist.Items {
+		if item.Song == nil || item.Song.Link == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "#EXTINF:-1,%s - %s\n", m3uText(item.Song.Group), m3uText(item.Song.Name))
+		fmt.Fprintf(&b, "%s\n", m3uText(item.Song.Link))
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="playlist-%d.m3u"`, playlist.ID))
+	c.Data(200, "audio/x-mpegurl; charset=utf-8", []byte(b.String()))
+}
+
+// m3uText keeps a value on one line so it cannot inject extra entries.
+func m3uText(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
+
+func (h *PlaylistHandler) load(c *gin.Context) (*models.Playlist, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 0)
+	if err != nil {
+		middleware.RespondError(c, 404, "Playlist not found")
+		return nil, false
+	}
+
+	playlist, err := h.repo.GetByID(c.Request.Context(), uint(id))
+	if err != nil {
+		h.respondError(c, err, "Failed to fetch playlist")
+		return nil, false
+	}
+	return playlist, true
+}
+
+// evaluate fills a smart playlist's items with its currently matching songs.
+func (h *PlaylistHandler) evaluate(c *gin.Context, playlist *models.Playlist) error {
+	if playlist.Rules == nil {
+		return nil
+	}
+	songs, _, err := h.songs.Search(c.Request.Context(), *playlist.Rules, 1, smartPlaylistLimit)
+	if err != nil {
+		return err
+	}
+	playlist.Items = make([]models.PlaylistItem, len(songs))
+	for i := range songs {
+		playlist.Items[i] = models.PlaylistItem{SongID: songs[i].ID, Position: i + 1, Song: &songs[i]}
+	}
+	return nil
+}
+
+// loadOwned loads the playlist for modification, which only its owner and
+// admins may do.
+func (h *PlaylistHandler) loadOwned(c *gin.Context) (*models.Playlist, bool) {
+	c.Request = c.Request.WithContext(repositories.ReadFromPrimary(c.Request.Context()))
+
+	playlist, ok := h.load(c)
+	if !ok {
+		return nil, false
+	}
+
+	principal, _ := middleware.CurrentPrincipal(c)
+	if playlist.Owner != principal.Subject && !principal.Can(auth.PermissionAdmin) {
+		middleware.RespondError(c, 403, "Forbidden")
+		return nil, false
+	}
+	return playlist, true
+}
+
+func (h *PlaylistHandler) respondPlaylist(c *gin.Context, id uint) {
+	playlist, err := h.repo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		h.respondError(c, err, "Failed to fetch playlist")
+		return
+	}
+	if err := h.evaluate(c, playlist); err != nil {
+		h.respondError(c, err, "Failed to evaluate smart playlist")
+		return
+	}
+	c.JSON(200, playlist)
+}
+
+func (h *PlaylistHandler) respondError(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		middleware.RespondError(c, 404, "Playlist not found")
+	case errors.Is(err, repositories.ErrSongNotFound), errors.Is(err, repositories.ErrSongNotInPlaylist):
+		middleware.RespondError(c, 404, err.Error())
+	case errors.Is(err, repositories.ErrSongInPlaylist):
+		middleware.RespondError(c, 409, err.Error())
+	case errors.Is(err, repositories.ErrInvalidPosition), errors.Is(err, repositories.ErrInvalidOrder):
+		middleware.RespondError(c, 400, err.Error())
+	default:
+		middleware.Logger(c).Info(msg, zap.Error(err))
+		middleware.RespondError(c, 500, msg)
+	}
+}
@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"awesomeProject/auth"
+	"awesomeProject/logger"
+	"awesomeProject/middleware"
+	"awesomeProject/models"
+	"awesomeProject/repositories"
+	"awesomeProject/tenant"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func setupPlaylistTest(t *testing.T) (*gin.Engine, []models.Song) {
+	logger.Init()
+	gin.SetMode(gin.TestMode)
+
+	songs := repositories.NewMemorySongRepository()
+	ctx := tenant.NewContext(context.Background(), tenant.Default)
+	seeded := []models.Song{
+		{Group: "Muse", Name: "Uprising", Link: "https://example.com/uprising"},
+		{Group: "Muse", Name: "Starlight", Link: "https://example.com/starlight"},
+		{Group: "Queen", Name: "No Link"},
+	}
+	for i := range seeded {
+		require.NoError(t, songs.Create(ctx, &seeded[i]))
+	}
+
+	keys := auth.KeyStore{
+		"reader-key":  {Subject: "reader", Role: auth.RoleReader},
+		"alice-key":   {Subject: "alice", Role: auth.RoleEditor},
+		"mallory-key": {Subject: "mallory", Role: auth.RoleEditor},
+		"admin-key":   {Subject: "admin", Role: auth.RoleAdmin},
+	}
+
+	r := gin.New()
+	api := r.Group("", middleware.Authenticate(keys), middleware.Tenant())
+	RegisterPlaylistRoutes(api, NewPlaylistHandler(repositories.NewMemoryPlaylistRepository(songs), songs))
+	return r, seeded
+}
+
+func doJSON(r *gin.Engine, method, path, key string, body any) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		_ = json.NewEncoder(&buf).Encode(body)
+	}
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", key)
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func decodePlaylist(t *testing.T, w *httptest.ResponseRecorder) models.Playlist {
+	var playlist models.Playlist
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &playlist))
+	return playlist
+}
+
+func playlistSongIDs(playlist models.Playlist) []uint {
+	var ids []uint
+	for _, item := range playlist.Items {
+		ids = append(ids, item.SongID)
+	}
+	return ids
+}
+
+func TestPlaylistHandler_Membership(t *testing.T) {
+	r, songs := setupPlaylistTest(t)
+
+	w := doJSON(r, "POST", "/api/v1/playlists", "alice-key", models.PlaylistRequest{Name: "Mix"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	playlist := decodePlaylist(t, w)
+	assert.Equal(t, "alice", playlist.Owner)
+	base := fmt.Sprintf("/api/v1/playlists/%d", playlist.ID)
+
+	for _, song := range songs {
+		w = doJSON(r, "POST", base+"/songs", "alice-key", models.AddPlaylistSongRequest{SongID: song.ID})
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+	assert.Equal(t, []uint{songs[0].ID, songs[1].ID, songs[2].ID}, playlistSongIDs(decodePlaylist(t, w)))
+
+	t.Run("duplicate song", func(t *testing.T) {
+		w := doJSON(r, "POST", base+"/songs", "alice-key", models.AddPlaylistSongRequest{SongID: songs[0].ID})
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("unknown song", func(t *testing.T) {
+		w := doJSON(r, "POST", base+"/songs", "alice-key", models.AddPlaylistSongRequest{SongID: 999})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("reorder", func(t *testing.T) {
+		order := []uint{songs[2].ID, songs[0].ID, songs[1].ID}
+		w := doJSON(r, "PUT", base+"/songs", "alice-key", models.ReorderPlaylistRequest{SongIDs: order})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, order, playlistSongIDs(decodePlaylist(t, w)))
+
+		w = doJSON(r, "PUT", base+"/songs", "alice-key", models.ReorderPlaylistRequest{SongIDs: order[:2]})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		w := doJSON(r, "DELETE", fmt.Sprintf("%s/songs/%d", base, songs[0].ID), "alice-key", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []uint{songs[2].ID, songs[1].ID}, playlistSongIDs(decodePlaylist(t, w)))
+
+		w = doJSON(r, "DELETE", fmt.Sprintf("%s/songs/%d", base, songs[0].ID), "alice-key", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("export", func(t *testing.T) {
+		w := doJSON(r, "GET", base+"/export", "reader-key", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "audio/x-mpegurl; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, "#EXTM3U\n#PLAYLIST:Mix\n#EXTINF:-1,Muse - Starlight\nhttps://example.com/starlight\n", w.Body.String())
+	})
+}
+
+func TestPlaylistHandler_Ownership(t *testing.T) {
+	r, songs := setupPlaylistTest(t)
+
+	w := doJSON(r, "POST", "/api/v1/playlists", "alice-key", models.PlaylistRequest{Name: "Mix"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	base := fmt.Sprintf("/api/v1/playlists/%d", decodePlaylist(t, w).ID)
+
+	tests := []struct {
+		name   string
+		key    string
+		status int
+	}{
+		{"reader lacks write permission", "reader-key", http.StatusForbidden},
+		{"other editor is not the owner", "mallory-key", http.StatusForbidden},
+		{"owner", "alice-key", http.StatusOK},
+		{"admin", "admin-key", http.StatusOK},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := doJSON(r, "PUT", base, tc.key, models.PlaylistRequest{Name: "Renamed by " + tc.key})
+			assert.Equal(t, tc.status, w.Code)
+
+			w = doJSON(r, "POST", base+"/songs", tc.key, models.AddPlaylistSongRequest{SongID: songs[0].ID})
+			if tc.status == http.StatusOK {
+				assert.NotEqual(t, http.StatusForbidden, w.Code)
+			} else {
+				assert.Equal(t, tc.status, w.Code)
+			}
+		})
+	}
+
+	t.Run("anyone with read access can view", func(t *testing.T) {
+		w := doJSON(r, "GET", base, "mallory-key", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, doJSON(r, "DELETE", base, "mallory-key", nil).Code)
+		assert.Equal(t, http.StatusNoContent, doJSON(r, "DELETE", base, "alice-key", nil).Code)
+		assert.Equal(t, http.StatusNotFound, doJSON(r, "GET", base, "alice-key", nil).Code)
+	})
+}
+
+func TestPlaylistHandler_SmartPlaylist(t *testing.T) {
+	r, songs := setupPlaylistTest(t)
+	muse := &models.Rule{Field: models.RuleFieldGroup, Op: models.RuleOpEquals, Value: "Muse"}
+
+	t.Run("preview", func(t *testing.T) {
+		w := doJSON(r, "POST", "/api/v1/playlists/preview?limit=1", "reader-key", models.PreviewRulesRequest{Rules: muse})
+		require.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Total int64         `json:"total"`
+			Items []models.Song `json:"items"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, int64(2), response.Total)
+		require.Len(t, response.Items, 1)
+		assert.Equal(t, "Uprising", response.Items[0].Name)
+
+		invalid := &models.Rule{Field: "mood", Op: models.RuleOpEquals, Value: "happy"}
+		w = doJSON(r, "POST", "/api/v1/playlists/preview", "reader-key", models.PreviewRulesRequest{Rules: invalid})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	w := doJSON(r, "POST", "/api/v1/playlists", "alice-key", models.PlaylistRequest{Name: "All Muse", Rules: muse})
+	require.Equal(t, http.StatusCreated, w.Code)
+	base := fmt.Sprintf("/api/v1/playlists/%d", decodePlaylist(t, w).ID)
+
+	t.Run("items are evaluated", func(t *testing.T) {
+		w := doJSON(r, "GET", base, "reader-key", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []uint{songs[0].ID, songs[1].ID}, playlistSongIDs(decodePlaylist(t, w)))
+
+		w = doJSON(r, "GET", base+"/songs?page=2&limit=1", "reader-key", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "Starlight")
+		assert.NotContains(t, w.Body.String(), "Uprising")
+	})
+
+	t.Run("membership is read-only", func(t *testing.T) {
+		w := doJSON(r, "POST", base+"/songs", "alice-key", models.AddPlaylistSongRequest{SongID: songs[2].ID})
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("rules can be changed", func(t *testing.T) {
+		queen := &models.Rule{Field: models.RuleFieldGroup, Op: models.RuleOpContains, Value: "queen"}
+		w := doJSON(r, "PUT", base, "alice-key", models.PlaylistRequest{Name: "Queen", Rules: queen})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []uint{songs[2].ID}, playlistSongIDs(decodePlaylist(t, w)))
+	})
+
+	t.Run("static playlists cannot gain rules", func(t *testing.T) {
+		w := doJSON(r, "POST", "/api/v1/playlists", "alice-key", models.PlaylistRequest{Name: "Static"})
+		require.Equal(t, http.StatusCreated, w.Code)
+		static := fmt.Sprintf("/api/v1/playlists/%d", decodePlaylist(t, w).ID)
+
+		w = doJSON(r, "PUT", static, "alice-key", models.PlaylistRequest{Name: "Static", Rules: muse})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
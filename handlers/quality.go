@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"awesomeProject/middleware"
+	"awesomeProject/models"
+	"awesomeProject/quality"
+	"awesomeProject/repositories"
+	"awesomeProject/services"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"slices"
+	"strconv"
+)
+
+// qualityScanBatch is how many songs the report loads per query.
+const qualityScanBatch = 500
+
+// Repair result statuses.
+const (
+	repairRepaired   = "repaired"
+	repairIncomplete = "incomplete"
+	repairReviewed   = "reviewed"
+	repairNotFound   = "not_found"
+	repairFailed     = "failed"
+)
+
+// QualityHandler reports songs with incomplete or suspicious details and
+// repairs them in bulk.
+type QualityHandler struct {
+	songs    repositories.SongRepository
+	reviews  repositories.ReviewRepository
+	musicAPI services.MusicAPIServiceInterface
+}
+
+func NewQualityHandler(songs repositories.SongRepository, reviews repositories.ReviewRepository, api services.MusicAPIServiceInterface) *QualityHandler {
+	return &QualityHandler{songs: songs, reviews: reviews, musicAPI: api}
+}
+
+// @Summary Data-quality report
+// @Description Songs with missing fields, malformed links, unparsable dates or suspicious lyrics
+// @Tags quality
+// @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page"
+// @Param issue query string false "Only songs with this issue"
+// @Param includeReviewed query bool false "Include songs whose issues were all reviewed"
+// @Success 200 {object} models.QualityReport
+// @Router /quality/report [get]
+func (h *QualityHandler) Report(c *gin.Context) {
+	log := middleware.Logger(c)
+	ctx := c.Request.Context()
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	issue := c.Query("issue")
+	includeReviewed := c.Query("includeReviewed") == "true"
+
+	reviews, err := h.reviews.Reviews(ctx)
+	if err != nil {
+		log.Info("Failed to fetch reviews", zap.Error(err))
+		middleware.RespondError(c, 500, "Failed to build quality report")
+		return
+	}
+
+	report := models.QualityReport{Issues: make(map[string]int64), Items: []models.FlaggedSong{}}
+	var flagged []models.FlaggedSong
+	for batch := 1; ; batch++ {
+		songs, total, err := h.songs.List(ctx, batch, qualityScanBatch, map[string]string{})
+		if err != nil {
+			log.Info("Failed to fetch songs", zap.Error(err))
+			middleware.RespondError(c, 500, "Failed to build quality report")
+			return
+		}
+		for _, song := range songs {
+			issues := quality.Inspect(song)
+			if len(issues) == 0 || (issue != "" && !slices.Contains(issues, issue)) {
+				continue
+			}
+			reviewed := covers(reviews[song.ID], issues)
+			if reviewed && !includeReviewed {
+				continue
+			}
+			for _, i := range issues {
+				report.Issues[i]++
+			}
+			flagged = append(flagged, models.FlaggedSong{ID: song.ID, Group: song.Group, Name: song.Name, Issues: issues, Reviewed: reviewed})
+		}
+		report.Scanned += int64(len(songs))
+		if len(songs) == 0 || report.Scanned >= total {
+			break
+		}
+	}
+
+	report.Flagged = int64(len(flagged))
+	start := min(max((page-1)*limit, 0), len(flagged))
+	end := min(start+max(limit, 0), len(flagged))
+	report.Items = append(report.Items, flagged[start:end]...)
+	c.JSON(200, report)
+}
+
+// covers reports whether every issue was accepted in a review.
+func covers(reviewed, issues []string) bool {
+	if reviewed == nil {
+		return false
+	}
+	for _, issue := range issues {
+		if !slices.Contains(reviewed, issue) {
+			return false
+		}
+	}
+	return true
+}
+
+// @Summary Repair flagged songs
+// @Description Re-run enrichment from the music API, or mark the songs' current issues as reviewed
+// @Tags quality
+// @Accept json
+// @Produce json
+// @Param request body models.QualityRepairRequest true "Songs and action"
+// @Success 200 {array} models.RepairResult
+// @Router /quality/repair [post]
+func (h *QualityHandler) Repair(c *gin.Context) {
+	log := middleware.Logger(c)
+
+	var req models.QualityRepairRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Info("Invalid request", zap.Error(err))
+		middleware.RespondError(c, 400, err.Error())
+		return
+	}
+
+	var results []models.RepairResult
+	if req.Action == models.RepairReview {
+		results = h.review(c, req.SongIDs)
+	} else {
+		results = h.reenrich(c, req.SongIDs)
+	}
+	if results == nil {
+		return
+	}
+	c.JSON(200, gin.H{"items": results})
+}
+
+func (h *QualityHandler) review(c *gin.Context, ids []uint) []models.RepairResult {
+	ctx := c.Request.Context()
+	results := make([]models.RepairResult, len(ids))
+	accepted := make(map[uint][]string)
+	for i, id := range ids {
+		results[i] = models.RepairResult{ID: id}
+		song, err := h.songs.GetByID(ctx, fmt.Sprint(id))
+		if err != nil {
+			results[i].Status, results[i].Error = repairStatus(err)
+			continue
+		}
+		issues := quality.Inspect(*song)
+		accepted[id] = append([]string{}, issues...)
+		results[i].Status, results[i].Issues = repairReviewed, issues
+	}
+
+	if len(accepted) > 0 {
+		if err := h.reviews.MarkReviewed(ctx, accepted); err != nil {
+			middleware.Logger(c).Info("Failed to mark songs reviewed", zap.Error(err))
+			middleware.RespondError(c, 500, "Failed to mark songs reviewed")
+			return nil
+		}
+	}
+	return results
+}
+
+// reenrich fetches each song's details again and fills in whatever the
+// music API returns. Songs are repaired independently; one failure does not
+// stop the rest.
+func (h *QualityHandler) reenrich(c *gin.Context, ids []uint) []models.RepairResult {
+	log := middleware.Logger(c)
+	ctx := c.Request.Context()
+	results := make([]models.RepairResult, len(ids))
+	for i, id := range ids {
+		results[i] = models.RepairResult{ID: id}
+		song, err := h.songs.GetByID(repositories.ReadFromPrimary(ctx), fmt.Sprint(id))
+		if err != nil {
+			results[i].Status, results[i].Error = repairStatus(err)
+			continue
+		}
+
+		details, err := h.musicAPI.GetSongInfo(ctx, song.Group, song.Name)
+		if err != nil {
+			log.Info("Failed to fetch song details", zap.Uint("id", id), zap.Error(err))
+			results[i].Status, results[i].Error = repairFailed, "Failed to fetch song details"
+			continue
+		}
+		if details.ReleaseDate != "" {
+			song.ReleaseDate = details.ReleaseDate
+		}
+		if details.Text != "" {
+			song.Text = details.Text
+		}
+		if details.Link != "" {
+			enriched := *song
+			replacePrimaryLink(&enriched, details.Link)
+			if err := validateLinks(enriched.Link, enriched.Links); err != nil {
+				log.Warn("Ignoring link from music API", zap.Uint("id", id), zap.String("link", details.Link), zap.Error(err))
+			} else {
+				song.Link, song.Links = enriched.Link, enriched.Links
+			}
+		}
+		if err := h.songs.Update(ctx, song); err != nil {
+			log.Info("Failed to update song", zap.Uint("id", id), zap.Error(err))
+			results[i].Status, results[i].Error = repairFailed, "Failed to update song"
+			continue
+		}
+
+		results[i].Issues = quality.Inspect(*song)
+		results[i].Status = repairRepaired
+		if len(results[i].Issues) > 0 {
+			results[i].Status = repairIncomplete
+		}
+	}
+	return results
+}
+
+func repairStatus(err error) (string, string) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return repairNotFound, "Song not found"
+	}
+	return repairFailed, "Failed to fetch song"
+}
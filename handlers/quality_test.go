@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"awesomeProject/auth"
+	"awesomeProject/logger"
+	"awesomeProject/middleware"
+	"awesomeProject/models"
+	"awesomeProject/quality"
+	"awesomeProject/repositories"
+	"awesomeProject/services"
+	"awesomeProject/tenant"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func TestQualityHandler(t *testing.T) {
+	logger.Init()
+	gin.SetMode(gin.TestMode)
+
+	ctx := tenant.NewContext(context.Background(), tenant.Default)
+	setup := func(t *testing.T) (*gin.Engine, *MockMusicAPIService, *repositories.MemorySongRepository, []models.Song) {
+		songs := repositories.NewMemorySongRepository()
+		stored := []models.Song{
+			{Group: "Muse", Name: "Uprising", ReleaseDate: "16.07.2009", Text: "Paranoia is in bloom", Link: "https://example.com/uprising"},
+			{Group: "Muse", Name: "Empty"},
+			{Group: "Queen", Name: "Broken", ReleaseDate: "1975", Text: "<html><body>502 Bad Gateway</body></html>", Link: "https://example.com/broken"},
+		}
+		for i := range stored {
+			require.NoError(t, songs.Create(ctx, &stored[i]))
+		}
+
+		api := new(MockMusicAPIService)
+		keys := auth.KeyStore{
+			"reader-key": {Subject: "reader", Role: auth.RoleReader},
+			"editor-key": {Subject: "editor", Role: auth.RoleEditor},
+		}
+		r := gin.New()
+		group := r.Group("", middleware.Authenticate(keys), middleware.Tenant())
+		RegisterQualityRoutes(group, NewQualityHandler(songs, repositories.NewMemoryReviewRepository(songs), api))
+		return r, api, songs, stored
+	}
+
+	report := func(t *testing.T, r *gin.Engine, query string) models.QualityReport {
+		w := doJSON(r, "GET", "/api/v1/quality/report"+query, "reader-key", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var report models.QualityReport
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+		return report
+	}
+
+	repair := func(t *testing.T, r *gin.Engine, req models.QualityRepairRequest) []models.RepairResult {
+		w := doJSON(r, "POST", "/api/v1/quality/repair", "editor-key", req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var body struct{ Items []models.RepairResult }
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body.Items
+	}
+
+	t.Run("report", func(t *testing.T) {
+		r, _, _, stored := setup(t)
+
+		got := report(t, r, "")
+		assert.Equal(t, int64(3), got.Scanned)
+		assert.Equal(t, int64(2), got.Flagged)
+		require.Len(t, got.Items, 2)
+		assert.Equal(t, stored[1].ID, got.Items[0].ID)
+		assert.Equal(t, []string{quality.IssueMissingText, quality.IssueMissingLink, quality.IssueMissingReleaseDate}, got.Items[0].Issues)
+		assert.Equal(t, []string{quality.IssueInvalidReleaseDate, quality.IssueSuspiciousText}, got.Items[1].Issues)
+		assert.Equal(t, int64(1), got.Issues[quality.IssueSuspiciousText])
+
+		got = report(t, r, "?issue="+quality.IssueSuspiciousText)
+		require.Len(t, got.Items, 1)
+		assert.Equal(t, stored[2].ID, got.Items[0].ID)
+
+		got = report(t, r, "?page=2&limit=1")
+		assert.Equal(t, int64(2), got.Flagged)
+		require.Len(t, got.Items, 1)
+		assert.Equal(t, stored[2].ID, got.Items[0].ID)
+	})
+
+	t.Run("review hides songs until new issues appear", func(t *testing.T) {
+		r, _, _, stored := setup(t)
+
+		results := repair(t, r, models.QualityRepairRequest{SongIDs: []uint{stored[1].ID, 999}, Action: models.RepairReview})
+		require.Len(t, results, 2)
+		assert.Equal(t, "reviewed", results[0].Status)
+		assert.Equal(t, "not_found", results[1].Status)
+
+		got := report(t, r, "")
+		require.Len(t, got.Items, 1)
+		assert.Equal(t, stored[2].ID, got.Items[0].ID)
+
+		got = report(t, r, "?includeReviewed=true")
+		require.Len(t, got.Items, 2)
+		assert.True(t, got.Items[0].Reviewed)
+	})
+
+	t.Run("reenrich", func(t *testing.T) {
+		r, api, _, stored := setup(t)
+		api.On("GetSongInfo", "Muse", "Empty").Return(&models.SongDetail{ReleaseDate: "01.01.2001", Text: "Verse", Link: "https://example.com/empty"}, nil)
+		api.On("GetSongInfo", "Queen", "Broken").Return(nil, &services.StatusError{StatusCode: http.StatusBadGateway})
+
+		results := repair(t, r, models.QualityRepairRequest{SongIDs: []uint{stored[1].ID, stored[2].ID}, Action: models.RepairReenrich})
+		require.Len(t, results, 2)
+		assert.Equal(t, models.RepairResult{ID: stored[1].ID, Status: "repaired"}, results[0])
+		assert.Equal(t, "failed", results[1].Status)
+
+		got := report(t, r, "")
+		require.Len(t, got.Items, 1)
+		assert.Equal(t, stored[2].ID, got.Items[0].ID)
+	})
+
+	t.Run("reenrich replaces the primary link", func(t *testing.T) {
+		r, api, songs, _ := setup(t)
+		linked := models.Song{Group: "Blur", Name: "Song 2", Link: "https://example.com/old"}
+		for i := 1; i < models.MaxSongLinks; i++ {
+			linked.Links = append(linked.Links, models.SongLink{URL: fmt.Sprintf("https://example.com/%d", i)})
+		}
+		require.NoError(t, songs.Create(ctx, &linked))
+		api.On("GetSongInfo", "Blur", "Song 2").Return(&models.SongDetail{Link: "https://example.com/new"}, nil)
+
+		repair(t, r, models.QualityRepairRequest{SongIDs: []uint{linked.ID}, Action: models.RepairReenrich})
+		song, err := songs.GetByID(ctx, fmt.Sprint(linked.ID))
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com/new", song.Link)
+		var urls []string
+		for _, link := range song.Links {
+			urls = append(urls, link.URL)
+		}
+		assert.Len(t, urls, models.MaxSongLinks, "the cap holds")
+		assert.Equal(t, "https://example.com/new", urls[0])
+		assert.NotContains(t, urls, "https://example.com/old", "the replaced link is dropped")
+	})
+
+	t.Run("validation and permissions", func(t *testing.T) {
+		r, _, _, stored := setup(t)
+
+		w := doJSON(r, "POST", "/api/v1/quality/repair", "editor-key", models.QualityRepairRequest{SongIDs: []uint{stored[1].ID}, Action: "delete"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = doJSON(r, "POST", "/api/v1/quality/repair", "reader-key", models.QualityRepairRequest{SongIDs: []uint{stored[1].ID}, Action: models.RepairReview})
+		assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+	})
+}
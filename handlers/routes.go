@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"awesomeProject/auth"
+	"awesomeProject/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterSongRoutes(r gin.IRouter, h *SongHandler) {
+	r.GET("/api/v1/song", middleware.Authorize(auth.PermissionRead), h.List)
+	r.GET("/api/v1/song/:id/text", middleware.Authorize(auth.PermissionRead), h.GetText)
+	r.POST("/api/v1/song", middleware.Authorize(auth.PermissionWrite), h.Create)
+	r.POST("/api/v1/song/ingest", middleware.Authorize(auth.PermissionWrite), h.Ingest)
+	r.PUT("/api/v1/song/:id", middleware.Authorize(auth.PermissionWrite), h.Update)
+	r.DELETE("/api/v1/song/:id", middleware.Authorize(auth.PermissionDelete), h.Delete)
+}
+
+func RegisterPlaylistRoutes(r gin.IRouter, h *PlaylistHandler) {
+	read := middleware.Authorize(auth.PermissionRead)
+	write := middleware.Authorize(auth.PermissionWrite)
+
+	r.GET("/api/v1/playlists", read, h.List)
+	r.POST("/api/v1/playlists", write, h.Create)
+	r.POST("/api/v1/playlists/preview", read, h.Preview)
+	r.GET("/api/v1/playlists/:id", read, h.Get)
+	r.PUT("/api/v1/playlists/:id", write, h.Update)
+	r.DELETE("/api/v1/playlists/:id", write, h.Delete)
+	r.GET("/api/v1/playlists/:id/export", read, h.Export)
+	r.GET("/api/v1/playlists/:id/songs", read, h.Songs)
+	r.POST("/api/v1/playlists/:id/songs", write, h.AddSong)
+	r.PUT("/api/v1/playlists/:id/songs", write, h.Reorder)
+	r.DELETE("/api/v1/playlists/:id/songs/:songId", write, h.RemoveSong)
+}
+
+func RegisterTaxonomyRoutes(r gin.IRouter, h *TaxonomyHandler) {
+	read := middleware.Authorize(auth.PermissionRead)
+	write := middleware.Authorize(auth.PermissionWrite)
+	admin := middleware.Authorize(auth.PermissionAdmin)
+
+	r.GET("/api/v1/tags", read, h.Tags)
+	r.POST("/api/v1/tags/apply", write, h.ApplyTags)
+	r.POST("/api/v1/tags/remove", write, h.RemoveTags)
+	r.GET("/api/v1/genres", read, h.Genres)
+	r.POST("/api/v1/genres", admin, h.CreateGenre)
+	r.DELETE("/api/v1/genres/:id", admin, h.DeleteGenre)
+	r.PUT("/api/v1/song/:id/genres", write, h.SetSongGenres)
+}
+
+func RegisterCreditRoutes(r gin.IRouter, h *CreditHandler) {
+	r.GET("/api/v1/people", middleware.Authorize(auth.PermissionRead), h.People)
+	r.PUT("/api/v1/song/:id/credits", middleware.Authorize(auth.PermissionWrite), h.SetCredits)
+}
+
+func RegisterAttachmentRoutes(r gin.IRouter, h *AttachmentHandler) {
+	read := middleware.Authorize(auth.PermissionRead)
+	write := middleware.Authorize(auth.PermissionWrite)
+
+	r.GET("/api/v1/song/:id/attachments", read, h.List)
+	r.POST("/api/v1/song/:id/attachments", write, h.Upload)
+	r.GET("/api/v1/song/:id/attachments/:attachmentId", read, h.Download)
+	r.GET("/api/v1/song/:id/attachments/:attachmentId/thumbnail", read, h.Thumbnail)
+	r.DELETE("/api/v1/song/:id/attachments/:attachmentId", write, h.Delete)
+}
+
+// RegisterEngagementRoutes registers the caller's personal song activity,
+// which every role may record.
+func RegisterEngagementRoutes(r gin.IRouter, h *EngagementHandler) {
+	read := middleware.Authorize(auth.PermissionRead)
+
+	r.PUT("/api/v1/song/:id/favorite", read, h.Favorite)
+	r.DELETE("/api/v1/song/:id/favorite", read, h.Unfavorite)
+	r.PUT("/api/v1/song/:id/rating", read, h.Rate)
+	r.DELETE("/api/v1/song/:id/rating", read, h.Unrate)
+	r.POST("/api/v1/song/:id/plays", read, h.RecordPlay)
+	r.GET("/api/v1/me/favorites", read, h.Favorites)
+	r.GET("/api/v1/me/top-played", read, h.TopPlayed)
+}
+
+func RegisterStatsRoutes(r gin.IRouter, h *StatsHandler) {
+	r.GET("/api/v1/stats", middleware.Authorize(auth.PermissionRead), h.Get)
+}
+
+func RegisterQualityRoutes(r gin.IRouter, h *QualityHandler) {
+	r.GET("/api/v1/quality/report", middleware.Authorize(auth.PermissionRead), h.Report)
+	r.POST("/api/v1/quality/repair", middleware.Authorize(auth.PermissionWrite), h.Repair)
+}
+
+func RegisterAdminRoutes(r gin.IRouter, h *AdminHandler) {
+	r.GET("/api/v1/admin/log-level", middleware.Authorize(auth.PermissionAdmin), h.GetLogLevel)
+	r.PUT("/api/v1/admin/log-level", middleware.Authorize(auth.PermissionAdmin), h.SetLogLevel)
+}
+
+func RegisterHealthRoutes(r gin.IRouter, h *HealthHandler) {
+	r.GET("/healthz", h.Live)
+	r.GET("/readyz", h.Ready)
+	r.GET("/health", h.Report)
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"awesomeProject/auth"
+	"awesomeProject/logger"
+	"awesomeProject/middleware"
+	"awesomeProject/models"
+	"bytes"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func setupAuthTest() *gin.Engine {
+	logger.Init()
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockSongRepository)
+	mockAPI := new(MockMusicAPIService)
+
+	song := &models.Song{ID: 1, Group: "Muse", Name: "Uprising", Text: "Verse 1"}
+	mockRepo.On("List", mock.Anything, mock.Anything, mock.Anything).Return([]models.Song{*song}, int64(1), nil).Maybe()
+	mockRepo.On("GetByID", mock.Anything).Return(song, nil).Maybe()
+	mockRepo.On("Create", mock.Anything).Return(nil).Maybe()
+	mockRepo.On("Update", mock.Anything).Return(nil).Maybe()
+	mockRepo.On("Delete", mock.Anything).Return(nil).Maybe()
+	mockAPI.On("GetSongInfo", mock.Anything, mock.Anything).Return(&models.SongDetail{}, nil).Maybe()
+
+	keys := auth.KeyStore{
+		"reader-key": {Subject: "reader", Role: auth.RoleReader},
+		"editor-key": {Subject: "editor", Role: auth.RoleEditor},
+		"admin-key":  {Subject: "admin", Role: auth.RoleAdmin},
+	}
+
+	r := gin.New()
+	api := r.Group("", middleware.Authenticate(keys))
+	RegisterSongRoutes(api, NewSongHandler(mockRepo, mockAPI))
+	RegisterAdminRoutes(api, NewAdminHandler())
+	return r
+}
+
+func TestRegisterSongRoutes_Authorization(t *testing.T) {
+	r := setupAuthTest()
+
+	createBody, _ := json.Marshal(models.CreateSongRequest{Group: "Muse", Song: "Uprising"})
+	updateBody, _ := json.Marshal(models.Song{Group: "Muse", Name: "Uprising"})
+	levelBody, _ := json.Marshal(LogLevelRequest{Level: "info"})
+
+	routes := []struct {
+		name    string
+		method  string
+		path    string
+		body    []byte
+		allowed map[auth.Role]bool
+	}{
+		{"List", "GET", "/api/v1/song", nil,
+			map[auth.Role]bool{auth.RoleReader: true, auth.RoleEditor: true, auth.RoleAdmin: true}},
+		{"GetText", "GET", "/api/v1/song/1/text", nil,
+			map[auth.Role]bool{auth.RoleReader: true, auth.RoleEditor: true, auth.RoleAdmin: true}},
+		{"Create", "POST", "/api/v1/song", createBody,
+			map[auth.Role]bool{auth.RoleEditor: true, auth.RoleAdmin: true}},
+		{"Update", "PUT", "/api/v1/song/1", updateBody,
+			map[auth.Role]bool{auth.RoleEditor: true, auth.RoleAdmin: true}},
+		{"Delete", "DELETE", "/api/v1/song/1", nil,
+			map[auth.Role]bool{auth.RoleAdmin: true}},
+		{"GetLogLevel", "GET", "/api/v1/admin/log-level", nil,
+			map[auth.Role]bool{auth.RoleAdmin: true}},
+		{"SetLogLevel", "PUT", "/api/v1/admin/log-level", levelBody,
+			map[auth.Role]bool{auth.RoleAdmin: true}},
+	}
+
+	roles := map[auth.Role]string{
+		auth.RoleReader: "reader-key",
+		auth.RoleEditor: "editor-key",
+		auth.RoleAdmin:  "admin-key",
+	}
+
+	for _, route := range routes {
+		for role, key := range roles {
+			t.Run(route.name+" as "+string(role), func(t *testing.T) {
+				w := httptest.NewRecorder()
+				req := httptest.NewRequest(route.method, route.path, bytes.NewBuffer(route.body))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("X-API-Key", key)
+				r.ServeHTTP(w, req)
+
+				if route.allowed[role] {
+					assert.Less(t, w.Code, 400)
+					return
+				}
+
+				assert.Equal(t, http.StatusForbidden, w.Code)
+				var response map[string]interface{}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, "Forbidden", response["error"])
+			})
+		}
+
+		t.Run(route.name+" without key", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(route.method, route.path, bytes.NewBuffer(route.body))
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+		})
+	}
+
+	t.Run("Bearer token", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/api/v1/song", nil)
+		req.Header.Set("Authorization", "Bearer reader-key")
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
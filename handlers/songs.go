@@ -1,12 +1,19 @@
 package handlers
 
 import (
+	"awesomeProject/links"
 	"awesomeProject/logger"
+	"awesomeProject/middleware"
 	"awesomeProject/models"
 	"awesomeProject/repositories"
 	"awesomeProject/services"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -30,27 +37,64 @@ func NewSongHandler(repo repositories.SongRepository, api services.MusicAPIServi
 // @Param group query string false "Filter by group"
 // @Param song query string false "Filter by song name"
 // @Param releaseDate query string false "Filter by release date"
+// @Param tags query string false "Comma-separated tags"
+// @Param tagMode query string false "Match all (default) or any of the tags"
+// @Param genre query string false "Filter by genre, including its sub-genres"
+// @Param person query string false "Filter by credited person"
+// @Param role query string false "Only count credits of person in this role"
+// @Param provider query string false "Filter by link provider"
+// @Param linkState query string false "Filter by link check state: unchecked, ok or broken"
 // @Success 200 {object} models.Song
 // @Router /songs [get]
 func (h *SongHandler) List(c *gin.Context) {
+	log := middleware.Logger(c)
+
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
 
+	tagMode := c.Query("tagMode")
+	if tagMode != "" && tagMode != repositories.TagModeAll && tagMode != repositories.TagModeAny {
+		middleware.RespondError(c, 400, "tagMode must be all or any")
+		return
+	}
+	role := c.Query("role")
+	if role != "" && (c.Query("person") == "" || !models.ValidCreditRole(role)) {
+		middleware.RespondError(c, 400, "role must be a known credit role and needs person")
+		return
+	}
+	provider := c.Query("provider")
+	if provider != "" && !models.ValidProvider(provider) {
+		middleware.RespondError(c, 400, "provider must be one of "+strings.Join(models.Providers, ", "))
+		return
+	}
+	linkState := c.Query("linkState")
+	if linkState != "" && !slices.Contains(models.LinkStates, linkState) {
+		middleware.RespondError(c, 400, "linkState must be one of "+strings.Join(models.LinkStates, ", "))
+		return
+	}
+
 	filters := map[string]string{
 		"group":       c.Query("group"),
 		"song":        c.Query("song"),
 		"releaseDate": c.Query("releaseDate"),
 		"link":        c.Query("link"),
+		"tags":        c.Query("tags"),
+		"tagMode":     tagMode,
+		"genre":       c.Query("genre"),
+		"person":      c.Query("person"),
+		"role":        role,
+		"provider":    provider,
+		"linkState":   linkState,
 	}
 
-	songs, total, err := h.songRepo.List(page, limit, filters)
+	songs, total, err := h.songRepo.List(c.Request.Context(), page, limit, filters)
 	if err != nil {
-		logger.Info("Failed to fetch songs", zap.Error(err))
-		c.JSON(500, gin.H{"error": "Failed to fetch songs"})
+		log.Info("Failed to fetch songs", zap.Error(err))
+		middleware.RespondError(c, 500, "Failed to fetch songs")
 		return
 	}
 
-	logger.Debug("Successfully fetched songs",
+	log.Debug("Successfully fetched songs",
 		zap.Int("count", len(songs)),
 		zap.Int("page", page),
 		zap.Int("limit", limit))
@@ -62,10 +106,12 @@ func (h *SongHandler) List(c *gin.Context) {
 }
 
 func (h *SongHandler) GetText(c *gin.Context) {
-	song, err := h.songRepo.GetByID(c.Param("id"))
+	log := middleware.Logger(c)
+
+	song, err := h.songRepo.GetByID(c.Request.Context(), c.Param("id"))
 	if err != nil {
-		logger.Info("Song not found", zap.Error(err))
-		c.JSON(404, gin.H{"error": "Song not found"})
+		log.Info("Song not found", zap.Error(err))
+		middleware.RespondError(c, 404, "Song not found")
 		return
 	}
 
@@ -79,7 +125,7 @@ func (h *SongHandler) GetText(c *gin.Context) {
 		end = len(verses)
 	}
 
-	logger.Debug("Fetching song text",
+	log.Debug("Fetching song text",
 		zap.Int("songId", int(song.ID)),
 		zap.Int("page", page),
 		zap.Int("limit", limit))
@@ -91,69 +137,163 @@ func (h *SongHandler) GetText(c *gin.Context) {
 }
 
 func (h *SongHandler) Create(c *gin.Context) {
+	log := middleware.Logger(c)
+
 	var req models.CreateSongRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		logger.Info("Invalid request", zap.Error(err))
-		c.JSON(400, gin.H{"error": err.Error()})
+		log.Info("Invalid request", zap.Error(err))
+		middleware.RespondError(c, 400, err.Error())
+		return
+	}
+	extra := make([]models.SongLink, len(req.Links))
+	for i, raw := range req.Links {
+		extra[i].URL = raw
+	}
+	if err := validateLinks("", extra); err != nil {
+		log.Info("Invalid request", zap.Error(err))
+		middleware.RespondError(c, 400, err.Error())
 		return
 	}
 
-	details, err := h.musicAPI.GetSongInfo(req.Group, req.Song)
+	song, err := h.create(c.Request.Context(), req, models.SongDetail{})
 	if err != nil {
-		logger.Info("Failed to fetch song details", zap.Error(err))
-		c.JSON(500, gin.H{"error": "Failed to fetch song details"})
+		if errors.Is(err, errSongDetails) {
+			middleware.RespondError(c, 500, "Failed to fetch song details")
+			return
+		}
+		middleware.RespondError(c, 500, "Failed to create song")
 		return
 	}
+	c.JSON(201, song)
+}
+
+// errSongDetails marks a failure to fetch a new song's details from the
+// music API.
+var errSongDetails = errors.New("failed to fetch song details")
+
+// create fills in a new song's details from the music API and stores it.
+// Non-empty fields of known, such as tags read from an audio file, take
+// precedence over the API's. req.Links must already be valid.
+func (h *SongHandler) create(ctx context.Context, req models.CreateSongRequest, known models.SongDetail) (*models.Song, error) {
+	log := logger.FromContext(ctx)
+
+	details, err := h.musicAPI.GetSongInfo(ctx, req.Group, req.Song)
+	if err != nil {
+		log.Info("Failed to fetch song details", zap.Error(err))
+		return nil, fmt.Errorf("%w: %w", errSongDetails, err)
+	}
 
 	song := models.Song{
 		Group:       req.Group,
 		Name:        req.Song,
-		ReleaseDate: details.ReleaseDate,
-		Text:        details.Text,
-		Link:        details.Link,
+		ReleaseDate: cmp.Or(known.ReleaseDate, details.ReleaseDate),
+		Text:        cmp.Or(known.Text, details.Text),
+		Links:       make([]models.SongLink, len(req.Links)),
+	}
+	for i, raw := range req.Links {
+		song.Links[i].URL = raw
 	}
 
-	if err := h.songRepo.Create(&song); err != nil {
-		logger.Info("Failed to create song", zap.Error(err))
-		c.JSON(500, gin.H{"error": "Failed to create song"})
-		return
+	song.Link = details.Link
+	if song.Link != "" {
+		if err := validateLinks(song.Link, song.Links); err != nil {
+			log.Warn("Ignoring link from music API", zap.String("link", song.Link), zap.Error(err))
+			song.Link = ""
+		}
 	}
 
-	logger.Debug("Song created successfully", zap.String("group", song.Group), zap.String("name", song.Name))
-	c.JSON(201, song)
+	if err := h.songRepo.Create(ctx, &song); err != nil {
+		log.Info("Failed to create song", zap.Error(err))
+		return nil, err
+	}
+
+	log.Debug("Song created successfully", zap.String("group", song.Group), zap.String("name", song.Name))
+	return &song, nil
 }
 
 func (h *SongHandler) Update(c *gin.Context) {
-	song, err := h.songRepo.GetByID(c.Param("id"))
+	log := middleware.Logger(c)
+
+	// Read the row being modified from the primary so a lagging replica
+	// cannot resurrect stale fields.
+	song, err := h.songRepo.GetByID(repositories.ReadFromPrimary(c.Request.Context()), c.Param("id"))
 	if err != nil {
-		logger.Info("Song not found", zap.Error(err))
-		c.JSON(404, gin.H{"error": "Song not found"})
+		log.Info("Song not found", zap.Error(err))
+		middleware.RespondError(c, 404, "Song not found")
 		return
 	}
 
+	// Tags, genres, credits and stats have their own endpoints; keep them
+	// out of the bind.
+	tags, genres, credits, stats := song.Tags, song.Genres, song.Credits, song.Stats
+	storedLink, storedLinks := song.Link, song.Links
+	song.Links = nil
 	if err := c.ShouldBindJSON(song); err != nil {
-		logger.Info("Invalid request", zap.Error(err))
-		c.JSON(400, gin.H{"error": err.Error()})
+		log.Info("Invalid request", zap.Error(err))
+		middleware.RespondError(c, 400, err.Error())
+		return
+	}
+	song.Tags, song.Genres, song.Credits, song.Stats = tags, genres, credits, stats
+	if song.Links == nil {
+		// Without "links" the stored ones stay.
+		link := song.Link
+		song.Link, song.Links = storedLink, storedLinks
+		replacePrimaryLink(song, link)
+	}
+	if err := validateLinks(song.Link, song.Links); err != nil {
+		log.Info("Invalid request", zap.Error(err))
+		middleware.RespondError(c, 400, err.Error())
 		return
 	}
 
-	if err := h.songRepo.Update(song); err != nil {
-		logger.Info("Failed to update song", zap.Error(err))
-		c.JSON(500, gin.H{"error": "Failed to update song"})
+	if err := h.songRepo.Update(c.Request.Context(), song); err != nil {
+		log.Info("Failed to update song", zap.Error(err))
+		middleware.RespondError(c, 500, "Failed to update song")
 		return
 	}
 
-	logger.Debug("Song updated successfully", zap.Uint("id", song.ID))
+	log.Debug("Song updated successfully", zap.Uint("id", song.ID))
 	c.JSON(200, song)
 }
 
 func (h *SongHandler) Delete(c *gin.Context) {
-	if err := h.songRepo.Delete(c.Param("id")); err != nil {
-		logger.Info("Failed to delete song", zap.Error(err))
-		c.JSON(500, gin.H{"error": "Failed to delete song"})
+	log := middleware.Logger(c)
+
+	if err := h.songRepo.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		log.Info("Failed to delete song", zap.Error(err))
+		middleware.RespondError(c, 500, "Failed to delete song")
 		return
 	}
 
-	logger.Debug("Song deleted successfully", zap.String("id", c.Param("id")))
+	log.Debug("Song deleted successfully", zap.String("id", c.Param("id")))
 	c.Status(204)
 }
+
+// replacePrimaryLink makes link the primary link of song. The link it
+// replaces is dropped from song.Links rather than kept as a secondary one.
+func replacePrimaryLink(song *models.Song, link string) {
+	if link == song.Link {
+		return
+	}
+	old := song.Link
+	song.Links = slices.DeleteFunc(slices.Clone(song.Links), func(l models.SongLink) bool { return l.URL == old })
+	song.Link = link
+}
+
+// validateLinks rejects links that are not absolute http(s) URLs and more
+// links than a song may have once merged as stored. Blank links are allowed;
+// they are dropped.
+func validateLinks(primary string, list []models.SongLink) error {
+	for _, link := range append([]models.SongLink{{URL: primary}}, list...) {
+		if link.URL == "" {
+			continue
+		}
+		if err := links.Validate(link.URL); err != nil {
+			return fmt.Errorf("invalid link %q: %w", link.URL, err)
+		}
+	}
+	if len(links.Merge(primary, list)) > models.MaxSongLinks {
+		return fmt.Errorf("a song may have at most %d links", models.MaxSongLinks)
+	}
+	return nil
+}
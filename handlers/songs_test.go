@@ -6,13 +6,16 @@ import (
 	"awesomeProject/repositories"
 	"awesomeProject/services"
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -20,12 +23,17 @@ type MockSongRepository struct {
 	mock.Mock
 }
 
-func (m *MockSongRepository) List(page int, limit int, filters map[string]string) ([]models.Song, int64, error) {
+func (m *MockSongRepository) List(ctx context.Context, page int, limit int, filters map[string]string) ([]models.Song, int64, error) {
 	args := m.Called(page, limit, filters)
 	return args.Get(0).([]models.Song), args.Get(1).(int64), args.Error(2)
 }
 
-func (m *MockSongRepository) GetByID(id string) (*models.Song, error) {
+func (m *MockSongRepository) Search(ctx context.Context, rule models.Rule, page, limit int) ([]models.Song, int64, error) {
+	args := m.Called(rule, page, limit)
+	return args.Get(0).([]models.Song), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockSongRepository) GetByID(ctx context.Context, id string) (*models.Song, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -33,17 +41,17 @@ func (m *MockSongRepository) GetByID(id string) (*models.Song, error) {
 	return args.Get(0).(*models.Song), args.Error(1)
 }
 
-func (m *MockSongRepository) Create(song *models.Song) error {
+func (m *MockSongRepository) Create(ctx context.Context, song *models.Song) error {
 	args := m.Called(song)
 	return args.Error(0)
 }
 
-func (m *MockSongRepository) Update(song *models.Song) error {
+func (m *MockSongRepository) Update(ctx context.Context, song *models.Song) error {
 	args := m.Called(song)
 	return args.Error(0)
 }
 
-func (m *MockSongRepository) Delete(id string) error {
+func (m *MockSongRepository) Delete(ctx context.Context, id string) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
@@ -52,7 +60,7 @@ type MockMusicAPIService struct {
 	mock.Mock
 }
 
-func (m *MockMusicAPIService) GetSongInfo(group, song string) (*models.SongDetail, error) {
+func (m *MockMusicAPIService) GetSongInfo(ctx context.Context, group, song string) (*models.SongDetail, error) {
 	args := m.Called(group, song)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -103,6 +111,13 @@ func TestSongHandler_List(t *testing.T) {
 			"song":        "",
 			"releaseDate": "",
 			"link":        "",
+			"tags":        "",
+			"tagMode":     "",
+			"genre":       "",
+			"person":      "",
+			"role":        "",
+			"provider":    "",
+			"linkState":   "",
 		}).Return(testSongs, int64(2), nil).Once()
 
 		w := httptest.NewRecorder()
@@ -126,6 +141,13 @@ func TestSongHandler_List(t *testing.T) {
 			"song":        "",
 			"releaseDate": "",
 			"link":        "",
+			"tags":        "",
+			"tagMode":     "",
+			"genre":       "",
+			"person":      "",
+			"role":        "",
+			"provider":    "",
+			"linkState":   "",
 		}).Return(filteredSongs, int64(1), nil).Once()
 
 		w := httptest.NewRecorder()
@@ -142,6 +164,80 @@ func TestSongHandler_List(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 
+	t.Run("Filter by tags and genre", func(t *testing.T) {
+		mockRepo.On("List", 1, 10, map[string]string{
+			"group":       "",
+			"song":        "",
+			"releaseDate": "",
+			"link":        "",
+			"tags":        "rock,live",
+			"tagMode":     "any",
+			"genre":       "Punk",
+			"person":      "",
+			"role":        "",
+			"provider":    "",
+			"linkState":   "",
+		}).Return(testSongs, int64(2), nil).Once()
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/api/v1/song?tags=rock,live&tagMode=any&genre=Punk", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Invalid tag mode", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/api/v1/song?tags=rock&tagMode=some", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Filter by credited person", func(t *testing.T) {
+		mockRepo.On("List", 1, 10, map[string]string{
+			"group":       "",
+			"song":        "",
+			"releaseDate": "",
+			"link":        "",
+			"tags":        "",
+			"tagMode":     "",
+			"genre":       "",
+			"person":      "Brian May",
+			"role":        "composer",
+			"provider":    "",
+			"linkState":   "",
+		}).Return(testSongs[1:], int64(1), nil).Once()
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/api/v1/song?person=Brian+May&role=composer", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Invalid role", func(t *testing.T) {
+		for _, query := range []string{"person=Brian+May&role=drummer", "role=composer"} {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/api/v1/song?"+query, nil)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+
+	t.Run("Invalid provider or link state", func(t *testing.T) {
+		for _, query := range []string{"provider=napster", "linkState=dead"} {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/api/v1/song?"+query, nil)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+
 	t.Run("Database error", func(t *testing.T) {
 		mockRepo.On("List", 1, 10, mock.Anything).
 			Return([]models.Song{}, int64(0), errors.New("database error")).Once()
@@ -204,6 +300,116 @@ func TestSongHandler_Create(t *testing.T) {
 
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
+
+	t.Run("Extra links are validated", func(t *testing.T) {
+		body, _ := json.Marshal(models.CreateSongRequest{Group: "Muse", Song: "Uprising", Links: []string{"youtube.com/watch"}})
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/song", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Invalid link from the music API is dropped", func(t *testing.T) {
+		mockAPI.On("GetSongInfo", "Muse", "Starlight").
+			Return(&models.SongDetail{Text: "Far away", Link: "<a>not a link</a>"}, nil).Once()
+		mockRepo.On("Create", mock.MatchedBy(func(s *models.Song) bool {
+			return s.Name == "Starlight" && s.Link == "" && len(s.Links) == 1 && s.Links[0].URL == "https://youtu.be/dQw4w9WgXcQ"
+		})).Return(nil).Once()
+
+		body, _ := json.Marshal(models.CreateSongRequest{Group: "Muse", Song: "Starlight", Links: []string{"https://youtu.be/dQw4w9WgXcQ"}})
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/song", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestSongHandler_Update_ValidatesLinks(t *testing.T) {
+	mockRepo, _, r := setupTest()
+
+	for _, body := range []string{
+		`{"group": "Muse", "name": "Uprising", "link": "not a url"}`,
+		`{"group": "Muse", "name": "Uprising", "links": [{"url": "ftp://example.com/uprising"}]}`,
+	} {
+		mockRepo.On("GetByID", "1").Return(&models.Song{ID: 1, Group: "Muse", Name: "Uprising"}, nil).Once()
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("PUT", "/api/v1/song/1", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+func TestSongHandler_Update_Links(t *testing.T) {
+	stored := func() *models.Song {
+		return &models.Song{ID: 1, Group: "Muse", Name: "Uprising", Link: "https://youtu.be/old", Links: []models.SongLink{
+			{URL: "https://youtu.be/old"}, {URL: "https://open.spotify.com/track/uprising"},
+		}}
+	}
+	put := func(r *gin.Engine, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("PUT", "/api/v1/song/1", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+	updatedLinks := func(mockRepo *MockSongRepository) []string {
+		song := mockRepo.Calls[len(mockRepo.Calls)-1].Arguments.Get(0).(*models.Song)
+		var urls []string
+		for _, link := range song.Links {
+			urls = append(urls, link.URL)
+		}
+		return urls
+	}
+
+	t.Run("Change primary link only", func(t *testing.T) {
+		mockRepo, _, r := setupTest()
+		mockRepo.On("GetByID", "1").Return(stored(), nil).Once()
+		mockRepo.On("Update", mock.Anything).Return(nil).Once()
+
+		w := put(r, `{"group": "Muse", "name": "Uprising", "link": "https://youtu.be/new"}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []string{"https://open.spotify.com/track/uprising"}, updatedLinks(mockRepo), "the old primary link is dropped")
+	})
+
+	t.Run("Unchanged primary link keeps the stored links", func(t *testing.T) {
+		mockRepo, _, r := setupTest()
+		mockRepo.On("GetByID", "1").Return(stored(), nil).Once()
+		mockRepo.On("Update", mock.Anything).Return(nil).Once()
+
+		assert.Equal(t, http.StatusOK, put(r, `{"group": "Muse", "name": "Uprising (Live)"}`).Code)
+		assert.Equal(t, []string{"https://youtu.be/old", "https://open.spotify.com/track/uprising"}, updatedLinks(mockRepo))
+	})
+
+	t.Run("Explicit links replace the stored ones", func(t *testing.T) {
+		mockRepo, _, r := setupTest()
+		mockRepo.On("GetByID", "1").Return(stored(), nil).Once()
+		mockRepo.On("Update", mock.Anything).Return(nil).Once()
+
+		assert.Equal(t, http.StatusOK, put(r, `{"group": "Muse", "name": "Uprising", "links": [{"url": "https://example.com/uprising"}]}`).Code)
+		assert.Equal(t, []string{"https://example.com/uprising"}, updatedLinks(mockRepo))
+	})
+
+	t.Run("The cap counts the primary link", func(t *testing.T) {
+		mockRepo, _, r := setupTest()
+		mockRepo.On("GetByID", "1").Return(stored(), nil).Once()
+
+		list := make([]string, models.MaxSongLinks)
+		for i := range list {
+			list[i] = fmt.Sprintf(`{"url": "https://example.com/%d"}`, i)
+		}
+		w := put(r, `{"group": "Muse", "name": "Uprising", "link": "https://youtu.be/new", "links": [`+strings.Join(list, ",")+`]}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+	})
 }
 
 func TestSongHandler_GetText(t *testing.T) {
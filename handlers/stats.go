@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"awesomeProject/middleware"
+	"awesomeProject/repositories"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type StatsHandler struct {
+	repo repositories.StatsRepository
+}
+
+func NewStatsHandler(repo repositories.StatsRepository) *StatsHandler {
+	return &StatsHandler{repo: repo}
+}
+
+// @Summary Library statistics
+// @Description Song counts by artist, release year and decade, missing fields and enrichment coverage
+// @Tags stats
+// @Produce json
+// @Success 200 {object} models.LibraryStats
+// @Router /stats [get]
+func (h *StatsHandler) Get(c *gin.Context) {
+	stats, err := h.repo.LibraryStats(c.Request.Context())
+	if err != nil {
+		middleware.Logger(c).Info("Failed to compute stats", zap.Error(err))
+		middleware.RespondError(c, 500, "Failed to compute stats")
+		return
+	}
+	c.JSON(200, stats)
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"awesomeProject/auth"
+	"awesomeProject/logger"
+	"awesomeProject/middleware"
+	"awesomeProject/models"
+	"awesomeProject/repositories"
+	"awesomeProject/tenant"
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestStatsHandler(t *testing.T) {
+	logger.Init()
+	gin.SetMode(gin.TestMode)
+
+	songs := repositories.NewMemorySongRepository()
+	ctx := tenant.NewContext(context.Background(), tenant.Default)
+	require.NoError(t, songs.Create(ctx, &models.Song{Group: "Muse", Name: "Uprising", ReleaseDate: "16.07.2009"}))
+
+	keys := auth.KeyStore{"reader-key": {Subject: "reader", Role: auth.RoleReader}}
+	r := gin.New()
+	api := r.Group("", middleware.Authenticate(keys), middleware.Tenant())
+	RegisterStatsRoutes(api, NewStatsHandler(repositories.NewCachedStatsRepository(repositories.NewMemoryStatsRepository(songs), time.Minute)))
+
+	w := doJSON(r, "GET", "/api/v1/stats", "reader-key", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var stats models.LibraryStats
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	assert.Equal(t, int64(1), stats.Songs)
+	assert.Equal(t, []models.StatsCount{{Key: "2000s", Count: 1}}, stats.ByDecade)
+
+	require.NoError(t, songs.Create(ctx, &models.Song{Group: "Queen", Name: "Bicycle"}))
+	w = doJSON(r, "GET", "/api/v1/stats", "reader-key", nil)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	assert.Equal(t, int64(1), stats.Songs, "cached within the TTL")
+}
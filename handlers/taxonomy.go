@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"awesomeProject/middleware"
+	"awesomeProject/models"
+	"awesomeProject/repositories"
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"strconv"
+)
+
+type TaxonomyHandler struct {
+	repo repositories.TaxonomyRepository
+}
+
+func NewTaxonomyHandler(repo repositories.TaxonomyRepository) *TaxonomyHandler {
+	return &TaxonomyHandler{repo: repo}
+}
+
+func (h *TaxonomyHandler) Tags(c *gin.Context) {
+	counts, err := h.repo.TagCounts(c.Request.Context())
+	if err != nil {
+		h.respondError(c, err, "Failed to fetch tags")
+		return
+	}
+	c.JSON(200, gin.H{"items": counts})
+}
+
+func (h *TaxonomyHandler) ApplyTags(c *gin.Context) {
+	h.bulkTag(c, h.repo.TagSongs, "Failed to tag songs")
+}
+
+func (h *TaxonomyHandler) RemoveTags(c *gin.Context) {
+	h.bulkTag(c, h.repo.UntagSongs, "Failed to untag songs")
+}
+
+func (h *TaxonomyHandler) bulkTag(c *gin.Context, apply func(ctx context.Context, songIDs []uint, tags []string) error, msg string) {
+	var req models.BulkTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.Logger(c).Info("Invalid request", zap.Error(err))
+		middleware.RespondError(c, 400, err.Error())
+		return
+	}
+
+	if err := apply(c.Request.Context(), req.SongIDs, req.Tags); err != nil {
+		h.respondError(c, err, msg)
+		return
+	}
+	c.Status(204)
+}
+
+func (h *TaxonomyHandler) Genres(c *gin.Context) {
+	genres, err := h.repo.Genres(c.Request.Context())
+	if err != nil {
+		h.respondError(c, err, "Failed to fetch genres")
+		return
+	}
+	c.JSON(200, gin.H{"items": genres})
+}
+
+func (h *TaxonomyHandler) CreateGenre(c *gin.Context) {
+	var req models.GenreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.Logger(c).Info("Invalid request", zap.Error(err))
+		middleware.RespondError(c, 400, err.Error())
+		return
+	}
+
+	genre := models.Genre{Name: req.Name, ParentID: req.ParentID}
+	if err := h.repo.CreateGenre(c.Request.Context(), &genre); err != nil {
+		h.respondError(c, err, "Failed to create genre")
+		return
+	}
+	c.JSON(201, genre)
+}
+
+func (h *TaxonomyHandler) DeleteGenre(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 0)
+	if err != nil {
+		middleware.RespondError(c, 404, "Genre not found")
+		return
+	}
+
+	if err := h.repo.DeleteGenre(c.Request.Context(), uint(id)); err != nil {
+		h.respondError(c, err, "Failed to delete genre")
+		return
+	}
+	c.Status(204)
+}
+
+func (h *TaxonomyHandler) SetSongGenres(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 0)
+	if err != nil {
+		middleware.RespondError(c, 404, "Song not found")
+		return
+	}
+
+	var req models.SetGenresRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.Logger(c).Info("Invalid request", zap.Error(err))
+		middleware.RespondError(c, 400, err.Error())
+		return
+	}
+
+	if err := h.repo.SetSongGenres(c.Request.Context(), uint(id), req.GenreIDs); err != nil {
+		h.respondError(c, err, "Failed to set genres")
+		return
+	}
+	c.Status(204)
+}
+
+func (h *TaxonomyHandler) respondError(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		middleware.RespondError(c, 404, "Genre not found")
+	case errors.Is(err, repositories.ErrSongNotFound), errors.Is(err, repositories.ErrGenreNotFound):
+		middleware.RespondError(c, 404, err.Error())
+	case errors.Is(err, repositories.ErrGenreExists), errors.Is(err, repositories.ErrGenreHasChildren):
+		middleware.RespondError(c, 409, err.Error())
+	default:
+		middleware.Logger(c).Info(msg, zap.Error(err))
+		middleware.RespondError(c, 500, msg)
+	}
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"awesomeProject/auth"
+	"awesomeProject/logger"
+	"awesomeProject/middleware"
+	"awesomeProject/models"
+	"awesomeProject/repositories"
+	"awesomeProject/tenant"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func setupTaxonomyTest(t *testing.T) (*gin.Engine, []models.Song) {
+	logger.Init()
+	gin.SetMode(gin.TestMode)
+
+	songs := repositories.NewMemorySongRepository()
+	ctx := tenant.NewContext(context.Background(), tenant.Default)
+	seeded := []models.Song{{Group: "Muse", Name: "Uprising"}, {Group: "Queen", Name: "Bicycle"}}
+	for i := range seeded {
+		require.NoError(t, songs.Create(ctx, &seeded[i]))
+	}
+
+	keys := auth.KeyStore{
+		"reader-key": {Subject: "reader", Role: auth.RoleReader},
+		"editor-key": {Subject: "editor", Role: auth.RoleEditor},
+		"admin-key":  {Subject: "admin", Role: auth.RoleAdmin},
+	}
+
+	r := gin.New()
+	api := r.Group("", middleware.Authenticate(keys), middleware.Tenant())
+	RegisterTaxonomyRoutes(api, NewTaxonomyHandler(repositories.NewMemoryTaxonomyRepository(songs)))
+	return r, seeded
+}
+
+func TestTaxonomyHandler_Tags(t *testing.T) {
+	r, songs := setupTaxonomyTest(t)
+	ids := []uint{songs[0].ID, songs[1].ID}
+
+	w := doJSON(r, "POST", "/api/v1/tags/apply", "reader-key", models.BulkTagRequest{SongIDs: ids, Tags: []string{"rock"}})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = doJSON(r, "POST", "/api/v1/tags/apply", "editor-key", models.BulkTagRequest{SongIDs: ids, Tags: []string{"Rock", "live"}})
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+
+	w = doJSON(r, "POST", "/api/v1/tags/apply", "editor-key", models.BulkTagRequest{SongIDs: []uint{ids[0], 999}, Tags: []string{"demo"}})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = doJSON(r, "POST", "/api/v1/tags/apply", "editor-key", models.BulkTagRequest{SongIDs: ids})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doJSON(r, "POST", "/api/v1/tags/remove", "editor-key", models.BulkTagRequest{SongIDs: ids[:1], Tags: []string{"live"}})
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	w = doJSON(r, "GET", "/api/v1/tags", "reader-key", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var response struct{ Items []models.TagCount }
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, []models.TagCount{{Name: "rock", Count: 2}, {Name: "live", Count: 1}}, response.Items)
+}
+
+func TestTaxonomyHandler_Genres(t *testing.T) {
+	r, songs := setupTaxonomyTest(t)
+
+	w := doJSON(r, "POST", "/api/v1/genres", "editor-key", models.GenreRequest{Name: "Rock"})
+	assert.Equal(t, http.StatusForbidden, w.Code, "only admins curate genres")
+
+	w = doJSON(r, "POST", "/api/v1/genres", "admin-key", models.GenreRequest{Name: "Rock"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var rock models.Genre
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rock))
+
+	w = doJSON(r, "POST", "/api/v1/genres", "admin-key", models.GenreRequest{Name: "rock"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = doJSON(r, "POST", "/api/v1/genres", "admin-key", models.GenreRequest{Name: "Punk", ParentID: &rock.ID})
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	missing := uint(999)
+	w = doJSON(r, "POST", "/api/v1/genres", "admin-key", models.GenreRequest{Name: "Ska", ParentID: &missing})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	path := fmt.Sprintf("/api/v1/song/%d/genres", songs[0].ID)
+	w = doJSON(r, "PUT", path, "editor-key", models.SetGenresRequest{GenreIDs: []uint{rock.ID}})
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = doJSON(r, "PUT", path, "editor-key", models.SetGenresRequest{GenreIDs: []uint{999}})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doJSON(r, "PUT", "/api/v1/song/999/genres", "editor-key", models.SetGenresRequest{GenreIDs: []uint{rock.ID}})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = doJSON(r, "DELETE", fmt.Sprintf("/api/v1/genres/%d", rock.ID), "admin-key", nil)
+	assert.Equal(t, http.StatusConflict, w.Code, "genres with sub-genres cannot be deleted")
+	w = doJSON(r, "DELETE", "/api/v1/genres/999", "admin-key", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = doJSON(r, "GET", "/api/v1/genres", "reader-key", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var response struct{ Items []models.Genre }
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Items, 2)
+	assert.Equal(t, "Punk", response.Items[0].Name)
+	assert.Equal(t, &rock.ID, response.Items[0].ParentID)
+}
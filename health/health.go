@@ -0,0 +1,114 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+)
+
+type Check struct {
+	Name string
+	// Critical checks gate readiness; the others only degrade the report.
+	Critical bool
+	Run      func(ctx context.Context) error
+}
+
+type Result struct {
+	Status    string  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMs float64 `json:"latencyMs"`
+	// Error is for logs only: it can name hosts, DSNs and driver details.
+	Error string `json:"-"`
+}
+
+type Report struct {
+	Status       string            `json:"status"`
+	ShuttingDown bool              `json:"shuttingDown"`
+	Checks       map[string]Result `json:"checks"`
+}
+
+type Checker struct {
+	checks       []Check
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+}
+
+func NewChecker(timeout time.Duration, checks ...Check) *Checker {
+	return &Checker{checks: checks, timeout: timeout}
+}
+
+// SetShuttingDown makes readiness fail so the orchestrator stops routing
+// traffic while in-flight requests drain.
+func (c *Checker) SetShuttingDown() {
+	c.shuttingDown.Store(true)
+}
+
+func (c *Checker) ShuttingDown() bool {
+	return c.shuttingDown.Load()
+}
+
+// Run executes all checks concurrently, each bounded by the checker timeout.
+func (c *Checker) Run(ctx context.Context) Report {
+	report := Report{
+		Status:       StatusOK,
+		ShuttingDown: c.ShuttingDown(),
+		Checks:       make(map[string]Result, len(c.checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check.Run(checkCtx)
+			result := Result{
+				Status:    StatusUp,
+				Critical:  check.Critical,
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				result.Status = StatusDown
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			report.Checks[check.Name] = result
+			mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status == StatusUp {
+			continue
+		}
+		if result.Critical {
+			report.Status = StatusDown
+			break
+		}
+		report.Status = StatusDegraded
+	}
+	if report.ShuttingDown {
+		report.Status = StatusDown
+	}
+	return report
+}
+
+// Ready reports whether all critical checks pass and no shutdown is pending.
+func (r Report) Ready() bool {
+	return r.Status != StatusDown
+}
@@ -0,0 +1,205 @@
+// Package ingest implements the ingest subcommand, which uploads audio files
+// to a running server's ingest endpoint and prints what became of each.
+package ingest
+
+import (
+	"awesomeProject/handlers"
+	"awesomeProject/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Extensions lists the file types collected from directories.
+var Extensions = []string{".mp3", ".flac", ".ogg", ".oga", ".opus"}
+
+// HeadSize is how much of each file is uploaded. Tags sit at the start of
+// the file, so the audio after them is not sent.
+const HeadSize = 16 << 20
+
+// Client uploads files to the ingest endpoint of a server.
+type Client struct {
+	BaseURL string
+	APIKey  string
+	Tenant  string
+	HTTP    *http.Client
+}
+
+// Upload sends the files in one request and returns the server's report.
+func (c *Client) Upload(ctx context.Context, paths []string) (models.IngestReport, error) {
+	body, form := io.Pipe()
+	writer := multipart.NewWriter(form)
+	go func() {
+		form.CloseWithError(writeFiles(writer, paths))
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", strings.TrimRight(c.BaseURL, "/")+"/api/v1/song/ingest", body)
+	if err != nil {
+		body.Close()
+		return models.IngestReport{}, err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("X-API-Key", c.APIKey)
+	if c.Tenant != "" {
+		req.Header.Set("X-Tenant-ID", c.Tenant)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return models.IngestReport{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct{ Error string }
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		return models.IngestReport{}, fmt.Errorf("server answered %s: %s", resp.Status, apiErr.Error)
+	}
+	var report models.IngestReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return models.IngestReport{}, fmt.Errorf("decode report: %w", err)
+	}
+	if len(report.Items) != len(paths) {
+		return models.IngestReport{}, fmt.Errorf("server reported %d files, sent %d", len(report.Items), len(paths))
+	}
+	return report, nil
+}
+
+func writeFiles(writer *multipart.Writer, paths []string) error {
+	for _, path := range paths {
+		if err := writeFile(writer, path); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+func writeFile(writer *multipart.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	part, err := writer.CreateFormFile("files", filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(part, io.LimitReader(f, HeadSize))
+	return err
+}
+
+// Collect expands directories into the audio files below them, in lexical
+// order. Named files are taken whatever their extension; other files found
+// in directories are returned as skipped.
+func Collect(paths []string) (files []string, skipped []models.IngestResult, err error) {
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			switch {
+			case d.IsDir():
+			case p == path || slices.Contains(Extensions, strings.ToLower(filepath.Ext(p))):
+				files = append(files, p)
+			default:
+				skipped = append(skipped, models.IngestResult{File: p, Status: models.IngestSkipped, Reason: "not an audio file"})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return files, skipped, nil
+}
+
+// Run executes the ingest subcommand and returns the process exit code: 0
+// when every file was created or skipped, 1 when some failed and 2 on
+// usage errors.
+func Run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("ingest", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: ingest [flags] file-or-directory...")
+		flags.PrintDefaults()
+	}
+	baseURL := flags.String("url", envOr("MUSIC_LIBRARY_URL", "http://localhost:8081"), "server base URL")
+	apiKey := flags.String("key", os.Getenv("MUSIC_LIBRARY_API_KEY"), "API key with write access")
+	tenant := flags.String("tenant", "", "tenant to add the songs to")
+	batch := flags.Int("batch", 20, "files per request")
+	timeout := flags.Duration("timeout", 5*time.Minute, "timeout of each request")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 || *apiKey == "" || *batch < 1 || *batch > handlers.MaxIngestFiles {
+		fmt.Fprintf(stderr, "ingest needs files or directories, an API key (-key or MUSIC_LIBRARY_API_KEY) and a batch size from 1 to %d\n", handlers.MaxIngestFiles)
+		flags.Usage()
+		return 2
+	}
+
+	files, skipped, err := Collect(flags.Args())
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	client := &Client{BaseURL: *baseURL, APIKey: *apiKey, Tenant: *tenant, HTTP: &http.Client{Timeout: *timeout}}
+	var report models.IngestReport
+	for _, result := range skipped {
+		report.Add(result)
+		printResult(stdout, result)
+	}
+	for chunk := range slices.Chunk(files, *batch) {
+		uploaded, err := client.Upload(ctx, chunk)
+		for i, path := range chunk {
+			result := models.IngestResult{File: path, Status: models.IngestFailed}
+			if err != nil {
+				result.Reason = err.Error()
+			} else {
+				result = uploaded.Items[i]
+				result.File = path
+			}
+			report.Add(result)
+			printResult(stdout, result)
+		}
+		if errors.Is(err, context.Canceled) {
+			break
+		}
+	}
+
+	fmt.Fprintf(stdout, "%d created, %d skipped, %d failed\n", report.Created, report.Skipped, report.Failed)
+	if report.Failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+func printResult(w io.Writer, result models.IngestResult) {
+	switch {
+	case result.Song != nil:
+		fmt.Fprintf(w, "%-8s %s: song %d, %s - %s\n", result.Status, result.File, result.Song.ID, result.Song.Group, result.Song.Name)
+	case result.Reason != "":
+		fmt.Fprintf(w, "%-8s %s: %s\n", result.Status, result.File, result.Reason)
+	default:
+		fmt.Fprintf(w, "%-8s %s\n", result.Status, result.File)
+	}
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
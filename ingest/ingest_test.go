@@ -0,0 +1,139 @@
+package ingest
+
+import (
+	"awesomeProject/models"
+	"bytes"
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func createFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+func TestCollect(t *testing.T) {
+	dir := t.TempDir()
+	createFiles(t, dir, map[string]string{
+		"b.mp3":          "",
+		"a/track.FLAC":   "",
+		"a/cover.jpg":    "",
+		"c/d/voice.opus": "",
+		"notes.txt":      "",
+	})
+
+	files, skipped, err := Collect([]string{dir, filepath.Join(dir, "notes.txt")})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "a/track.FLAC"),
+		filepath.Join(dir, "b.mp3"),
+		filepath.Join(dir, "c/d/voice.opus"),
+		filepath.Join(dir, "notes.txt"),
+	}, files)
+	assert.Equal(t, []models.IngestResult{
+		{File: filepath.Join(dir, "a/cover.jpg"), Status: models.IngestSkipped, Reason: "not an audio file"},
+		{File: filepath.Join(dir, "notes.txt"), Status: models.IngestSkipped, Reason: "not an audio file"},
+	}, skipped)
+
+	_, _, err = Collect([]string{filepath.Join(dir, "missing")})
+	assert.Error(t, err)
+}
+
+// fakeServer answers ingest requests with one result per uploaded file,
+// chosen by the file's content.
+func fakeServer(t *testing.T) (*httptest.Server, *[]int) {
+	gin.SetMode(gin.TestMode)
+	var batches []int
+	r := gin.New()
+	r.POST("/api/v1/song/ingest", func(c *gin.Context) {
+		if c.GetHeader("X-API-Key") != "editor-key" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+			return
+		}
+		form, err := c.MultipartForm()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		var report models.IngestReport
+		for i, header := range form.File["files"] {
+			f, _ := header.Open()
+			content, _ := io.ReadAll(f)
+			f.Close()
+			result := models.IngestResult{File: header.Filename}
+			switch string(content) {
+			case "new":
+				result.Status = models.IngestCreated
+				result.Song = &models.Song{ID: uint(i + 1), Group: "Muse", Name: "Uprising"}
+			case "broken":
+				result.Status, result.Reason = models.IngestFailed, "failed to create song"
+			default:
+				result.Status, result.Reason = models.IngestSkipped, "no tags found"
+			}
+			report.Add(result)
+		}
+		batches = append(batches, len(form.File["files"]))
+		c.JSON(http.StatusOK, report)
+	})
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv, &batches
+}
+
+func TestRun(t *testing.T) {
+	srv, batches := fakeServer(t)
+	dir := t.TempDir()
+	createFiles(t, dir, map[string]string{"a.mp3": "new", "b.ogg": "plain", "c.flac": "new", "readme.md": ""})
+
+	var stdout, stderr bytes.Buffer
+	code := Run(context.Background(), []string{"-url", srv.URL, "-key", "editor-key", "-batch", "2", dir}, &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+	assert.Equal(t, []int{2, 1}, *batches)
+	assert.Equal(t, strings.Join([]string{
+		"skipped  " + filepath.Join(dir, "readme.md") + ": not an audio file",
+		"created  " + filepath.Join(dir, "a.mp3") + ": song 1, Muse - Uprising",
+		"skipped  " + filepath.Join(dir, "b.ogg") + ": no tags found",
+		"created  " + filepath.Join(dir, "c.flac") + ": song 1, Muse - Uprising",
+		"2 created, 2 skipped, 0 failed",
+		"",
+	}, "\n"), stdout.String())
+
+	t.Run("failures", func(t *testing.T) {
+		createFiles(t, dir, map[string]string{"d.mp3": "broken"})
+		stdout.Reset()
+		code := Run(context.Background(), []string{"-url", srv.URL, "-key", "editor-key", filepath.Join(dir, "d.mp3")}, &stdout, &stderr)
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stdout.String(), "failed   "+filepath.Join(dir, "d.mp3")+": failed to create song")
+
+		stdout.Reset()
+		code = Run(context.Background(), []string{"-url", srv.URL, "-key", "wrong", filepath.Join(dir, "a.mp3")}, &stdout, &stderr)
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stdout.String(), "server answered 401 Unauthorized: Invalid API key")
+	})
+
+	t.Run("usage", func(t *testing.T) {
+		t.Setenv("MUSIC_LIBRARY_API_KEY", "")
+		for _, args := range [][]string{
+			{dir},
+			{"-key", "editor-key"},
+			{"-key", "editor-key", "-batch", "101", dir},
+			{"-unknown"},
+			{"-key", "editor-key", filepath.Join(dir, "missing")},
+		} {
+			assert.Equal(t, 2, Run(context.Background(), args, io.Discard, io.Discard), args)
+		}
+	})
+}
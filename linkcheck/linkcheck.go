@@ -0,0 +1,309 @@
+// Package linkcheck periodically checks that songs' links still resolve and
+// records the outcome on the songs.
+package linkcheck
+
+import (
+	"awesomeProject/logger"
+	"awesomeProject/metrics"
+	"awesomeProject/models"
+	"awesomeProject/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	userAgent     = "MusicLibraryLinkChecker/1.0"
+	recordTimeout = 5 * time.Second
+)
+
+type Config struct {
+	// Interval is the pause between two scans.
+	Interval time.Duration
+	// RecheckAfter is how old a check must be before the link is checked
+	// again.
+	RecheckAfter time.Duration
+	// BatchSize is how many due links are loaded and recorded at once.
+	BatchSize int
+	// Concurrency caps how many hosts are checked at the same time. A host
+	// never gets more than one request at a time.
+	Concurrency int
+	// HostDelay is the pause between two requests to the same host.
+	HostDelay time.Duration
+	// Timeout bounds a single request, redirects included.
+	Timeout time.Duration
+	// AllowPrivate lets checks reach loopback, private and link-local
+	// addresses. Without it stored links cannot probe the internal network.
+	AllowPrivate bool
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Interval:     time.Hour,
+		RecheckAfter: 24 * time.Hour,
+		BatchSize:    200,
+		Concurrency:  4,
+		HostDelay:    time.Second,
+		Timeout:      10 * time.Second,
+	}
+}
+
+type Checker struct {
+	repo   repositories.LinkCheckRepository
+	cfg    Config
+	client *http.Client
+	now    func() time.Time
+
+	mu sync.Mutex
+	// lastRequest holds when each host was last checked, so HostDelay
+	// holds across batches and scans.
+	lastRequest map[string]time.Time
+}
+
+func New(repo repositories.LinkCheckRepository, cfg Config) *Checker {
+	return &Checker{
+		repo:   repo,
+		cfg:    cfg,
+		client: newClient(cfg),
+		now:    time.Now,
+
+		lastRequest: make(map[string]time.Time),
+	}
+}
+
+// Run scans immediately and then every Interval until ctx is cancelled.
+func (c *Checker) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		checked, err := c.Scan(ctx)
+		if err != nil && ctx.Err() == nil {
+			logger.Error("Link check failed", zap.Error(err))
+		} else if checked > 0 {
+			logger.Info("Checked links", zap.Int("count", checked))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Scan checks every link that is due, batch by batch, and returns how many
+// it recorded. It stops early when ctx is cancelled.
+func (c *Checker) Scan(ctx context.Context) (int, error) {
+	cutoff := c.now().Add(-c.cfg.RecheckAfter)
+	total := 0
+	for ctx.Err() == nil {
+		due, err := c.repo.DueLinks(ctx, cutoff, c.cfg.BatchSize)
+		if err != nil {
+			return total, err
+		}
+		checks := c.checkAll(ctx, due)
+		if len(checks) > 0 {
+			if err := c.record(ctx, checks); err != nil {
+				return total, err
+			}
+		}
+		total += len(checks)
+		if len(due) < c.cfg.BatchSize {
+			break
+		}
+	}
+	return total, nil
+}
+
+// record stores checks even when ctx was cancelled mid-batch, so work done
+// before shutdown is kept.
+func (c *Checker) record(ctx context.Context, checks []models.LinkCheck) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), recordTimeout)
+	defer cancel()
+	return c.repo.RecordLinkChecks(ctx, checks)
+}
+
+// checkAll checks links grouped by host: up to Concurrency hosts at once,
+// each host's links one after another with HostDelay in between. Links not
+// checked before ctx is cancelled are left out of the result.
+func (c *Checker) checkAll(ctx context.Context, due []models.LinkCheck) []models.LinkCheck {
+	c.forgetIdleHosts()
+
+	var hosts []string
+	byHost := make(map[string][]models.LinkCheck)
+	for _, check := range due {
+		host := hostOf(check.Link)
+		if _, ok := byHost[host]; !ok {
+			hosts = append(hosts, host)
+		}
+		byHost[host] = append(byHost[host], check)
+	}
+
+	queue := make(chan []models.LinkCheck)
+	var mu sync.Mutex
+	var results []models.LinkCheck
+	var wg sync.WaitGroup
+	for range min(c.cfg.Concurrency, len(hosts)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for checks := range queue {
+				for _, check := range checks {
+					host := hostOf(check.Link)
+					if !c.waitForHost(ctx, host) {
+						break
+					}
+					check.Status = c.Check(ctx, check.Link)
+					c.touchHost(host)
+					if ctx.Err() != nil {
+						break
+					}
+					check.CheckedAt = c.now().UTC()
+					metrics.ObserveLinkCheck(models.Song{Link: check.Link, LinkStatus: check.Status, LinkCheckedAt: &check.CheckedAt}.LinkState())
+
+					mu.Lock()
+					results = append(results, check)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, host := range hosts {
+		select {
+		case queue <- byHost[host]:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(queue)
+	wg.Wait()
+	return results
+}
+
+// waitForHost waits until HostDelay has passed since the last request to
+// host and reports whether ctx is still live.
+func (c *Checker) waitForHost(ctx context.Context, host string) bool {
+	c.mu.Lock()
+	last, ok := c.lastRequest[host]
+	c.mu.Unlock()
+	if !ok {
+		return ctx.Err() == nil
+	}
+	return sleep(ctx, c.cfg.HostDelay-time.Since(last))
+}
+
+func (c *Checker) touchHost(host string) {
+	c.mu.Lock()
+	c.lastRequest[host] = time.Now()
+	c.mu.Unlock()
+}
+
+// forgetIdleHosts drops hosts whose delay has passed, so the map does not
+// keep every host ever checked.
+func (c *Checker) forgetIdleHosts() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for host, last := range c.lastRequest {
+		if time.Since(last) >= c.cfg.HostDelay {
+			delete(c.lastRequest, host)
+		}
+	}
+}
+
+// Check requests link and returns the final status code after redirects, or
+// 0 if the link could not be reached. Servers that refuse HEAD are asked
+// again with GET.
+func (c *Checker) Check(ctx context.Context, link string) int {
+	status, err := c.request(ctx, http.MethodHead, link)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented || status == http.StatusForbidden) {
+		status, err = c.request(ctx, http.MethodGet, link)
+	}
+	if err != nil {
+		return 0
+	}
+	return status
+}
+
+func (c *Checker) request(ctx context.Context, method, link string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, link, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	// Only the status matters; read a little so small bodies let the
+	// connection be reused.
+	_, _ = io.CopyN(io.Discard, resp.Body, 4096)
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+var errPrivateAddress = errors.New("private address")
+
+// sharedAddressSpace is the carrier-grade NAT range, RFC 6598.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+func newClient(cfg Config) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !cfg.AllowPrivate {
+		// The dialer sees the resolved address of every connection,
+		// redirects included. A proxy would hide it, so none is used.
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: refusePrivate}
+		transport.DialContext = dialer.DialContext
+		transport.Proxy = nil
+	}
+	return &http.Client{Timeout: cfg.Timeout, Transport: transport}
+}
+
+func refusePrivate(_, address string, _ syscall.RawConn) error {
+	addr, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !publicAddr(addr.Addr()) {
+		return fmt.Errorf("%w %s", errPrivateAddress, address)
+	}
+	return nil
+}
+
+// publicAddr reports whether ip is a globally routable unicast address.
+func publicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !sharedAddressSpace.Contains(ip)
+}
+
+func hostOf(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// sleep waits for d and reports whether ctx is still live.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
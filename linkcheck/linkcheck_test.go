@@ -0,0 +1,229 @@
+package linkcheck
+
+import (
+	"awesomeProject/logger"
+	"awesomeProject/models"
+	"awesomeProject/repositories"
+	"awesomeProject/tenant"
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stub answers by path and records when each host was hit.
+type stub struct {
+	mu       sync.Mutex
+	inFlight map[string]int
+	overlap  bool
+	hits     map[string][]time.Time
+}
+
+func newStub(t *testing.T) (*stub, *httptest.Server) {
+	s := &stub{inFlight: make(map[string]int), hits: make(map[string][]time.Time)}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := strings.Split(r.Host, ":")[0]
+		s.mu.Lock()
+		s.inFlight[host]++
+		s.overlap = s.overlap || s.inFlight[host] > 1
+		s.hits[host] = append(s.hits[host], time.Now())
+		s.mu.Unlock()
+		defer func() {
+			s.mu.Lock()
+			s.inFlight[host]--
+			s.mu.Unlock()
+		}()
+
+		switch r.URL.Path {
+		case "/ok":
+		case "/moved":
+			http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+		case "/no-head":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		case "/slow":
+			time.Sleep(50 * time.Millisecond)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+func setup(t *testing.T, links ...string) (*repositories.MemorySongRepository, []models.Song) {
+	songs := repositories.NewMemorySongRepository()
+	ctx := tenant.NewContext(context.Background(), tenant.Default)
+	var stored []models.Song
+	for i, link := range links {
+		song := models.Song{Group: "Band", Name: fmt.Sprint(i), Link: link}
+		require.NoError(t, songs.Create(ctx, &song))
+		stored = append(stored, song)
+	}
+	return songs, stored
+}
+
+func state(t *testing.T, songs *repositories.MemorySongRepository, id uint) models.Song {
+	song, err := songs.GetByID(tenant.NewContext(context.Background(), tenant.Default), fmt.Sprint(id))
+	require.NoError(t, err)
+	return *song
+}
+
+func testConfig() Config {
+	cfg := DefaultConfig()
+	cfg.HostDelay = 20 * time.Millisecond
+	cfg.Timeout = time.Second
+	cfg.AllowPrivate = true
+	return cfg
+}
+
+func TestChecker_Scan(t *testing.T) {
+	logger.Init()
+	_, srv := newStub(t)
+	songs, stored := setup(t,
+		srv.URL+"/ok", srv.URL+"/moved", srv.URL+"/no-head", srv.URL+"/gone", "http://127.0.0.1:1/refused")
+
+	checker := New(repositories.NewMemoryLinkCheckRepository(songs), testConfig())
+	checked, err := checker.Scan(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 5, checked)
+
+	for i, want := range []int{200, 200, 200, 404, 0} {
+		song := state(t, songs, stored[i].ID)
+		assert.Equal(t, want, song.LinkStatus, song.Link)
+		assert.NotNil(t, song.LinkCheckedAt)
+	}
+	assert.Equal(t, models.LinkStateBroken, state(t, songs, stored[3].ID).LinkState())
+
+	checked, err = checker.Scan(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, checked, "fresh checks are not repeated")
+
+	checker.now = func() time.Time { return time.Now().Add(25 * time.Hour) }
+	checked, err = checker.Scan(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 5, checked, "stale checks are repeated")
+}
+
+func TestChecker_Politeness(t *testing.T) {
+	logger.Init()
+	s, srv := newStub(t)
+	port := srv.URL[strings.LastIndex(srv.URL, ":")+1:]
+	var links []string
+	for i := 0; i < 3; i++ {
+		links = append(links, fmt.Sprintf("http://127.0.0.1:%s/slow?%d", port, i), fmt.Sprintf("http://localhost:%s/slow?%d", port, i))
+	}
+	songs, _ := setup(t, links...)
+
+	cfg := testConfig()
+	cfg.BatchSize = 4
+	checked, err := New(repositories.NewMemoryLinkCheckRepository(songs), cfg).Scan(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 6, checked, "every batch is checked")
+
+	assert.False(t, s.overlap, "one request per host at a time")
+	for host, hits := range s.hits {
+		for i := 1; i < len(hits); i++ {
+			assert.GreaterOrEqual(t, hits[i].Sub(hits[i-1]), cfg.HostDelay, host)
+		}
+	}
+}
+
+func TestChecker_PolitenessAcrossBatches(t *testing.T) {
+	logger.Init()
+	s, srv := newStub(t)
+	songs, _ := setup(t, srv.URL+"/ok", srv.URL+"/ok?1", srv.URL+"/ok?2")
+
+	cfg := testConfig()
+	cfg.BatchSize = 1
+	cfg.HostDelay = 50 * time.Millisecond
+	checker := New(repositories.NewMemoryLinkCheckRepository(songs), cfg)
+	checked, err := checker.Scan(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, checked)
+
+	checker.now = func() time.Time { return time.Now().Add(25 * time.Hour) }
+	checked, err = checker.Scan(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, checked)
+
+	hits := s.hits["127.0.0.1"]
+	require.Len(t, hits, 6)
+	for i := 1; i < len(hits); i++ {
+		assert.GreaterOrEqual(t, hits[i].Sub(hits[i-1]), cfg.HostDelay, "request %d", i)
+	}
+}
+
+func TestChecker_RunStopsOnCancel(t *testing.T) {
+	logger.Init()
+	_, srv := newStub(t)
+	songs, stored := setup(t, srv.URL+"/ok", srv.URL+"/slow")
+
+	cfg := testConfig()
+	cfg.HostDelay = time.Hour
+	checker := New(repositories.NewMemoryLinkCheckRepository(songs), cfg)
+	// The clock is read once per scan and once per finished check.
+	var calls int
+	firstChecked := make(chan struct{})
+	checker.now = func() time.Time {
+		if calls++; calls == 2 {
+			close(firstChecked)
+		}
+		return time.Now()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		checker.Run(ctx)
+		close(done)
+	}()
+
+	<-firstChecked
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not stop")
+	}
+	assert.NotNil(t, state(t, songs, stored[0].ID).LinkCheckedAt, "finished checks are recorded")
+	assert.Nil(t, state(t, songs, stored[1].ID).LinkCheckedAt, "unfinished checks are not")
+}
+
+func TestChecker_RefusesPrivateAddresses(t *testing.T) {
+	_, srv := newStub(t)
+	cfg := testConfig()
+	cfg.AllowPrivate = false
+	checker := New(repositories.NewMemoryLinkCheckRepository(repositories.NewMemorySongRepository()), cfg)
+
+	assert.Zero(t, checker.Check(context.Background(), srv.URL+"/ok"))
+	_, err := checker.request(context.Background(), http.MethodHead, strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)+"/ok")
+	assert.ErrorIs(t, err, errPrivateAddress)
+
+	for addr, want := range map[string]bool{
+		"93.184.216.34":    true,
+		"2606:4700::1111":  true,
+		"127.0.0.1":        false,
+		"10.1.2.3":         false,
+		"172.16.0.1":       false,
+		"192.168.1.1":      false,
+		"169.254.169.254":  false,
+		"100.64.0.1":       false,
+		"0.0.0.0":          false,
+		"::1":              false,
+		"fd00::1":          false,
+		"fe80::1":          false,
+		"::ffff:10.0.0.1":  false,
+		"::ffff:127.0.0.1": false,
+	} {
+		assert.Equal(t, want, publicAddr(netip.MustParseAddr(addr)), addr)
+	}
+}
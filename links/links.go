@@ -0,0 +1,161 @@
+// Package links validates song links and recognises the media provider they
+// point to.
+package links
+
+import (
+	"awesomeProject/models"
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// MaxLength is the longest link accepted.
+const MaxLength = 2048
+
+var ErrInvalid = errors.New("link must be an absolute http or https URL")
+
+var (
+	youTubeID    = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+	spotifyID    = regexp.MustCompile(`^[A-Za-z0-9]{22}$`)
+	appleMusicID = regexp.MustCompile(`^[0-9]+$`)
+	pathSegment  = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+)
+
+var spotifyKinds = map[string]bool{"track": true, "album": true, "artist": true, "playlist": true, "episode": true, "show": true}
+
+var appleMusicKinds = map[string]bool{"album": true, "song": true, "artist": true, "playlist": true, "music-video": true}
+
+// Validate checks that raw is an absolute http(s) URL of acceptable length.
+func Validate(raw string) error {
+	if len(raw) > MaxLength || strings.TrimSpace(raw) != raw {
+		return ErrInvalid
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrInvalid
+	}
+	return nil
+}
+
+// Parse validates raw and recognises its provider. Links to unrecognised
+// sites, or to recognised sites without a usable ID, get ProviderOther or an
+// empty ProviderID respectively.
+func Parse(raw string) (models.SongLink, error) {
+	if err := Validate(raw); err != nil {
+		return models.SongLink{}, err
+	}
+	u, _ := url.Parse(raw)
+	provider, id := recognise(u)
+	return models.SongLink{URL: raw, Provider: provider, ProviderID: id}, nil
+}
+
+// Recognise is Parse for links that were validated earlier, or that must be
+// stored anyway: invalid links are kept with ProviderOther.
+func Recognise(raw string) models.SongLink {
+	link, err := Parse(raw)
+	if err != nil {
+		return models.SongLink{URL: raw, Provider: models.ProviderOther}
+	}
+	return link
+}
+
+// Merge returns a song's links with its primary link first and repeated URLs
+// removed. Blank URLs are dropped.
+func Merge(primary string, list []models.SongLink) []models.SongLink {
+	var out []models.SongLink
+	seen := make(map[string]bool)
+	for _, raw := range append([]string{primary}, urls(list)...) {
+		if raw == "" || seen[raw] {
+			continue
+		}
+		seen[raw] = true
+		out = append(out, Recognise(raw))
+	}
+	return out
+}
+
+func urls(list []models.SongLink) []string {
+	out := make([]string, len(list))
+	for i, link := range list {
+		out[i] = link.URL
+	}
+	return out
+}
+
+func recognise(u *url.URL) (string, string) {
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	segments := strings.FieldsFunc(u.Path, func(r rune) bool { return r == '/' })
+
+	switch {
+	case host == "youtube.com" || host == "m.youtube.com" || host == "music.youtube.com":
+		id := u.Query().Get("v")
+		if len(segments) == 2 && (segments[0] == "shorts" || segments[0] == "embed" || segments[0] == "live") {
+			id = segments[1]
+		}
+		return models.ProviderYouTube, match(youTubeID, id)
+	case host == "youtu.be":
+		return models.ProviderYouTube, match(youTubeID, first(segments))
+	case host == "open.spotify.com":
+		if len(segments) > 0 && strings.HasPrefix(segments[0], "intl-") {
+			segments = segments[1:]
+		}
+		if len(segments) >= 2 && spotifyKinds[segments[0]] && spotifyID.MatchString(segments[1]) {
+			return models.ProviderSpotify, segments[0] + ":" + segments[1]
+		}
+		return models.ProviderSpotify, ""
+	case host == "music.apple.com":
+		// /{storefront}/{kind}/{slug}/{id}; the slug is optional and a
+		// track within an album is selected with ?i=.
+		if len(segments) >= 3 && appleMusicKinds[segments[1]] {
+			kind, id := segments[1], segments[len(segments)-1]
+			if track := u.Query().Get("i"); kind == "album" && appleMusicID.MatchString(track) {
+				kind, id = "song", track
+			}
+			if appleMusicID.MatchString(id) {
+				return models.ProviderAppleMusic, kind + ":" + id
+			}
+		}
+		return models.ProviderAppleMusic, ""
+	case host == "soundcloud.com" || host == "m.soundcloud.com":
+		if len(segments) >= 1 && len(segments) <= 2 && allMatch(segments) {
+			return models.ProviderSoundCloud, strings.Join(segments, "/")
+		}
+		return models.ProviderSoundCloud, ""
+	case host == "on.soundcloud.com":
+		return models.ProviderSoundCloud, ""
+	case strings.HasSuffix(host, ".bandcamp.com"):
+		artist := strings.TrimSuffix(host, ".bandcamp.com")
+		if len(segments) == 2 && (segments[0] == "track" || segments[0] == "album") && allMatch(segments) {
+			return models.ProviderBandcamp, artist + "/" + segments[0] + "/" + segments[1]
+		}
+		if len(segments) == 0 {
+			return models.ProviderBandcamp, artist
+		}
+		return models.ProviderBandcamp, ""
+	}
+	return models.ProviderOther, ""
+}
+
+func match(pattern *regexp.Regexp, id string) string {
+	if pattern.MatchString(id) {
+		return id
+	}
+	return ""
+}
+
+func first(segments []string) string {
+	if len(segments) == 0 {
+		return ""
+	}
+	return segments[0]
+}
+
+func allMatch(segments []string) bool {
+	for _, segment := range segments {
+		if !pathSegment.MatchString(segment) {
+			return false
+		}
+	}
+	return true
+}
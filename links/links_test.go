@@ -0,0 +1,63 @@
+package links
+
+import (
+	"awesomeProject/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		url, provider, id string
+	}{
+		{"https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=42", models.ProviderYouTube, "dQw4w9WgXcQ"},
+		{"https://youtu.be/dQw4w9WgXcQ", models.ProviderYouTube, "dQw4w9WgXcQ"},
+		{"https://m.youtube.com/shorts/dQw4w9WgXcQ", models.ProviderYouTube, "dQw4w9WgXcQ"},
+		{"https://music.youtube.com/watch?v=dQw4w9WgXcQ", models.ProviderYouTube, "dQw4w9WgXcQ"},
+		{"https://www.youtube.com/@muse", models.ProviderYouTube, ""},
+		{"https://open.spotify.com/track/4uLU6hMCjMI75M1A2tKUQC?si=abc", models.ProviderSpotify, "track:4uLU6hMCjMI75M1A2tKUQC"},
+		{"https://open.spotify.com/intl-de/album/4uLU6hMCjMI75M1A2tKUQC", models.ProviderSpotify, "album:4uLU6hMCjMI75M1A2tKUQC"},
+		{"https://music.apple.com/us/album/uprising/1440726231?i=1440726519", models.ProviderAppleMusic, "song:1440726519"},
+		{"https://music.apple.com/gb/album/the-resistance/1440726231", models.ProviderAppleMusic, "album:1440726231"},
+		{"https://music.apple.com/us/song/1440726519", models.ProviderAppleMusic, "song:1440726519"},
+		{"https://soundcloud.com/muse/uprising", models.ProviderSoundCloud, "muse/uprising"},
+		{"https://on.soundcloud.com/abc123", models.ProviderSoundCloud, ""},
+		{"https://muse.bandcamp.com/track/uprising", models.ProviderBandcamp, "muse/track/uprising"},
+		{"https://muse.bandcamp.com/", models.ProviderBandcamp, "muse"},
+		{"http://example.com/uprising.mp3", models.ProviderOther, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			link, err := Parse(tt.url)
+			require.NoError(t, err)
+			assert.Equal(t, models.SongLink{URL: tt.url, Provider: tt.provider, ProviderID: tt.id}, link)
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, raw := range []string{
+		"", "not a url", "youtube.com/watch?v=dQw4w9WgXcQ", "ftp://example.com/a", "https://",
+		" https://example.com", "javascript:alert(1)", "https://example.com/" + strings.Repeat("a", MaxLength),
+	} {
+		_, err := Parse(raw)
+		assert.ErrorIs(t, err, ErrInvalid, raw)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	got := Merge("https://youtu.be/dQw4w9WgXcQ", []models.SongLink{
+		{URL: "https://soundcloud.com/muse/uprising"},
+		{URL: "https://youtu.be/dQw4w9WgXcQ"},
+		{URL: ""},
+		{URL: "broken", Provider: models.ProviderSpotify},
+	})
+	assert.Equal(t, []models.SongLink{
+		{URL: "https://youtu.be/dQw4w9WgXcQ", Provider: models.ProviderYouTube, ProviderID: "dQw4w9WgXcQ"},
+		{URL: "https://soundcloud.com/muse/uprising", Provider: models.ProviderSoundCloud, ProviderID: "muse/uprising"},
+		{URL: "broken", Provider: models.ProviderOther},
+	}, got)
+	assert.Nil(t, Merge("", nil))
+}
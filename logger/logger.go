@@ -1,11 +1,109 @@
 package logger
 
-import "go.uber.org/zap"
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
 
-var log *zap.Logger
+var (
+	log   = zap.NewNop()
+	level = zap.NewAtomicLevel()
+)
 
-func Init() {
-	log, _ = zap.NewProduction()
+type Config struct {
+	// Level is one of debug, info, warn, error.
+	Level string
+	// Format is "json" or "console".
+	Format string
+	// Outputs are "stdout", "stderr" or file paths.
+	Outputs  []string
+	Sampling bool
+}
+
+func DefaultConfig() Config {
+	return Config{Level: "info", Format: "json", Outputs: []string{"stderr"}}
+}
+
+// Init configures the global logger with the defaults.
+func Init() error {
+	return InitWithConfig(DefaultConfig())
+}
+
+func InitWithConfig(cfg Config) error {
+	zapCfg := zap.NewProductionConfig()
+	if cfg.Format == "console" {
+		zapCfg = zap.NewDevelopmentConfig()
+	} else if cfg.Format != "json" {
+		return fmt.Errorf("unknown log format %q", cfg.Format)
+	}
+
+	if err := SetLevel(cfg.Level); err != nil {
+		return err
+	}
+	zapCfg.Level = level
+	zapCfg.OutputPaths = cfg.Outputs
+	zapCfg.Sampling = nil
+	if cfg.Sampling {
+		zapCfg.Sampling = &zap.SamplingConfig{Initial: 100, Thereafter: 100}
+	}
+
+	built, err := zapCfg.Build()
+	if err != nil {
+		return err
+	}
+	log = built
+	return nil
+}
+
+func parseLevel(l string) (zapcore.Level, error) {
+	var parsed zapcore.Level
+	if err := parsed.UnmarshalText([]byte(l)); err != nil {
+		return parsed, fmt.Errorf("unknown log level %q", l)
+	}
+	return parsed, nil
+}
+
+func ValidateLevel(l string) error {
+	_, err := parseLevel(l)
+	return err
+}
+
+func SetLevel(l string) error {
+	parsed, err := parseLevel(l)
+	if err != nil {
+		return err
+	}
+	level.SetLevel(parsed)
+	return nil
+}
+
+func Level() string {
+	return level.String()
+}
+
+func L() *zap.Logger {
+	return log
+}
+
+func Sync() error {
+	return log.Sync()
+}
+
+type contextKey struct{}
+
+// WithContext stores a request-scoped logger in ctx.
+func WithContext(ctx context.Context, l *zap.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the request-scoped logger, or the global one.
+func FromContext(ctx context.Context) *zap.Logger {
+	if l, ok := ctx.Value(contextKey{}).(*zap.Logger); ok {
+		return l
+	}
+	return log
 }
 
 func Info(msg string, fields ...zap.Field) {
@@ -15,3 +113,11 @@ func Info(msg string, fields ...zap.Field) {
 func Debug(msg string, fields ...zap.Field) {
 	log.Debug(msg, fields...)
 }
+
+func Warn(msg string, fields ...zap.Field) {
+	log.Warn(msg, fields...)
+}
+
+func Error(msg string, fields ...zap.Field) {
+	log.Error(msg, fields...)
+}
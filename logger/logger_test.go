@@ -0,0 +1,38 @@
+package logger
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInitWithConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	require.NoError(t, InitWithConfig(Config{Level: "warn", Format: "json", Outputs: []string{path}}))
+
+	Info("hidden")
+	Warn("shown")
+	require.NoError(t, SetLevel("debug"))
+	Debug("now shown")
+	Sync()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "hidden")
+	assert.Contains(t, string(data), "shown")
+	assert.Contains(t, string(data), "now shown")
+
+	assert.Error(t, InitWithConfig(Config{Level: "loud", Format: "json", Outputs: []string{path}}))
+	assert.Error(t, InitWithConfig(Config{Level: "info", Format: "xml", Outputs: []string{path}}))
+}
+
+func TestFromContext(t *testing.T) {
+	require.NoError(t, InitWithConfig(DefaultConfig()))
+	assert.Same(t, L(), FromContext(context.Background()))
+
+	scoped := L().Named("request")
+	assert.Same(t, scoped, FromContext(WithContext(context.Background(), scoped)))
+}
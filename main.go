@@ -1,22 +1,34 @@
 package main
 
 import (
+	"awesomeProject/blobstore"
+	"awesomeProject/config"
+	"awesomeProject/database"
 	_ "awesomeProject/docs"
 	"awesomeProject/handlers"
+	"awesomeProject/health"
+	"awesomeProject/ingest"
+	"awesomeProject/linkcheck"
 	"awesomeProject/logger"
+	"awesomeProject/metrics"
 	"awesomeProject/middleware"
-	"awesomeProject/models"
+	"awesomeProject/ratelimit"
 	"awesomeProject/repositories"
+	"awesomeProject/server"
 	"awesomeProject/services"
+	"awesomeProject/tracing"
+	"context"
+	"flag"
+	"fmt"
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	"go.uber.org/zap"
-	"gorm.io/driver/postgres"
-	"gorm.io/gorm"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 // @title Music Library API
@@ -25,43 +37,181 @@ import (
 // @host localhost:8081
 // @BasePath /
 func main() {
-	if err := godotenv.Load(); err != nil {
-		log.Fatal("Error loading .env file")
+	if len(os.Args) > 1 && os.Args[1] == "ingest" {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		code := ingest.Run(ctx, os.Args[2:], os.Stdout, os.Stderr)
+		stop()
+		os.Exit(code)
 	}
 
-	logger.Init()
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "optional YAML configuration file")
+	envFile := flag.String("env-file", ".env", "optional .env file")
+	printConfig := flag.Bool("print-config", false, "print the effective configuration and exit")
+	flag.Parse()
 
-	required := []string{"DATABASE_URL", "EXTERNAL_API_URL", "PORT"}
-	for _, key := range required {
-		if os.Getenv(key) == "" {
-			log.Fatalf("Environment variable %s is required", key)
+	cfg, err := config.Load(config.Options{YAMLFile: *configFile, EnvFile: *envFile})
+	if *printConfig {
+		fmt.Print(cfg.Redacted())
+	}
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+	if *printConfig {
+		return
+	}
+
+	if err := logger.InitWithConfig(cfg.LoggerConfig()); err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
+	defer logger.Sync()
+	logger.Info("Effective configuration", zap.String("config", cfg.Redacted()))
+
+	keys, err := cfg.APIKeys()
+	if err != nil {
+		log.Fatalf("Invalid API_KEYS: %v", err)
+	}
+	rateLimitPolicy, err := cfg.RateLimitPolicy()
+	if err != nil {
+		log.Fatalf("Invalid rate limit configuration: %v", err)
+	}
+
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.Tracing.Exporter)
+	if err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
+
+	musicAPI := services.NewMusicAPIService(cfg.Upstream.URL, cfg.Upstream.Timeout)
+	checks := []health.Check{
+		{Name: "musicApi", Critical: cfg.Upstream.ReadinessCheck, Run: musicAPI.Ping},
+	}
+
+	var songRepo repositories.SongRepository
+	var playlistRepo repositories.PlaylistRepository
+	var taxonomyRepo repositories.TaxonomyRepository
+	var creditRepo repositories.CreditRepository
+	var engagementRepo repositories.EngagementRepository
+	var statsRepo repositories.StatsRepository
+	var reviewRepo repositories.ReviewRepository
+	var linkCheckRepo repositories.LinkCheckRepository
+	var attachmentRepo repositories.AttachmentRepository
+	closeDB := func() error { return nil }
+	if cfg.Database.Driver == config.DriverMemory {
+		logger.Warn("Using in-memory storage, data is lost on restart")
+		songs := repositories.NewMemorySongRepository()
+		songRepo = songs
+		playlistRepo = repositories.NewMemoryPlaylistRepository(songs)
+		taxonomyRepo = repositories.NewMemoryTaxonomyRepository(songs)
+		creditRepo = repositories.NewMemoryCreditRepository(songs)
+		engagementRepo = repositories.NewMemoryEngagementRepository(songs)
+		statsRepo = repositories.NewMemoryStatsRepository(songs)
+		reviewRepo = repositories.NewMemoryReviewRepository(songs)
+		linkCheckRepo = repositories.NewMemoryLinkCheckRepository(songs)
+		attachmentRepo = repositories.NewMemoryAttachmentRepository(songs)
+	} else {
+		db, err := database.Open(cfg.Database)
+		if err != nil {
+			logger.Info("Failed to connect to database", zap.Error(err))
+			log.Fatal("Failed to connect to database")
+		}
+
+		sqlDB, err := db.DB()
+		if err != nil {
+			log.Fatalf("Failed to access database pool: %v", err)
+		}
+		if err := metrics.RegisterDBStats(sqlDB, "music"); err != nil {
+			log.Fatalf("Failed to register pool metrics: %v", err)
 		}
+
+		songRepo = repositories.NewSQLSongRepository(db)
+		playlistRepo = repositories.NewSQLPlaylistRepository(db)
+		taxonomyRepo = repositories.NewSQLTaxonomyRepository(db)
+		creditRepo = repositories.NewSQLCreditRepository(db)
+		engagementRepo = repositories.NewSQLEngagementRepository(db)
+		statsRepo = repositories.NewSQLStatsRepository(db)
+		reviewRepo = repositories.NewSQLReviewRepository(db)
+		linkCheckRepo = repositories.NewSQLLinkCheckRepository(db)
+		attachmentRepo = repositories.NewSQLAttachmentRepository(db)
+		checks = append(checks, health.Check{Name: "database", Critical: true, Run: sqlDB.PingContext})
+		closeDB = sqlDB.Close
 	}
 
-	db, err := gorm.Open(postgres.Open(os.Getenv("DATABASE_URL")), &gorm.Config{})
+	blobs, err := blobstore.Open(cfg.BlobStoreConfig())
 	if err != nil {
-		logger.Info("Failed to connect to database", zap.Error(err))
-		log.Fatal("Failed to connect to database")
+		log.Fatalf("Failed to open blob store: %v", err)
 	}
+	songRepo = repositories.NewBlobCleaningSongRepository(songRepo, attachmentRepo, blobs)
 
-	db.AutoMigrate(&models.Song{})
+	if cfg.Stats.CacheTTL > 0 {
+		statsRepo = repositories.NewCachedStatsRepository(statsRepo, cfg.Stats.CacheTTL)
+	}
 
-	songRepo := repositories.NewSQLSongRepository(db)
-	musicAPI := services.NewMusicAPIService(os.Getenv("EXTERNAL_API_URL"))
 	songHandler := handlers.NewSongHandler(songRepo, musicAPI)
+	checker := health.NewChecker(2*time.Second, checks...)
 
-	r := gin.Default()
-	r.Use(middleware.CORS())
+	r := gin.New()
+	if err := r.SetTrustedProxies(cfg.HTTP.TrustedProxies); err != nil {
+		log.Fatalf("Invalid HTTP_TRUSTED_PROXIES: %v", err)
+	}
+	r.Use(middleware.RequestID())
+	r.Use(middleware.Tracing())
+	r.Use(middleware.RequestLogger())
+	r.Use(middleware.AccessLog(cfg.AccessLogConfig()))
+	r.Use(middleware.Recovery())
+	r.Use(middleware.Metrics())
+	r.Use(middleware.CORS(cfg.CORSConfig()))
+	// Registered before the rate limiter so probes are never throttled.
+	handlers.RegisterHealthRoutes(r, handlers.NewHealthHandler(checker))
+	r.Use(middleware.RateLimit(ratelimit.NewMemoryStore(), rateLimitPolicy, keys))
 
-	r.GET("/api/v1/song", songHandler.List)
-	r.GET("/api/v1/song/:id/text", songHandler.GetText)
-	r.POST("/api/v1/song", songHandler.Create)
-	r.PUT("/api/v1/song/:id", songHandler.Update)
-	r.DELETE("/api/v1/song/:id", songHandler.Delete)
+	api := r.Group("", middleware.Authenticate(keys), middleware.Tenant(), middleware.ReadConsistency())
+	handlers.RegisterSongRoutes(api, songHandler)
+	handlers.RegisterPlaylistRoutes(api, handlers.NewPlaylistHandler(playlistRepo, songRepo))
+	handlers.RegisterTaxonomyRoutes(api, handlers.NewTaxonomyHandler(taxonomyRepo))
+	handlers.RegisterCreditRoutes(api, handlers.NewCreditHandler(creditRepo))
+	handlers.RegisterEngagementRoutes(api, handlers.NewEngagementHandler(engagementRepo))
+	handlers.RegisterStatsRoutes(api, handlers.NewStatsHandler(statsRepo))
+	handlers.RegisterAttachmentRoutes(api, handlers.NewAttachmentHandler(attachmentRepo, blobs))
+	handlers.RegisterQualityRoutes(api, handlers.NewQualityHandler(songRepo, reviewRepo, musicAPI))
+	handlers.RegisterAdminRoutes(api, handlers.NewAdminHandler())
 
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	port := os.Getenv("PORT")
-	logger.Info("Starting server", zap.String("port", port))
-	r.Run(":" + port)
+	serverConfig := cfg.ServerConfig()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	linkCheckDone := make(chan struct{})
+	if cfg.LinkCheck.Enabled {
+		go func() {
+			defer close(linkCheckDone)
+			linkcheck.New(linkCheckRepo, cfg.LinkCheckerConfig()).Run(ctx)
+		}()
+	} else {
+		close(linkCheckDone)
+	}
+
+	logger.Info("Starting server", zap.Int("port", cfg.HTTP.Port))
+	err = server.Run(ctx, server.New(serverConfig, r), serverConfig, func() {
+		// A second signal terminates immediately.
+		stop()
+		logger.Info("Shutting down, draining connections", zap.Duration("timeout", serverConfig.ShutdownTimeout))
+		checker.SetShuttingDown()
+	})
+	if err != nil {
+		logger.Error("Server stopped with error", zap.Error(err))
+	}
+	// The shutdown callback cancelled ctx; let the link checker record
+	// what it finished before the database closes.
+	stop()
+	<-linkCheckDone
+
+	if err := closeDB(); err != nil {
+		logger.Error("Failed to close database pool", zap.Error(err))
+	}
+	if err := shutdownTracing(context.Background()); err != nil {
+		logger.Error("Failed to flush traces", zap.Error(err))
+	}
+	logger.Info("Server stopped")
 }
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"database/sql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by method, route template and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	musicAPIRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "music_api_requests_total",
+		Help: "Calls to the external music API by outcome.",
+	}, []string{"outcome"})
+
+	musicAPIDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "music_api_request_duration_seconds",
+		Help:    "Latency of calls to the external music API by outcome.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"outcome"})
+
+	linkChecks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "link_checks_total",
+		Help: "Song link liveness checks by resulting state.",
+	}, []string{"state"})
+
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "Duration of gorm operations by operation and table.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		musicAPIRequests,
+		musicAPIDuration,
+		linkChecks,
+		dbQueryDuration,
+	)
+}
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+func ObserveHTTPRequest(method, route string, status int, elapsed time.Duration) {
+	code := strconv.Itoa(status)
+	httpRequests.WithLabelValues(method, route, code).Inc()
+	httpDuration.WithLabelValues(method, route, code).Observe(elapsed.Seconds())
+}
+
+func ObserveMusicAPICall(outcome string, elapsed time.Duration) {
+	musicAPIRequests.WithLabelValues(outcome).Inc()
+	musicAPIDuration.WithLabelValues(outcome).Observe(elapsed.Seconds())
+}
+
+func ObserveLinkCheck(state string) {
+	linkChecks.WithLabelValues(state).Inc()
+}
+
+// RegisterDBStats exposes the connection pool statistics of db.
+func RegisterDBStats(db *sql.DB, name string) error {
+	return Registry.Register(collectors.NewDBStatsCollector(db, name))
+}
@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type record struct {
+	ID   uint
+	Name string
+}
+
+func scrape(t *testing.T) string {
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	require.Equal(t, 200, w.Code)
+	return w.Body.String()
+}
+
+func TestGormPlugin(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:metrics?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(GormPlugin{}))
+	require.NoError(t, db.AutoMigrate(&record{}))
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	defer sqlDB.Close()
+	require.NoError(t, RegisterDBStats(sqlDB, "test"))
+
+	require.NoError(t, db.Create(&record{Name: "a"}).Error)
+	var found []record
+	require.NoError(t, db.Find(&found).Error)
+
+	body := scrape(t)
+	assert.Contains(t, body, `db_query_duration_seconds_count{operation="create",table="records"} 1`)
+	assert.Contains(t, body, `db_query_duration_seconds_count{operation="query",table="records"} 1`)
+	assert.Contains(t, body, `go_sql_open_connections{db_name="test"}`)
+}
+
+func TestObserveMusicAPICall(t *testing.T) {
+	ObserveMusicAPICall("success", 20*time.Millisecond)
+	ObserveMusicAPICall("transport_error", time.Second)
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(musicAPIRequests.WithLabelValues("transport_error")))
+	assert.Contains(t, scrape(t), `music_api_request_duration_seconds_count{outcome="success"} 1`)
+}
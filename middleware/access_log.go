@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type AccessLogConfig struct {
+	// RedactHeaders lists request headers whose values are never logged.
+	RedactHeaders []string
+}
+
+func DefaultAccessLogConfig() AccessLogConfig {
+	return AccessLogConfig{RedactHeaders: []string{"Authorization", "X-API-Key", "Cookie", "Proxy-Authorization"}}
+}
+
+// AccessLog writes one structured line per request using the request-scoped
+// logger, so it carries the request ID, principal and tenant.
+func AccessLog(cfg AccessLogConfig) gin.HandlerFunc {
+	redacted := map[string]bool{}
+	for _, h := range cfg.RedactHeaders {
+		redacted[http.CanonicalHeaderKey(h)] = true
+	}
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		headers := make(map[string]string, len(c.Request.Header))
+		for name, values := range c.Request.Header {
+			if redacted[name] {
+				headers[name] = "[REDACTED]"
+				continue
+			}
+			headers[name] = strings.Join(values, ", ")
+		}
+
+		status := c.Writer.Status()
+		fields := []zap.Field{
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.Request.URL.Path),
+			zap.Int("status", status),
+			zap.Duration("latency", time.Since(start)),
+			zap.Int("bytes", c.Writer.Size()),
+			zap.String("clientIp", c.ClientIP()),
+			zap.String("userAgent", c.Request.UserAgent()),
+			zap.Any("headers", headers),
+		}
+
+		log := Logger(c)
+		if status >= 500 {
+			log.Error("Request completed", fields...)
+		} else {
+			log.Info("Request completed", fields...)
+		}
+	}
+}
+
+// Recovery turns panics into a 500 in the standard error format.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, err any) {
+		Logger(c).Error("Panic recovered", zap.Any("panic", err), zap.Stack("stack"))
+		RespondError(c, 500, "Internal server error")
+	})
+}
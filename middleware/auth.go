@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"awesomeProject/auth"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"strings"
+)
+
+const principalKey = "principal"
+
+// Authenticate identifies the caller by the X-API-Key header or a bearer token.
+func Authenticate(keys auth.KeyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := requestKey(c)
+		principal, ok := keys.Lookup(key)
+		if key == "" || !ok {
+			Logger(c).Info("Unauthenticated request", zap.String("path", c.Request.URL.Path))
+			RespondError(c, 401, "Unauthorized")
+			return
+		}
+
+		c.Set(principalKey, principal)
+		withLogFields(c, zap.String("principal", principal.Subject))
+		c.Next()
+	}
+}
+
+// requestKey returns the API key from the X-API-Key header or a bearer token.
+func requestKey(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+	return strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+}
+
+// Authorize rejects callers whose role does not grant the permission.
+func Authorize(perm auth.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := CurrentPrincipal(c)
+		if !ok {
+			RespondError(c, 401, "Unauthorized")
+			return
+		}
+
+		if !principal.Can(perm) {
+			Logger(c).Info("Forbidden request",
+				zap.String("subject", principal.Subject),
+				zap.String("role", string(principal.Role)),
+				zap.String("permission", string(perm)))
+			RespondError(c, 403, "Forbidden")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func CurrentPrincipal(c *gin.Context) (auth.Principal, bool) {
+	value, ok := c.Get(principalKey)
+	if !ok {
+		return auth.Principal{}, false
+	}
+	principal, ok := value.(auth.Principal)
+	return principal, ok
+}
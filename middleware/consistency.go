@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"awesomeProject/repositories"
+	"github.com/gin-gonic/gin"
+)
+
+const ReadConsistencyHeader = "X-Read-Consistency"
+
+// ReadConsistency lets a client that just wrote data send
+// "X-Read-Consistency: strong" to read it back from the primary.
+func ReadConsistency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader(ReadConsistencyHeader) == "strong" {
+			c.Request = c.Request.WithContext(repositories.ReadFromPrimary(c.Request.Context()))
+		}
+		c.Next()
+	}
+}
@@ -1,18 +1,120 @@
 package middleware
 
-import "github.com/gin-gonic/gin"
+import (
+	"github.com/gin-gonic/gin"
+	"strconv"
+	"strings"
+)
+
+type CORSConfig struct {
+	// AllowedOrigins holds exact origins, "*" for any origin, or wildcard
+	// subdomain patterns such as "https://*.example.com".
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           int
+}
+
+func DefaultCORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{"Content-Type", "Authorization", "X-API-Key", "X-Tenant-ID", "X-Request-ID", "X-Read-Consistency"},
+		ExposedHeaders: []string{"X-Request-ID"},
+		MaxAge:         600,
+	}
+}
+
+func (cfg CORSConfig) originAllowed(origin string) bool {
+	for _, pattern := range cfg.AllowedOrigins {
+		// Echoing any origin with credentials would let every site make
+		// credentialed calls, so "*" only counts without them.
+		if (pattern == "*" && !cfg.AllowCredentials) || strings.EqualFold(pattern, origin) {
+			return true
+		}
+
+		prefix, suffix, wildcard := strings.Cut(strings.ToLower(pattern), "*")
+		if !wildcard {
+			continue
+		}
+		lower := strings.ToLower(origin)
+		if len(lower) <= len(prefix)+len(suffix) || !strings.HasPrefix(lower, prefix) || !strings.HasSuffix(lower, suffix) {
+			continue
+		}
+		if sub := lower[len(prefix) : len(lower)-len(suffix)]; !strings.ContainsAny(sub, "/:") {
+			return true
+		}
+	}
+	return false
+}
+
+func (cfg CORSConfig) anyOrigin() bool {
+	for _, pattern := range cfg.AllowedOrigins {
+		if pattern == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// CORS must be installed with r.Use so that it also runs for OPTIONS
+// preflights, which have no registered route of their own.
+func CORS(cfg CORSConfig) gin.HandlerFunc {
+	methods := strings.Join(cfg.AllowedMethods, ", ")
+	headers := strings.Join(cfg.AllowedHeaders, ", ")
+	exposed := strings.Join(cfg.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(cfg.MaxAge)
 
-func CORS() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		origin := c.GetHeader("Origin")
+		preflight := c.Request.Method == "OPTIONS" && c.GetHeader("Access-Control-Request-Method") != ""
+		c.Writer.Header().Add("Vary", "Origin")
+
+		if origin == "" {
+			c.Next()
+			return
+		}
 
-		if c.Request.Method == "OPTIONS" {
+		if !cfg.originAllowed(origin) {
+			if preflight {
+				RespondError(c, 403, "Origin not allowed")
+				return
+			}
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		if cfg.anyOrigin() && !cfg.AllowCredentials {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+		}
+		if cfg.AllowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if preflight {
+			h.Set("Access-Control-Allow-Methods", methods)
+			if headers == "*" {
+				h.Set("Access-Control-Allow-Headers", c.GetHeader("Access-Control-Request-Headers"))
+			} else {
+				h.Set("Access-Control-Allow-Headers", headers)
+			}
+			if cfg.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", maxAge)
+			}
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
 			c.AbortWithStatus(204)
 			return
 		}
 
+		if exposed != "" {
+			h.Set("Access-Control-Expose-Headers", exposed)
+		}
 		c.Next()
 	}
 }
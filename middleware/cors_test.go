@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func setupCORSTest(cfg CORSConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(CORS(cfg))
+	r.GET("/api/v1/song", func(c *gin.Context) { c.Status(200) })
+	r.DELETE("/api/v1/song/:id", func(c *gin.Context) { c.Status(204) })
+	return r
+}
+
+func preflight(r *gin.Engine, path, origin string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("OPTIONS", path, nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", "DELETE")
+	req.Header.Set("Access-Control-Request-Headers", "X-API-Key")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	cfg := DefaultCORSConfig()
+	cfg.AllowedOrigins = []string{"https://app.example.com", "https://*.example.org"}
+	cfg.AllowCredentials = true
+	r := setupCORSTest(cfg)
+
+	t.Run("Allowed origin on a parameterized route", func(t *testing.T) {
+		w := preflight(r, "/api/v1/song/1", "https://app.example.com")
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+		assert.Contains(t, w.Header().Get("Access-Control-Allow-Methods"), "PATCH")
+		assert.Contains(t, w.Header().Get("Access-Control-Allow-Methods"), "OPTIONS")
+		assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+	})
+
+	t.Run("Wildcard subdomain", func(t *testing.T) {
+		w := preflight(r, "/api/v1/song", "https://music.eu.example.org")
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "https://music.eu.example.org", w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("Wildcard does not match the bare domain or other schemes", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, preflight(r, "/api/v1/song", "https://example.org").Code)
+		assert.Equal(t, http.StatusForbidden, preflight(r, "/api/v1/song", "http://app.example.org").Code)
+		assert.Equal(t, http.StatusForbidden, preflight(r, "/api/v1/song", "https://evil.com/.example.org").Code)
+	})
+
+	t.Run("Disallowed origin", func(t *testing.T) {
+		w := preflight(r, "/api/v1/song", "https://evil.com")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+}
+
+func TestCORS_SimpleRequest(t *testing.T) {
+	cfg := DefaultCORSConfig()
+	cfg.ExposedHeaders = []string{"X-Request-ID"}
+	r := setupCORSTest(cfg)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/v1/song", nil)
+	req.Header.Set("Origin", "https://anything.test")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "X-Request-ID", w.Header().Get("Access-Control-Expose-Headers"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "Origin", w.Header().Get("Vary"))
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/song", nil))
+	assert.Equal(t, "Origin", w.Header().Get("Vary"), "responses without an origin vary too")
+}
+
+func TestCORS_AnyOriginWithCredentials(t *testing.T) {
+	cfg := DefaultCORSConfig()
+	cfg.AllowedOrigins = []string{"*", "https://app.example.com"}
+	cfg.AllowCredentials = true
+	r := setupCORSTest(cfg)
+
+	w := preflight(r, "/api/v1/song", "https://evil.com")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+
+	w = preflight(r, "/api/v1/song", "https://app.example.com")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"awesomeProject/logger"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RequestLogger attaches a logger carrying the request ID and route template
+// to the request context. Later middleware may enrich it via withLogFields.
+// It must run after RequestID.
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := logger.L().With(zap.String("requestId", CurrentRequestID(c)), zap.String("route", c.FullPath()))
+		c.Request = c.Request.WithContext(logger.WithContext(c.Request.Context(), l))
+		c.Next()
+	}
+}
+
+// Logger returns the request-scoped logger for handlers.
+func Logger(c *gin.Context) *zap.Logger {
+	return logger.FromContext(c.Request.Context())
+}
+
+func withLogFields(c *gin.Context, fields ...zap.Field) {
+	l := Logger(c).With(fields...)
+	c.Request = c.Request.WithContext(logger.WithContext(c.Request.Context(), l))
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"awesomeProject/auth"
+	"awesomeProject/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRequestLogger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	path := filepath.Join(t.TempDir(), "app.log")
+	require.NoError(t, logger.InitWithConfig(logger.Config{Level: "info", Format: "json", Outputs: []string{path}}))
+	defer logger.Init()
+
+	keys := auth.KeyStore{"k": {Subject: "alice", Role: auth.RoleReader}}
+
+	r := gin.New()
+	r.Use(RequestID(), RequestLogger())
+	r.GET("/api/v1/song/:id/text", Authenticate(keys), Tenant(), func(c *gin.Context) {
+		Logger(c).Info("handled")
+		c.Status(200)
+	})
+
+	req := httptest.NewRequest("GET", "/api/v1/song/1/text", nil)
+	req.Header.Set("X-API-Key", "k")
+	req.Header.Set("X-Request-ID", "req-42")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	logger.Sync()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	line := string(data)
+	assert.Contains(t, line, `"msg":"handled"`)
+	assert.Contains(t, line, `"requestId":"req-42"`)
+	assert.Contains(t, line, `"route":"/api/v1/song/:id/text"`)
+	assert.Contains(t, line, `"principal":"alice"`)
+	assert.Contains(t, line, `"tenant":"default"`)
+}
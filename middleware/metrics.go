@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"awesomeProject/metrics"
+	"github.com/gin-gonic/gin"
+	"time"
+)
+
+// Metrics records request counts and latency per route template. Unmatched
+// paths share one label so random URLs cannot blow up cardinality.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.ObserveHTTPRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
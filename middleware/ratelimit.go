@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"awesomeProject/auth"
+	"awesomeProject/ratelimit"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"math"
+	"strconv"
+	"time"
+)
+
+type RateLimitPolicy struct {
+	Default ratelimit.Limit
+	// Routes overrides the default for "METHOD /route/:template" keys.
+	Routes map[string]ratelimit.Limit
+}
+
+func (p RateLimitPolicy) limitFor(route string) (ratelimit.Limit, string) {
+	if limit, ok := p.Routes[route]; ok {
+		return limit, route
+	}
+	return p.Default, "default"
+}
+
+// RateLimit throttles clients by the principal of their API key, falling back
+// to the client IP, with a separate bucket for every route that has its own
+// limit. Keys missing from keys count as no key, so inventing keys does not
+// buy fresh buckets.
+func RateLimit(store ratelimit.Store, policy RateLimitPolicy, keys auth.KeyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, scope := policy.limitFor(c.Request.Method + " " + c.FullPath())
+
+		result, err := store.Take(clientKey(c, keys)+"|"+scope, limit)
+		if err != nil {
+			Logger(c).Warn("Rate limit store unavailable", zap.Error(err))
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		h.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		h.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		h.Set("RateLimit-Reset", ceilSeconds(result.Reset))
+
+		if !result.Allowed {
+			h.Set("Retry-After", ceilSeconds(result.RetryAfter))
+			Logger(c).Info("Rate limit exceeded", zap.String("route", scope), zap.String("ip", c.ClientIP()))
+			RespondError(c, 429, "Too many requests")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func clientKey(c *gin.Context, keys auth.KeyStore) string {
+	if principal, ok := keys.Lookup(requestKey(c)); ok {
+		return "principal:" + principal.Subject
+	}
+	return "ip:" + c.ClientIP()
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"awesomeProject/auth"
+	"awesomeProject/logger"
+	"awesomeProject/ratelimit"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	logger.Init()
+	gin.SetMode(gin.TestMode)
+
+	policy := RateLimitPolicy{
+		Default: ratelimit.Limit{Rate: 3, Period: time.Minute, Burst: 3},
+		Routes: map[string]ratelimit.Limit{
+			"POST /api/v1/song": {Rate: 1, Period: time.Minute, Burst: 1},
+		},
+	}
+
+	keys := auth.KeyStore{
+		"alice-key":  {Subject: "alice", Role: auth.RoleReader},
+		"alice-key2": {Subject: "alice", Role: auth.RoleEditor},
+		"bob-key":    {Subject: "bob", Role: auth.RoleReader},
+	}
+
+	r := gin.New()
+	r.Use(RateLimit(ratelimit.NewMemoryStore(), policy, keys))
+	r.GET("/api/v1/song", func(c *gin.Context) { c.Status(200) })
+	r.POST("/api/v1/song", func(c *gin.Context) { c.Status(201) })
+
+	do := func(method, key, ip string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, "/api/v1/song", nil)
+		req.RemoteAddr = ip + ":1234"
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Per-route limit", func(t *testing.T) {
+		w := do("POST", "alice-key", "10.0.0.1")
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "1", w.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+
+		w = do("POST", "alice-key", "10.0.0.1")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "60", w.Header().Get("Retry-After"))
+		assert.JSONEq(t, `{"error":"Too many requests"}`, w.Body.String())
+	})
+
+	t.Run("Routes without an override share the default bucket", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusOK, do("GET", "alice-key", "10.0.0.1").Code)
+		}
+		assert.Equal(t, http.StatusTooManyRequests, do("GET", "alice-key", "10.0.0.1").Code)
+	})
+
+	t.Run("Clients are keyed by API key before IP", func(t *testing.T) {
+		assert.Equal(t, http.StatusCreated, do("POST", "bob-key", "10.0.0.1").Code)
+		assert.Equal(t, http.StatusCreated, do("POST", "", "10.0.0.1").Code)
+		assert.Equal(t, http.StatusTooManyRequests, do("POST", "", "10.0.0.1").Code)
+		assert.Equal(t, http.StatusCreated, do("POST", "", "10.0.0.2").Code)
+	})
+
+	t.Run("Keys of one principal share a bucket", func(t *testing.T) {
+		assert.Equal(t, http.StatusTooManyRequests, do("GET", "alice-key2", "10.0.0.9").Code)
+	})
+
+	t.Run("Unknown keys fall back to the IP", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusOK, do("GET", fmt.Sprintf("bogus-%d", i), "10.0.0.3").Code)
+		}
+		assert.Equal(t, http.StatusTooManyRequests, do("GET", "bogus-3", "10.0.0.3").Code)
+		assert.Equal(t, http.StatusTooManyRequests, do("GET", "", "10.0.0.3").Code)
+	})
+}
+
+func TestRateLimit_ForwardedFor(t *testing.T) {
+	logger.Init()
+	gin.SetMode(gin.TestMode)
+
+	newEngine := func(trusted []string) *gin.Engine {
+		r := gin.New()
+		require.NoError(t, r.SetTrustedProxies(trusted))
+		r.Use(RateLimit(ratelimit.NewMemoryStore(), RateLimitPolicy{Default: ratelimit.Limit{Rate: 2, Period: time.Minute, Burst: 2}}, auth.KeyStore{}))
+		r.GET("/api/v1/song", func(c *gin.Context) { c.Status(200) })
+		return r
+	}
+	do := func(r *gin.Engine, forwardedFor string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/api/v1/song", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("Spoofed headers share the peer's bucket", func(t *testing.T) {
+		r := newEngine(nil)
+		assert.Equal(t, http.StatusOK, do(r, "203.0.113.1"))
+		assert.Equal(t, http.StatusOK, do(r, "203.0.113.2"))
+		assert.Equal(t, http.StatusTooManyRequests, do(r, "203.0.113.3"))
+	})
+
+	t.Run("Trusted proxies forward the client IP", func(t *testing.T) {
+		r := newEngine([]string{"10.0.0.0/8"})
+		assert.Equal(t, http.StatusOK, do(r, "203.0.113.1"))
+		assert.Equal(t, http.StatusOK, do(r, "203.0.113.1"))
+		assert.Equal(t, http.StatusTooManyRequests, do(r, "203.0.113.1"))
+		assert.Equal(t, http.StatusOK, do(r, "203.0.113.2"))
+	})
+}
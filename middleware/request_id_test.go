@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"awesomeProject/logger"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(RequestID())
+	r.GET("/missing", func(c *gin.Context) { RespondError(c, 404, "Song not found") })
+
+	t.Run("Accepts caller ID and echoes it in header and error body", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/missing", nil)
+		req.Header.Set(RequestIDHeader, "abc-123")
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, "abc-123", w.Header().Get(RequestIDHeader))
+		assert.JSONEq(t, `{"error":"Song not found","requestId":"abc-123"}`, w.Body.String())
+	})
+
+	t.Run("Generates an ID when missing or malformed", func(t *testing.T) {
+		for _, sent := range []string{"", "bad id\nwith newline", strings.Repeat("x", 200)} {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/missing", nil)
+			req.Header.Set(RequestIDHeader, sent)
+			r.ServeHTTP(w, req)
+
+			id := w.Header().Get(RequestIDHeader)
+			assert.Len(t, id, 36)
+			assert.NotEqual(t, sent, id)
+		}
+	})
+}
+
+func TestAccessLog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	path := filepath.Join(t.TempDir(), "access.log")
+	require.NoError(t, logger.InitWithConfig(logger.Config{Level: "info", Format: "json", Outputs: []string{path}}))
+	defer logger.Init()
+
+	r := gin.New()
+	r.Use(RequestID(), RequestLogger(), AccessLog(DefaultAccessLogConfig()), Recovery())
+	r.GET("/api/v1/song/:id/text", func(c *gin.Context) { c.String(200, "hello") })
+	r.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	req := httptest.NewRequest("GET", "/api/v1/song/7/text", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	req.Header.Set("X-API-Key", "super-secret")
+	req.Header.Set("Accept", "text/plain")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/panic", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), `"error":"Internal server error"`)
+	logger.Sync()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "super-secret")
+
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		if entry["msg"] == "Request completed" {
+			entries = append(entries, entry)
+		}
+	}
+	require.Len(t, entries, 2)
+
+	ok := entries[0]
+	assert.Equal(t, "req-1", ok["requestId"])
+	assert.Equal(t, "/api/v1/song/:id/text", ok["route"])
+	assert.Equal(t, "/api/v1/song/7/text", ok["path"])
+	assert.Equal(t, "GET", ok["method"])
+	assert.Equal(t, float64(200), ok["status"])
+	assert.Equal(t, float64(5), ok["bytes"])
+	assert.Contains(t, ok, "latency")
+	assert.Contains(t, ok, "clientIp")
+	headers := ok["headers"].(map[string]interface{})
+	assert.Equal(t, "[REDACTED]", headers["X-Api-Key"])
+	assert.Equal(t, "text/plain", headers["Accept"])
+
+	assert.Equal(t, "error", entries[1]["level"])
+	assert.Equal(t, float64(500), entries[1]["status"])
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"awesomeProject/tenant"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Tenant resolves the library the request operates on and stores it in the
+// request context, where the repositories pick it up. A principal bound to a
+// tenant always uses it; other principals may pick one with X-Tenant-ID.
+func Tenant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requested := c.GetHeader("X-Tenant-ID")
+		if len(c.Request.Header.Values("X-Tenant-ID")) > 0 && !tenant.ValidID(requested) {
+			Logger(c).Info("Invalid tenant requested", zap.Int("length", len(requested)))
+			RespondError(c, 400, fmt.Sprintf("X-Tenant-ID must be 1 to %d letters, digits, underscores or hyphens", tenant.MaxIDLength))
+			return
+		}
+		id := requested
+
+		if principal, ok := CurrentPrincipal(c); ok && principal.Tenant != "" {
+			if requested != "" && requested != principal.Tenant {
+				Logger(c).Info("Cross-tenant request rejected",
+					zap.String("subject", principal.Subject),
+					zap.String("tenant", requested))
+				RespondError(c, 403, "Forbidden")
+				return
+			}
+			id = principal.Tenant
+		}
+
+		if id == "" {
+			id = tenant.Default
+		}
+
+		c.Request = c.Request.WithContext(tenant.NewContext(c.Request.Context(), id))
+		withLogFields(c, zap.String("tenant", id))
+		c.Next()
+	}
+}
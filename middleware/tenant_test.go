@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"awesomeProject/auth"
+	"awesomeProject/logger"
+	"awesomeProject/tenant"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTenant(t *testing.T) {
+	logger.Init()
+	gin.SetMode(gin.TestMode)
+
+	keys := auth.KeyStore{
+		"bound-key":  {Subject: "bound", Role: auth.RoleReader, Tenant: "acme"},
+		"global-key": {Subject: "global", Role: auth.RoleAdmin},
+	}
+
+	r := gin.New()
+	r.GET("/tenant", Authenticate(keys), Tenant(), func(c *gin.Context) {
+		id, _ := tenant.FromContext(c.Request.Context())
+		c.String(200, id)
+	})
+
+	tests := []struct {
+		name   string
+		key    string
+		header string
+		code   int
+		tenant string
+	}{
+		{"Bound principal uses its tenant", "bound-key", "", http.StatusOK, "acme"},
+		{"Bound principal may repeat its tenant", "bound-key", "acme", http.StatusOK, "acme"},
+		{"Bound principal cannot switch tenant", "bound-key", "globex", http.StatusForbidden, ""},
+		{"Global principal selects tenant by header", "global-key", "globex", http.StatusOK, "globex"},
+		{"Global principal falls back to default", "global-key", "", http.StatusOK, tenant.Default},
+		{"Tenant IDs may use letters, digits, _ and -", "global-key", "Acme_2-eu", http.StatusOK, "Acme_2-eu"},
+		{"Blank tenant ID", "global-key", "   ", http.StatusBadRequest, ""},
+		{"Tenant ID with other characters", "global-key", "acme/../globex", http.StatusBadRequest, ""},
+		{"Tenant ID too long", "global-key", strings.Repeat("a", tenant.MaxIDLength+1), http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/tenant", nil)
+			req.Header.Set("X-API-Key", tt.key)
+			if tt.header != "" {
+				req.Header.Set("X-Tenant-ID", tt.header)
+			}
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.code, w.Code)
+			if tt.code == http.StatusOK {
+				assert.Equal(t, tt.tenant, w.Body.String())
+			}
+		})
+	}
+}
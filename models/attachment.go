@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// Attachment kinds: album art, sheet music and short audio previews.
+const (
+	AttachmentCover      = "cover"
+	AttachmentSheetMusic = "sheet_music"
+	AttachmentPreview    = "preview"
+)
+
+// AttachmentKind limits what may be uploaded as one kind of attachment.
+// Content types are sniffed from the file, not taken from the client.
+type AttachmentKind struct {
+	MaxSize      int64
+	ContentTypes []string
+}
+
+var AttachmentKinds = map[string]AttachmentKind{
+	AttachmentCover: {
+		MaxSize:      10 << 20,
+		ContentTypes: []string{"image/jpeg", "image/png", "image/gif", "image/webp"},
+	},
+	AttachmentSheetMusic: {
+		MaxSize:      20 << 20,
+		ContentTypes: []string{"application/pdf"},
+	},
+	AttachmentPreview: {
+		MaxSize:      20 << 20,
+		ContentTypes: []string{"audio/mpeg", "audio/ogg", "audio/wav", "audio/flac", "audio/mp4", "audio/x-m4a"},
+	},
+}
+
+// Attachment is a file stored with a song. The file itself lives in the blob
+// store under Key; covers also get a JPEG thumbnail under ThumbnailKey.
+type Attachment struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	SongID      uint      `json:"songId" gorm:"not null;index"`
+	Kind        string    `json:"kind" gorm:"size:32;not null"`
+	FileName    string    `json:"fileName" gorm:"size:255"`
+	ContentType string    `json:"contentType" gorm:"size:128;not null"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256" gorm:"size:64;not null"`
+	Key         string    `json:"-" gorm:"size:512;not null"`
+	Thumbnail   bool      `json:"thumbnail"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+func (a Attachment) ThumbnailKey() string {
+	return a.Key + ".thumb.jpg"
+}
+
+// BlobKeys lists every blob stored for the attachment.
+func (a Attachment) BlobKeys() []string {
+	if a.Thumbnail {
+		return []string{a.Key, a.ThumbnailKey()}
+	}
+	return []string{a.Key}
+}
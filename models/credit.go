@@ -0,0 +1,58 @@
+package models
+
+import (
+	"slices"
+	"strings"
+)
+
+// Credit roles. A person may hold several roles on the same song.
+const (
+	CreditRolePerformer = "performer"
+	CreditRoleFeatured  = "featured"
+	CreditRoleComposer  = "composer"
+	CreditRoleLyricist  = "lyricist"
+	CreditRoleProducer  = "producer"
+)
+
+var CreditRoles = []string{CreditRolePerformer, CreditRoleFeatured, CreditRoleComposer, CreditRoleLyricist, CreditRoleProducer}
+
+func ValidCreditRole(role string) bool {
+	return slices.Contains(CreditRoles, role)
+}
+
+// Person is someone credited on songs. Names are matched ignoring case, so
+// "Freddie Mercury" and "freddie mercury" are the same person.
+type Person struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	Name     string `json:"name" gorm:"size:128;not null;uniqueIndex:idx_people_tenant_name"`
+	TenantID string `json:"-" gorm:"size:64;not null;default:'default';uniqueIndex:idx_people_tenant_name"`
+}
+
+// Credit links a person to a song in a role. Position keeps the order the
+// credits were given in.
+type Credit struct {
+	SongID   uint   `gorm:"primaryKey"`
+	PersonID uint   `gorm:"primaryKey;index"`
+	Role     string `gorm:"primaryKey;size:16"`
+	Position int    `gorm:"not null"`
+}
+
+// SongCredit is a credit as it appears on a song.
+type SongCredit struct {
+	Person string `json:"person" binding:"required,min=1,max=128"`
+	Role   string `json:"role" binding:"required,oneof=performer featured composer lyricist producer"`
+}
+
+type SetCreditsRequest struct {
+	Credits []SongCredit `json:"credits" binding:"required,max=50,dive"`
+}
+
+type PersonCredits struct {
+	Name  string `json:"name"`
+	Songs int64  `json:"songs"`
+}
+
+// NormalizePersonName trims a name and collapses its inner whitespace.
+func NormalizePersonName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Favorite, Rating and Play record what a user (a principal's Subject) does
+// with a song.
+type Favorite struct {
+	UserID    string `gorm:"primaryKey;size:128"`
+	SongID    uint   `gorm:"primaryKey;index"`
+	CreatedAt time.Time
+}
+
+type Rating struct {
+	UserID    string `gorm:"primaryKey;size:128"`
+	SongID    uint   `gorm:"primaryKey;index"`
+	Stars     int    `gorm:"not null"`
+	UpdatedAt time.Time
+}
+
+type Play struct {
+	ID       uint      `gorm:"primaryKey"`
+	UserID   string    `gorm:"size:128;not null;index:idx_plays_user_song"`
+	SongID   uint      `gorm:"not null;index:idx_plays_user_song;index"`
+	PlayedAt time.Time `gorm:"not null"`
+}
+
+// SongStats aggregates every user's engagement with a song.
+type SongStats struct {
+	Favorites     int64   `json:"favorites"`
+	Ratings       int64   `json:"ratings"`
+	AverageRating float64 `json:"averageRating"`
+	Plays         int64   `json:"plays"`
+}
+
+type RateSongRequest struct {
+	Stars int `json:"stars" binding:"required,min=1,max=5"`
+}
+
+type PlayedSong struct {
+	Song  Song  `json:"song"`
+	Plays int64 `json:"plays"`
+}
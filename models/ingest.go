@@ -0,0 +1,37 @@
+package models
+
+// Ingest outcomes. A file is skipped when it cannot become a song, such as
+// when it has no usable tags or the song already exists, and failed when
+// creating the song went wrong and retrying may help.
+const (
+	IngestCreated = "created"
+	IngestSkipped = "skipped"
+	IngestFailed  = "failed"
+)
+
+type IngestResult struct {
+	File   string `json:"file"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+	Song   *Song  `json:"song,omitempty"`
+}
+
+// IngestReport lists the outcome of every uploaded file in upload order.
+type IngestReport struct {
+	Created int            `json:"created"`
+	Skipped int            `json:"skipped"`
+	Failed  int            `json:"failed"`
+	Items   []IngestResult `json:"items"`
+}
+
+func (r *IngestReport) Add(result IngestResult) {
+	switch result.Status {
+	case IngestCreated:
+		r.Created++
+	case IngestSkipped:
+		r.Skipped++
+	case IngestFailed:
+		r.Failed++
+	}
+	r.Items = append(r.Items, result)
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Link providers. Links to any other site are kept with ProviderOther.
+const (
+	ProviderYouTube    = "youtube"
+	ProviderSpotify    = "spotify"
+	ProviderAppleMusic = "applemusic"
+	ProviderSoundCloud = "soundcloud"
+	ProviderBandcamp   = "bandcamp"
+	ProviderOther      = "other"
+)
+
+var Providers = []string{ProviderYouTube, ProviderSpotify, ProviderAppleMusic, ProviderSoundCloud, ProviderBandcamp, ProviderOther}
+
+func ValidProvider(provider string) bool {
+	return slices.Contains(Providers, provider)
+}
+
+// MaxSongLinks caps how many links a song may have.
+const MaxSongLinks = 20
+
+// SongLink is one of a song's links. The first link is the song's primary
+// Link. Provider and ProviderID are derived from the URL when the song is
+// saved.
+type SongLink struct {
+	SongID     uint   `json:"-" gorm:"primaryKey"`
+	Position   int    `json:"-" gorm:"primaryKey"`
+	URL        string `json:"url" gorm:"size:2048;not null"`
+	Provider   string `json:"provider" gorm:"size:16;not null;index"`
+	ProviderID string `json:"providerId,omitempty" gorm:"size:255"`
+}
+
+// Link states derived from the last liveness check. A link that could not be
+// reached at all is recorded with status 0 and is broken.
+const (
+	LinkStateUnchecked = "unchecked"
+	LinkStateOK        = "ok"
+	LinkStateBroken    = "broken"
+)
+
+var LinkStates = []string{LinkStateUnchecked, LinkStateOK, LinkStateBroken}
+
+// LinkCheck is the result of checking a song's link.
+type LinkCheck struct {
+	SongID    uint
+	Link      string
+	Status    int
+	CheckedAt time.Time
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+type Playlist struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	Name        string `json:"name" gorm:"size:200;not null"`
+	Description string `json:"description"`
+	Owner       string `json:"owner" gorm:"size:128;index"`
+	// Rules make this a smart playlist whose songs are whichever songs
+	// currently match; static playlists have none.
+	Rules     *Rule          `json:"rules,omitempty" gorm:"serializer:json;type:text"`
+	TenantID  string         `json:"-" gorm:"size:64;index;not null;default:'default'"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+	Items     []PlaylistItem `json:"items,omitempty" gorm:"-"`
+}
+
+// PlaylistItem places a song at a 1-based position within a playlist.
+type PlaylistItem struct {
+	ID         uint  `json:"-" gorm:"primaryKey"`
+	PlaylistID uint  `json:"-" gorm:"not null;uniqueIndex:idx_playlist_song"`
+	SongID     uint  `json:"songId" gorm:"not null;uniqueIndex:idx_playlist_song;index"`
+	Position   int   `json:"position" gorm:"not null"`
+	Song       *Song `json:"song,omitempty" gorm:"-"`
+}
+
+type PlaylistRequest struct {
+	Name        string `json:"name" binding:"required,min=1,max=200"`
+	Description string `json:"description" binding:"max=2000"`
+	Rules       *Rule  `json:"rules"`
+}
+
+type PreviewRulesRequest struct {
+	Rules *Rule `json:"rules" binding:"required"`
+}
+
+type AddPlaylistSongRequest struct {
+	SongID uint `json:"songId" binding:"required"`
+	// Position is 1-based; zero appends the song.
+	Position int `json:"position" binding:"min=0"`
+}
+
+type ReorderPlaylistRequest struct {
+	SongIDs []uint `json:"songIds" binding:"required"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// QualityReview records that someone looked at a song's data-quality
+// issues and accepted them. The song is flagged again if new issues appear.
+type QualityReview struct {
+	SongID     uint     `gorm:"primaryKey"`
+	Issues     []string `gorm:"serializer:json;type:text"`
+	ReviewedAt time.Time
+}
+
+type FlaggedSong struct {
+	ID       uint     `json:"id"`
+	Group    string   `json:"group"`
+	Name     string   `json:"song"`
+	Issues   []string `json:"issues"`
+	Reviewed bool     `json:"reviewed"`
+}
+
+type QualityReport struct {
+	Scanned int64 `json:"scanned"`
+	Flagged int64 `json:"flagged"`
+	// Issues counts flagged songs by issue.
+	Issues map[string]int64 `json:"issues"`
+	Items  []FlaggedSong    `json:"items"`
+}
+
+// Repair actions: re-run enrichment from the music API, or accept the
+// current issues.
+const (
+	RepairReenrich = "reenrich"
+	RepairReview   = "review"
+)
+
+type QualityRepairRequest struct {
+	SongIDs []uint `json:"songIds" binding:"required,min=1,max=100"`
+	Action  string `json:"action" binding:"required,oneof=reenrich review"`
+}
+
+type RepairResult struct {
+	ID     uint     `json:"id"`
+	Status string   `json:"status"`
+	Issues []string `json:"issues,omitempty"`
+	Error  string   `json:"error,omitempty"`
+}
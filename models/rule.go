@@ -0,0 +1,169 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// Rule is a node in a smart playlist's rule tree. A node either combines
+// child rules with All, Any or Not, or tests a single song field.
+type Rule struct {
+	All    []Rule   `json:"all,omitempty"`
+	Any    []Rule   `json:"any,omitempty"`
+	Not    *Rule    `json:"not,omitempty"`
+	Field  string   `json:"field,omitempty"`
+	Op     string   `json:"op,omitempty"`
+	Value  string   `json:"value,omitempty"`
+	Values []string `json:"values,omitempty"`
+	// Role narrows a person condition to credits in that role.
+	Role string `json:"role,omitempty"`
+}
+
+// Rule fields. releaseYear is the last four characters of the release
+// date, which the music API formats as DD.MM.YYYY. genre matches songs in
+// the named genre or any of its sub-genres. person matches credited names,
+// ignoring case. provider matches any of the song's links. linkState is the
+// state of the primary link's last liveness check.
+const (
+	RuleFieldGroup       = "group"
+	RuleFieldSong        = "song"
+	RuleFieldLink        = "link"
+	RuleFieldReleaseDate = "releaseDate"
+	RuleFieldReleaseYear = "releaseYear"
+	RuleFieldTag         = "tag"
+	RuleFieldGenre       = "genre"
+	RuleFieldPerson      = "person"
+	RuleFieldProvider    = "provider"
+	RuleFieldLinkState   = "linkState"
+)
+
+const (
+	RuleOpContains = "contains"
+	RuleOpEquals   = "equals"
+	RuleOpIn       = "in"
+	RuleOpBetween  = "between"
+)
+
+var ruleOps = map[string][]string{
+	RuleFieldGroup:       {RuleOpContains, RuleOpEquals, RuleOpIn},
+	RuleFieldSong:        {RuleOpContains, RuleOpEquals, RuleOpIn},
+	RuleFieldLink:        {RuleOpContains, RuleOpEquals},
+	RuleFieldReleaseDate: {RuleOpEquals, RuleOpIn},
+	RuleFieldReleaseYear: {RuleOpEquals, RuleOpIn, RuleOpBetween},
+	RuleFieldTag:         {RuleOpEquals, RuleOpIn},
+	RuleFieldGenre:       {RuleOpEquals, RuleOpIn},
+	RuleFieldPerson:      {RuleOpEquals, RuleOpIn},
+	RuleFieldProvider:    {RuleOpEquals, RuleOpIn},
+	RuleFieldLinkState:   {RuleOpEquals, RuleOpIn},
+}
+
+const (
+	maxRuleDepth = 8
+	maxRuleNodes = 100
+)
+
+// Validate checks that the tree is well formed and small enough to evaluate.
+func (r Rule) Validate() error {
+	nodes := 0
+	return r.validate(1, &nodes)
+}
+
+func (r Rule) validate(depth int, nodes *int) error {
+	*nodes++
+	if depth > maxRuleDepth {
+		return fmt.Errorf("rules may be nested at most %d levels deep", maxRuleDepth)
+	}
+	if *nodes > maxRuleNodes {
+		return fmt.Errorf("rules may contain at most %d nodes", maxRuleNodes)
+	}
+
+	kinds := 0
+	for _, set := range []bool{r.All != nil, r.Any != nil, r.Not != nil, r.Field != ""} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		return errors.New("each rule needs exactly one of all, any, not or field")
+	}
+
+	switch {
+	case r.All != nil || r.Any != nil:
+		children := r.All
+		if r.Any != nil {
+			children = r.Any
+		}
+		if len(children) == 0 {
+			return errors.New("all and any need at least one rule")
+		}
+		for _, child := range children {
+			if err := child.validate(depth+1, nodes); err != nil {
+				return err
+			}
+		}
+		return nil
+	case r.Not != nil:
+		return r.Not.validate(depth+1, nodes)
+	}
+
+	ops, ok := ruleOps[r.Field]
+	if !ok {
+		return fmt.Errorf("unknown field %q", r.Field)
+	}
+	if !slices.Contains(ops, r.Op) {
+		return fmt.Errorf("field %q does not support op %q", r.Field, r.Op)
+	}
+
+	switch r.Op {
+	case RuleOpContains, RuleOpEquals:
+		if r.Value == "" {
+			return fmt.Errorf("op %q on %q needs a value", r.Op, r.Field)
+		}
+	case RuleOpIn:
+		if len(r.Values) == 0 {
+			return fmt.Errorf("op %q on %q needs values", r.Op, r.Field)
+		}
+	case RuleOpBetween:
+		if len(r.Values) != 2 {
+			return fmt.Errorf("op %q on %q needs two values", r.Op, r.Field)
+		}
+	}
+
+	if r.Role != "" {
+		if r.Field != RuleFieldPerson {
+			return fmt.Errorf("role only applies to %q", RuleFieldPerson)
+		}
+		if !ValidCreditRole(r.Role) {
+			return fmt.Errorf("unknown role %q", r.Role)
+		}
+	}
+
+	if r.Field == RuleFieldProvider {
+		for _, v := range append([]string{r.Value}, r.Values...) {
+			if v != "" && !ValidProvider(v) {
+				return fmt.Errorf("unknown provider %q", v)
+			}
+		}
+	}
+	if r.Field == RuleFieldLinkState {
+		for _, v := range append([]string{r.Value}, r.Values...) {
+			if v != "" && !slices.Contains(LinkStates, v) {
+				return fmt.Errorf("unknown link state %q", v)
+			}
+		}
+	}
+
+	if r.Field == RuleFieldReleaseYear {
+		for _, v := range append([]string{r.Value}, r.Values...) {
+			if v == "" {
+				continue
+			}
+			if _, err := strconv.Atoi(v); err != nil || len(v) != 4 {
+				return fmt.Errorf("releaseYear %q must be a four digit year", v)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRule_Validate(t *testing.T) {
+	contains := func(field, value string) Rule { return Rule{Field: field, Op: RuleOpContains, Value: value} }
+
+	valid := []Rule{
+		contains(RuleFieldGroup, "muse"),
+		{All: []Rule{contains(RuleFieldGroup, "muse"), {Not: &Rule{Field: RuleFieldSong, Op: RuleOpIn, Values: []string{"a", "b"}}}}},
+		{Any: []Rule{{Field: RuleFieldReleaseYear, Op: RuleOpBetween, Values: []string{"2000", "2009"}}}},
+		{Field: RuleFieldPerson, Op: RuleOpEquals, Value: "Brian May", Role: CreditRoleComposer},
+		{Field: RuleFieldProvider, Op: RuleOpIn, Values: []string{ProviderSpotify, ProviderYouTube}},
+		{Field: RuleFieldLinkState, Op: RuleOpEquals, Value: LinkStateBroken},
+	}
+	for _, rule := range valid {
+		assert.NoError(t, rule.Validate())
+	}
+
+	deep := contains(RuleFieldGroup, "x")
+	for i := 0; i < maxRuleDepth; i++ {
+		deep = Rule{Not: &deep}
+	}
+	wide := Rule{Any: make([]Rule, maxRuleNodes)}
+	for i := range wide.Any {
+		wide.Any[i] = contains(RuleFieldGroup, "x")
+	}
+
+	invalid := map[string]Rule{
+		"empty":              {},
+		"two kinds":          {Field: RuleFieldGroup, Op: RuleOpContains, Value: "x", All: []Rule{contains(RuleFieldSong, "y")}},
+		"empty group":        {All: []Rule{}},
+		"unknown field":      contains("mood", "happy"),
+		"unsupported op":     {Field: RuleFieldLink, Op: RuleOpBetween, Values: []string{"a", "b"}},
+		"missing value":      contains(RuleFieldGroup, ""),
+		"missing values":     {Field: RuleFieldGroup, Op: RuleOpIn},
+		"between arity":      {Field: RuleFieldReleaseYear, Op: RuleOpBetween, Values: []string{"2000"}},
+		"year not a number":  {Field: RuleFieldReleaseYear, Op: RuleOpEquals, Value: "20x0"},
+		"role off person":    {Field: RuleFieldGroup, Op: RuleOpEquals, Value: "x", Role: CreditRoleComposer},
+		"unknown role":       {Field: RuleFieldPerson, Op: RuleOpEquals, Value: "x", Role: "drummer"},
+		"unknown provider":   {Field: RuleFieldProvider, Op: RuleOpEquals, Value: "napster"},
+		"unknown link state": {Field: RuleFieldLinkState, Op: RuleOpEquals, Value: "dead"},
+		"invalid child":      {Any: []Rule{contains(RuleFieldGroup, "x"), contains("mood", "y")}},
+		"too deep":           deep,
+		"too many nodes":     wide,
+	}
+	for name, rule := range invalid {
+		assert.Error(t, rule.Validate(), name)
+	}
+}
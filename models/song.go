@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type Song struct {
 	ID          uint   `json:"id" gorm:"primaryKey"`
 	Group       string `json:"group" binding:"required"`
@@ -7,11 +9,26 @@ type Song struct {
 	ReleaseDate string `json:"releaseDate"`
 	Text        string `json:"text"`
 	Link        string `json:"link"`
+	TenantID    string `json:"-" gorm:"size:64;index;not null;default:'default'"`
+	// LinkStatus and LinkCheckedAt record the last liveness check of Link.
+	// They are maintained by the link checker and reset when Link changes.
+	LinkStatus    int        `json:"linkStatus,omitempty"`
+	LinkCheckedAt *time.Time `json:"linkCheckedAt,omitempty" gorm:"index"`
+	// Links holds every link of the song, starting with Link.
+	Links []SongLink `json:"links,omitempty" gorm:"-"`
+	// Tags, Genres, Credits and Stats are loaded by the repository and are
+	// read-only here; they are managed through their own endpoints.
+	Tags    []string     `json:"tags,omitempty" gorm:"-"`
+	Genres  []string     `json:"genres,omitempty" gorm:"-"`
+	Credits []SongCredit `json:"credits,omitempty" gorm:"-"`
+	Stats   *SongStats   `json:"stats,omitempty" gorm:"-"`
 }
 
 type CreateSongRequest struct {
 	Group string `json:"group" binding:"required,min=1"`
 	Song  string `json:"song" binding:"required,min=1"`
+	// Links are added after the link the music API returns.
+	Links []string `json:"links" binding:"max=20"`
 }
 
 type SongDetail struct {
@@ -19,3 +36,16 @@ type SongDetail struct {
 	Text        string `json:"text"`
 	Link        string `json:"link"`
 }
+
+// LinkState reports the state of the song's Link, or "" if it has none.
+func (s Song) LinkState() string {
+	switch {
+	case s.Link == "":
+		return ""
+	case s.LinkCheckedAt == nil:
+		return LinkStateUnchecked
+	case s.LinkStatus > 0 && s.LinkStatus < 400:
+		return LinkStateOK
+	}
+	return LinkStateBroken
+}
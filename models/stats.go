@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// LibraryStats summarises a tenant's catalog.
+type LibraryStats struct {
+	Songs int64 `json:"songs"`
+	// ByArtist holds the artists with the most songs, most songs first.
+	ByArtist []StatsCount `json:"byArtist"`
+	// ByYear and ByDecade count songs with a parsable release year, oldest
+	// first.
+	ByYear   []StatsCount  `json:"byYear"`
+	ByDecade []StatsCount  `json:"byDecade"`
+	Missing  MissingCounts `json:"missing"`
+	// AverageTextLength is in characters, over songs that have text.
+	AverageTextLength float64 `json:"averageTextLength"`
+	// EnrichmentCoverage is the share of songs with text, link and release
+	// date all filled in, from 0 to 1.
+	EnrichmentCoverage float64   `json:"enrichmentCoverage"`
+	GeneratedAt        time.Time `json:"generatedAt"`
+}
+
+type StatsCount struct {
+	Key   string `json:"key"`
+	Count int64  `json:"count"`
+}
+
+type MissingCounts struct {
+	Text        int64 `json:"text"`
+	Link        int64 `json:"link"`
+	ReleaseDate int64 `json:"releaseDate"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import "strings"
+
+// Tag is a free-form label. Names are normalised with NormalizeTag and are
+// unique per tenant.
+type Tag struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	Name     string `json:"name" gorm:"size:64;not null;uniqueIndex:idx_tags_tenant_name"`
+	TenantID string `json:"-" gorm:"size:64;not null;default:'default';uniqueIndex:idx_tags_tenant_name"`
+}
+
+type SongTag struct {
+	SongID uint `gorm:"primaryKey"`
+	TagID  uint `gorm:"primaryKey;index"`
+}
+
+// Genre is an entry in the curated genre taxonomy. A song in a genre also
+// counts as being in every ancestor genre.
+type Genre struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	Name     string `json:"name" gorm:"size:64;not null;uniqueIndex:idx_genres_tenant_name"`
+	ParentID *uint  `json:"parentId,omitempty" gorm:"index"`
+	TenantID string `json:"-" gorm:"size:64;not null;default:'default';uniqueIndex:idx_genres_tenant_name"`
+}
+
+type SongGenre struct {
+	SongID  uint `gorm:"primaryKey"`
+	GenreID uint `gorm:"primaryKey;index"`
+}
+
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+type BulkTagRequest struct {
+	SongIDs []uint   `json:"songIds" binding:"required,min=1,max=500"`
+	Tags    []string `json:"tags" binding:"required,min=1,max=50,dive,min=1,max=64"`
+}
+
+type GenreRequest struct {
+	Name     string `json:"name" binding:"required,min=1,max=64"`
+	ParentID *uint  `json:"parentId"`
+}
+
+type SetGenresRequest struct {
+	GenreIDs []uint `json:"genreIds" binding:"required,max=50"`
+}
+
+// NormalizeTag lower-cases a tag and collapses its whitespace so "Live  Rock"
+// and "live rock" are the same tag.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.Join(strings.Fields(tag), " "))
+}
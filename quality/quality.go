@@ -0,0 +1,81 @@
+// Package quality flags songs whose stored details look incomplete or wrong,
+// typically because enrichment from the music API failed.
+package quality
+
+import (
+	"awesomeProject/links"
+	"awesomeProject/models"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Issues reported by Inspect.
+const (
+	IssueMissingText        = "missing_text"
+	IssueMissingLink        = "missing_link"
+	IssueMissingReleaseDate = "missing_release_date"
+	IssueMalformedLink      = "malformed_link"
+	IssueInvalidReleaseDate = "invalid_release_date"
+	IssueSuspiciousText     = "suspicious_text"
+)
+
+// ReleaseDateLayout is the format the music API uses for release dates.
+const ReleaseDateLayout = "02.01.2006"
+
+var htmlTag = regexp.MustCompile(`(?i)</?(html|head|body|div|p|span|br|script|style|title|h[1-6])\b[^>]*>|<!doctype`)
+
+// errorPhrases mark lyrics that are really an error message. They are only
+// matched against short texts, where they cannot be part of a verse.
+var errorPhrases = []string{
+	"internal server error", "not found", "bad gateway", "service unavailable",
+	"exception", "traceback", "stack trace", "error:", "\"error\"", "null", "undefined",
+}
+
+const shortText = 200
+
+// Inspect returns the issues found in song, in a stable order.
+func Inspect(song models.Song) []string {
+	var issues []string
+	if strings.TrimSpace(song.Text) == "" {
+		issues = append(issues, IssueMissingText)
+	}
+	if strings.TrimSpace(song.Link) == "" {
+		issues = append(issues, IssueMissingLink)
+	}
+	if strings.TrimSpace(song.ReleaseDate) == "" {
+		issues = append(issues, IssueMissingReleaseDate)
+	}
+	if strings.TrimSpace(song.Link) != "" && links.Validate(song.Link) != nil {
+		issues = append(issues, IssueMalformedLink)
+	}
+	if strings.TrimSpace(song.ReleaseDate) != "" {
+		if _, err := time.Parse(ReleaseDateLayout, song.ReleaseDate); err != nil {
+			issues = append(issues, IssueInvalidReleaseDate)
+		}
+	}
+	if strings.TrimSpace(song.Text) != "" && suspicious(song.Text) {
+		issues = append(issues, IssueSuspiciousText)
+	}
+	return issues
+}
+
+func suspicious(text string) bool {
+	if htmlTag.MatchString(text) {
+		return true
+	}
+	trimmed := strings.TrimSpace(text)
+	if strings.HasPrefix(trimmed, "{") && strings.HasSuffix(trimmed, "}") {
+		return true
+	}
+	if len(trimmed) > shortText {
+		return false
+	}
+	lower := strings.ToLower(trimmed)
+	for _, phrase := range errorPhrases {
+		if strings.Contains(lower, phrase) {
+			return true
+		}
+	}
+	return false
+}
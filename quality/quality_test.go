@@ -0,0 +1,38 @@
+package quality
+
+import (
+	"awesomeProject/models"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	good := models.Song{
+		Group: "Muse", Name: "Uprising", ReleaseDate: "16.07.2009",
+		Text: "Paranoia is in bloom\n\nThe PR transmissions will resume", Link: "https://example.com/uprising",
+	}
+	assert.Empty(t, Inspect(good))
+
+	for name, tc := range map[string]struct {
+		edit func(*models.Song)
+		want []string
+	}{
+		"empty":           {func(s *models.Song) { s.Text, s.Link, s.ReleaseDate = "", " ", "" }, []string{IssueMissingText, IssueMissingLink, IssueMissingReleaseDate}},
+		"relative link":   {func(s *models.Song) { s.Link = "/watch?v=1" }, []string{IssueMalformedLink}},
+		"ftp link":        {func(s *models.Song) { s.Link = "ftp://example.com/a" }, []string{IssueMalformedLink}},
+		"american date":   {func(s *models.Song) { s.ReleaseDate = "07/16/2009" }, []string{IssueInvalidReleaseDate}},
+		"impossible date": {func(s *models.Song) { s.ReleaseDate = "31.02.2009" }, []string{IssueInvalidReleaseDate}},
+		"html":            {func(s *models.Song) { s.Text = "<html><body>Oops</body></html>" }, []string{IssueSuspiciousText}},
+		"json":            {func(s *models.Song) { s.Text = `{"message": "rate limited"}` }, []string{IssueSuspiciousText}},
+		"error message":   {func(s *models.Song) { s.Text = "Error: song not found" }, []string{IssueSuspiciousText}},
+		"long lyrics mentioning an error": {
+			func(s *models.Song) { s.Text = strings.Repeat("la ", 100) + "my one mistake, my error" },
+			nil,
+		},
+	} {
+		song := good
+		tc.edit(&song)
+		assert.Equal(t, tc.want, Inspect(song), name)
+	}
+}
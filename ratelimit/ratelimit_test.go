@@ -0,0 +1,50 @@
+package ratelimit
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ParseLimit("10/m")
+	require.NoError(t, err)
+	assert.Equal(t, Limit{Rate: 10, Period: time.Minute, Burst: 10}, limit)
+
+	for _, invalid := range []string{"10", "0/s", "x/s", "10/d"} {
+		_, err := ParseLimit(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestMemoryStore_Take(t *testing.T) {
+	now := time.Unix(0, 0)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	limit := Limit{Rate: 2, Period: time.Second, Burst: 2}
+
+	first, _ := store.Take("client", limit)
+	assert.True(t, first.Allowed)
+	assert.Equal(t, 1, first.Remaining)
+
+	second, _ := store.Take("client", limit)
+	assert.True(t, second.Allowed)
+	assert.Equal(t, 0, second.Remaining)
+	assert.Equal(t, time.Second, second.Reset)
+
+	denied, _ := store.Take("client", limit)
+	assert.False(t, denied.Allowed)
+	assert.Equal(t, 500*time.Millisecond, denied.RetryAfter)
+
+	other, _ := store.Take("other", limit)
+	assert.True(t, other.Allowed, "buckets are per key")
+
+	now = now.Add(500 * time.Millisecond)
+	refilled, _ := store.Take("client", limit)
+	assert.True(t, refilled.Allowed)
+
+	now = now.Add(time.Hour)
+	store.Take("client", limit)
+	assert.Len(t, store.buckets, 1, "idle full buckets are swept")
+}
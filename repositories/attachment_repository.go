@@ -0,0 +1,146 @@
+package repositories
+
+import (
+	"awesomeProject/blobstore"
+	"awesomeProject/logger"
+	"awesomeProject/models"
+	"awesomeProject/tenant"
+	"awesomeProject/tracing"
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"strconv"
+)
+
+var ErrAttachmentNotFound = errors.New("attachment not found")
+
+// AttachmentRepository records the files stored with songs. The files
+// themselves are kept in a blobstore.Store.
+type AttachmentRepository interface {
+	// List returns a song's attachments, oldest first.
+	List(ctx context.Context, songID uint) ([]models.Attachment, error)
+	Get(ctx context.Context, songID, id uint) (*models.Attachment, error)
+	Create(ctx context.Context, attachment *models.Attachment) error
+	Delete(ctx context.Context, songID, id uint) error
+}
+
+type SQLAttachmentRepository struct {
+	db *gorm.DB
+}
+
+func NewSQLAttachmentRepository(db *gorm.DB) *SQLAttachmentRepository {
+	return &SQLAttachmentRepository{db: db}
+}
+
+func (r *SQLAttachmentRepository) List(ctx context.Context, songID uint) (_ []models.Attachment, err error) {
+	ctx, span := tracer.Start(ctx, "AttachmentRepository.List")
+	defer func() { tracing.End(span, err) }()
+
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return nil, ErrNoTenant
+	}
+	db := conn(ctx, r.db)
+	if err := requireSongs(db, tenantID, []uint{songID}); err != nil {
+		return nil, err
+	}
+	attachments := []models.Attachment{}
+	err = db.Where("song_id = ?", songID).Order("id").Find(&attachments).Error
+	return attachments, err
+}
+
+func (r *SQLAttachmentRepository) Get(ctx context.Context, songID, id uint) (_ *models.Attachment, err error) {
+	ctx, span := tracer.Start(ctx, "AttachmentRepository.Get")
+	defer func() { tracing.End(span, err) }()
+
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return nil, ErrNoTenant
+	}
+	var attachment models.Attachment
+	err = conn(ctx, r.db).
+		Joins("JOIN songs ON songs.id = attachments.song_id").
+		Where("songs.tenant_id = ? AND attachments.song_id = ? AND attachments.id = ?", tenantID, songID, id).
+		Take(&attachment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+func (r *SQLAttachmentRepository) Create(ctx context.Context, attachment *models.Attachment) (err error) {
+	ctx, span := tracer.Start(ctx, "AttachmentRepository.Create")
+	defer func() { tracing.End(span, err) }()
+
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := requireSongs(tx, tenantID, []uint{attachment.SongID}); err != nil {
+			return err
+		}
+		return tx.Create(attachment).Error
+	})
+}
+
+func (r *SQLAttachmentRepository) Delete(ctx context.Context, songID, id uint) (err error) {
+	ctx, span := tracer.Start(ctx, "AttachmentRepository.Delete")
+	defer func() { tracing.End(span, err) }()
+
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := requireSongs(tx, tenantID, []uint{songID}); err != nil {
+			return err
+		}
+		result := tx.Where("song_id = ? AND id = ?", songID, id).Delete(&models.Attachment{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrAttachmentNotFound
+		}
+		return nil
+	})
+}
+
+// BlobCleaningSongRepository removes the blobs of a song's attachments after
+// the song is deleted. A blob that cannot be removed is logged and left
+// behind; the song stays deleted.
+type BlobCleaningSongRepository struct {
+	SongRepository
+	attachments AttachmentRepository
+	blobs       blobstore.Store
+}
+
+func NewBlobCleaningSongRepository(songs SongRepository, attachments AttachmentRepository, blobs blobstore.Store) *BlobCleaningSongRepository {
+	return &BlobCleaningSongRepository{SongRepository: songs, attachments: attachments, blobs: blobs}
+}
+
+func (r *BlobCleaningSongRepository) Delete(ctx context.Context, id string) error {
+	var attachments []models.Attachment
+	if songID, err := strconv.ParseUint(id, 10, 0); err == nil {
+		attachments, err = r.attachments.List(ReadFromPrimary(ctx), uint(songID))
+		if err != nil && !errors.Is(err, ErrSongNotFound) {
+			return err
+		}
+	}
+	if err := r.SongRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	for _, attachment := range attachments {
+		for _, key := range attachment.BlobKeys() {
+			if err := r.blobs.Delete(context.WithoutCancel(ctx), key); err != nil {
+				logger.FromContext(ctx).Warn("Failed to delete attachment blob", zap.String("key", key), zap.Error(err))
+			}
+		}
+	}
+	return nil
+}
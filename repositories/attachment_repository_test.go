@@ -0,0 +1,179 @@
+package repositories
+
+import (
+	"awesomeProject/blobstore"
+	"awesomeProject/logger"
+	"awesomeProject/models"
+	"awesomeProject/tenant"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"strings"
+	"testing"
+)
+
+func testAttachmentRepository(t *testing.T, newRepos func(t *testing.T) (SongRepository, AttachmentRepository)) {
+	ctx := tenant.NewContext(context.Background(), tenant.Default)
+
+	setup := func(t *testing.T) (SongRepository, AttachmentRepository, uint) {
+		songs, attachments := newRepos(t)
+		song := models.Song{Group: "Band", Name: "One"}
+		require.NoError(t, songs.Create(ctx, &song))
+		return songs, attachments, song.ID
+	}
+	attachment := func(songID uint, kind string) *models.Attachment {
+		return &models.Attachment{
+			SongID: songID, Kind: kind, FileName: kind + ".bin", ContentType: "image/png",
+			Size: 3, SHA256: "abc", Key: fmt.Sprintf("default/songs/%d/%s", songID, kind),
+		}
+	}
+
+	t.Run("Create, List, Get and Delete", func(t *testing.T) {
+		_, attachments, songID := setup(t)
+		cover := attachment(songID, models.AttachmentCover)
+		cover.Thumbnail = true
+		require.NoError(t, attachments.Create(ctx, cover))
+		require.NoError(t, attachments.Create(ctx, attachment(songID, models.AttachmentSheetMusic)))
+		assert.NotZero(t, cover.ID)
+		assert.False(t, cover.CreatedAt.IsZero())
+
+		list, err := attachments.List(ctx, songID)
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+		assert.Equal(t, []string{models.AttachmentCover, models.AttachmentSheetMusic}, []string{list[0].Kind, list[1].Kind})
+
+		got, err := attachments.Get(ctx, songID, cover.ID)
+		require.NoError(t, err)
+		assert.Equal(t, cover.Key, got.Key)
+		assert.True(t, got.Thumbnail)
+
+		require.NoError(t, attachments.Delete(ctx, songID, cover.ID))
+		_, err = attachments.Get(ctx, songID, cover.ID)
+		assert.ErrorIs(t, err, ErrAttachmentNotFound)
+		assert.ErrorIs(t, attachments.Delete(ctx, songID, cover.ID), ErrAttachmentNotFound)
+		list, err = attachments.List(ctx, songID)
+		require.NoError(t, err)
+		assert.Len(t, list, 1)
+	})
+
+	t.Run("List of a song without attachments is empty", func(t *testing.T) {
+		_, attachments, songID := setup(t)
+		list, err := attachments.List(ctx, songID)
+		require.NoError(t, err)
+		assert.NotNil(t, list)
+		assert.Empty(t, list)
+	})
+
+	t.Run("missing songs", func(t *testing.T) {
+		_, attachments, songID := setup(t)
+		assert.ErrorIs(t, attachments.Create(ctx, attachment(999, models.AttachmentCover)), ErrSongNotFound)
+		_, err := attachments.List(ctx, 999)
+		assert.ErrorIs(t, err, ErrSongNotFound)
+
+		cover := attachment(songID, models.AttachmentCover)
+		require.NoError(t, attachments.Create(ctx, cover))
+		_, err = attachments.Get(ctx, 999, cover.ID)
+		assert.ErrorIs(t, err, ErrAttachmentNotFound, "the attachment belongs to another song")
+	})
+
+	t.Run("deleting a song drops its attachments", func(t *testing.T) {
+		songs, attachments, songID := setup(t)
+		cover := attachment(songID, models.AttachmentCover)
+		require.NoError(t, attachments.Create(ctx, cover))
+		require.NoError(t, songs.Delete(ctx, fmt.Sprint(songID)))
+
+		_, err := attachments.Get(ctx, songID, cover.ID)
+		assert.ErrorIs(t, err, ErrAttachmentNotFound)
+	})
+
+	t.Run("tenants are isolated", func(t *testing.T) {
+		_, attachments, songID := setup(t)
+		cover := attachment(songID, models.AttachmentCover)
+		require.NoError(t, attachments.Create(ctx, cover))
+
+		other := tenant.NewContext(context.Background(), "other")
+		_, err := attachments.Get(other, songID, cover.ID)
+		assert.ErrorIs(t, err, ErrAttachmentNotFound)
+		_, err = attachments.List(other, songID)
+		assert.ErrorIs(t, err, ErrSongNotFound)
+		assert.ErrorIs(t, attachments.Delete(other, songID, cover.ID), ErrSongNotFound)
+		assert.ErrorIs(t, attachments.Create(other, attachment(songID, models.AttachmentPreview)), ErrSongNotFound)
+	})
+
+	t.Run("requires a tenant", func(t *testing.T) {
+		_, attachments := newRepos(t)
+		_, err := attachments.List(context.Background(), 1)
+		assert.ErrorIs(t, err, ErrNoTenant)
+	})
+}
+
+func TestSQLAttachmentRepository_SQLite(t *testing.T) {
+	testAttachmentRepository(t, func(t *testing.T) (SongRepository, AttachmentRepository) {
+		db := setupSQLiteDB(t)
+		return NewSQLSongRepository(db), NewSQLAttachmentRepository(db)
+	})
+}
+
+func TestMemoryAttachmentRepository(t *testing.T) {
+	testAttachmentRepository(t, func(t *testing.T) (SongRepository, AttachmentRepository) {
+		songs := NewMemorySongRepository()
+		return songs, NewMemoryAttachmentRepository(songs)
+	})
+}
+
+func TestBlobCleaningSongRepository(t *testing.T) {
+	ctx := tenant.NewContext(context.Background(), tenant.Default)
+	blobs, err := blobstore.NewLocal(t.TempDir())
+	require.NoError(t, err)
+	memory := NewMemorySongRepository()
+	attachments := NewMemoryAttachmentRepository(memory)
+	songs := NewBlobCleaningSongRepository(memory, attachments, blobs)
+
+	song := models.Song{Group: "Band", Name: "One"}
+	require.NoError(t, songs.Create(ctx, &song))
+	cover := models.Attachment{SongID: song.ID, Kind: models.AttachmentCover, ContentType: "image/png", Key: "default/songs/cover", Thumbnail: true}
+	require.NoError(t, attachments.Create(ctx, &cover))
+	for _, key := range cover.BlobKeys() {
+		require.NoError(t, blobs.Put(ctx, key, strings.NewReader("x"), 1, "image/png"))
+	}
+	require.NoError(t, blobs.Put(ctx, "default/songs/other", strings.NewReader("x"), 1, "image/png"))
+
+	require.NoError(t, songs.Delete(ctx, fmt.Sprint(song.ID)))
+	for _, key := range cover.BlobKeys() {
+		_, err := blobs.Get(ctx, key)
+		assert.ErrorIs(t, err, blobstore.ErrNotFound, key)
+	}
+	other, err := blobs.Get(ctx, "default/songs/other")
+	require.NoError(t, err)
+	other.Close()
+
+	assert.NoError(t, songs.Delete(ctx, fmt.Sprint(song.ID)), "deleting a missing song is still fine")
+	assert.NoError(t, songs.Delete(ctx, "not-a-number"))
+}
+
+type failingDeleteStore struct{ blobstore.Store }
+
+func (failingDeleteStore) Delete(context.Context, string) error { return errors.New("bucket gone") }
+
+func TestBlobCleaningSongRepository_LogsWithRequestLogger(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	ctx := logger.WithContext(tenant.NewContext(context.Background(), tenant.Default), zap.New(core).With(zap.String("requestId", "req-1")))
+	blobs, err := blobstore.NewLocal(t.TempDir())
+	require.NoError(t, err)
+	memory := NewMemorySongRepository()
+	attachments := NewMemoryAttachmentRepository(memory)
+	songs := NewBlobCleaningSongRepository(memory, attachments, failingDeleteStore{blobs})
+
+	song := models.Song{Group: "Band", Name: "One"}
+	require.NoError(t, songs.Create(ctx, &song))
+	require.NoError(t, attachments.Create(ctx, &models.Attachment{SongID: song.ID, Kind: models.AttachmentPreview, ContentType: "audio/mpeg", Key: "default/songs/preview"}))
+
+	require.NoError(t, songs.Delete(ctx, fmt.Sprint(song.ID)), "blob failures do not fail the delete")
+	entries := logs.FilterMessage("Failed to delete attachment blob").All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "req-1", entries[0].ContextMap()["requestId"])
+}
@@ -0,0 +1,454 @@
+package repositories
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/tenant"
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testSongRepository runs the behaviour every SongRepository backend must
+// share, so handlers can be wired to any of them interchangeably.
+func testSongRepository(t *testing.T, newRepo func(t *testing.T) SongRepository) {
+	ctx := tenant.NewContext(context.Background(), tenant.Default)
+
+	seed := func(t *testing.T, repo SongRepository) []models.Song {
+		songs := []models.Song{
+			{Group: "Muse", Name: "Uprising", ReleaseDate: "16.07.2009", Link: "https://example.com/Uprising"},
+			{Group: "Muse", Name: "Starlight", ReleaseDate: "04.09.2006", Link: "https://example.com/starlight"},
+			{Group: "Queen", Name: "100% Pure", ReleaseDate: "16.07.2009"},
+			{Group: "Queen_Tribute", Name: "Under Pressure"},
+		}
+		for i := range songs {
+			require.NoError(t, repo.Create(ctx, &songs[i]))
+			require.NotZero(t, songs[i].ID)
+		}
+		return songs
+	}
+
+	names := func(songs []models.Song) []string {
+		out := make([]string, len(songs))
+		for i, s := range songs {
+			out[i] = s.Name
+		}
+		return out
+	}
+
+	t.Run("Create and GetByID", func(t *testing.T) {
+		repo := newRepo(t)
+		songs := seed(t, repo)
+
+		got, err := repo.GetByID(ctx, fmt.Sprint(songs[0].ID))
+		require.NoError(t, err)
+		want := songs[0]
+		want.Stats = &models.SongStats{}
+		assert.Equal(t, want, *got)
+
+		_, err = repo.GetByID(ctx, "999999")
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("List filters", func(t *testing.T) {
+		repo := newRepo(t)
+		seed(t, repo)
+
+		for _, tc := range []struct {
+			name    string
+			filters map[string]string
+			want    []string
+		}{
+			{"none", map[string]string{}, []string{"Uprising", "Starlight", "100% Pure", "Under Pressure"}},
+			{"group ignores case", map[string]string{FilterGroup: "mUSe"}, []string{"Uprising", "Starlight"}},
+			{"song is a substring match", map[string]string{FilterSong: "light"}, []string{"Starlight"}},
+			{"release date is exact", map[string]string{FilterReleaseDate: "16.07.2009"}, []string{"Uprising", "100% Pure"}},
+			{"release date does not match substrings", map[string]string{FilterReleaseDate: "2009"}, []string{}},
+			{"link ignores case", map[string]string{FilterLink: "uprising"}, []string{"Uprising"}},
+			{"percent is literal", map[string]string{FilterSong: "0% p"}, []string{"100% Pure"}},
+			{"underscore is literal", map[string]string{FilterGroup: "n_t"}, []string{"Under Pressure"}},
+			{"filters combine", map[string]string{FilterGroup: "queen", FilterReleaseDate: "16.07.2009"}, []string{"100% Pure"}},
+			{"provider", map[string]string{FilterProvider: models.ProviderOther}, []string{"Uprising", "Starlight"}},
+			{"provider without links", map[string]string{FilterProvider: models.ProviderSpotify}, []string{}},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				songs, total, err := repo.List(ctx, 1, 10, tc.filters)
+				require.NoError(t, err)
+				assert.Equal(t, int64(len(tc.want)), total)
+				assert.Equal(t, tc.want, names(songs))
+			})
+		}
+	})
+
+	t.Run("Search rule trees", func(t *testing.T) {
+		repo := newRepo(t)
+		seed(t, repo)
+
+		contains := func(field, value string) models.Rule {
+			return models.Rule{Field: field, Op: models.RuleOpContains, Value: value}
+		}
+		for _, tc := range []struct {
+			name string
+			rule models.Rule
+			want []string
+		}{
+			{"any", models.Rule{Any: []models.Rule{contains(models.RuleFieldSong, "star"), contains(models.RuleFieldGroup, "tribute")}},
+				[]string{"Starlight", "Under Pressure"}},
+			{"not", models.Rule{All: []models.Rule{contains(models.RuleFieldGroup, "muse"), {Not: &models.Rule{Field: models.RuleFieldSong, Op: models.RuleOpEquals, Value: "Uprising"}}}},
+				[]string{"Starlight"}},
+			{"in", models.Rule{Field: models.RuleFieldGroup, Op: models.RuleOpIn, Values: []string{"Queen", "Queen_Tribute"}},
+				[]string{"100% Pure", "Under Pressure"}},
+			{"release year between", models.Rule{Field: models.RuleFieldReleaseYear, Op: models.RuleOpBetween, Values: []string{"2007", "2010"}},
+				[]string{"Uprising", "100% Pure"}},
+			{"release year equals", models.Rule{Field: models.RuleFieldReleaseYear, Op: models.RuleOpEquals, Value: "2006"},
+				[]string{"Starlight"}},
+			{"nested", models.Rule{Any: []models.Rule{
+				{All: []models.Rule{contains(models.RuleFieldGroup, "muse"), {Field: models.RuleFieldReleaseYear, Op: models.RuleOpIn, Values: []string{"2006"}}}},
+				{Not: &models.Rule{Any: []models.Rule{contains(models.RuleFieldGroup, "muse"), contains(models.RuleFieldSong, "pure")}}},
+			}}, []string{"Starlight", "Under Pressure"}},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				require.NoError(t, tc.rule.Validate())
+				songs, total, err := repo.Search(ctx, tc.rule, 1, 10)
+				require.NoError(t, err)
+				assert.Equal(t, int64(len(tc.want)), total)
+				assert.Equal(t, tc.want, names(songs))
+			})
+		}
+	})
+
+	t.Run("List paginates in insertion order", func(t *testing.T) {
+		repo := newRepo(t)
+		seed(t, repo)
+
+		songs, total, err := repo.List(ctx, 2, 3, map[string]string{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), total)
+		assert.Equal(t, []string{"Under Pressure"}, names(songs))
+
+		songs, total, err = repo.List(ctx, 3, 3, map[string]string{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), total)
+		assert.Empty(t, songs)
+	})
+
+	t.Run("List pagination edge cases", func(t *testing.T) {
+		repo := newRepo(t)
+		seed(t, repo)
+
+		for _, tc := range []struct {
+			name        string
+			page, limit int
+			want        []string
+		}{
+			{"first page", 1, 2, []string{"Uprising", "Starlight"}},
+			{"exact last page", 2, 2, []string{"100% Pure", "Under Pressure"}},
+			{"single item pages", 3, 1, []string{"100% Pure"}},
+			{"limit larger than total", 1, 100, []string{"Uprising", "Starlight", "100% Pure", "Under Pressure"}},
+			{"far past the end", 50, 10, []string{}},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				songs, total, err := repo.List(ctx, tc.page, tc.limit, map[string]string{})
+				require.NoError(t, err)
+				assert.Equal(t, int64(4), total, "total ignores pagination")
+				assert.Equal(t, tc.want, names(songs))
+			})
+		}
+
+		songs, total, err := repo.List(ctx, 2, 1, map[string]string{FilterGroup: "muse"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total, "total counts filtered rows")
+		assert.Equal(t, []string{"Starlight"}, names(songs))
+	})
+
+	t.Run("reserved and quoted values", func(t *testing.T) {
+		repo := newRepo(t)
+
+		// "group" is a reserved word; quotes in values must stay data.
+		song := models.Song{Group: `Guns N' "Roses"`, Name: `Sweet Child O' Mine`}
+		require.NoError(t, repo.Create(ctx, &song))
+
+		got, err := repo.GetByID(ctx, fmt.Sprint(song.ID))
+		require.NoError(t, err)
+		assert.Equal(t, song.Group, got.Group)
+
+		songs, _, err := repo.List(ctx, 1, 10, map[string]string{FilterGroup: `n' "ro`, FilterSong: "o' mine"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Sweet Child O' Mine"}, names(songs))
+
+		songs, _, err = repo.List(ctx, 1, 10, map[string]string{FilterGroup: `' OR '1'='1`})
+		require.NoError(t, err)
+		assert.Empty(t, songs)
+
+		got.Group = `The "Updated" Group`
+		require.NoError(t, repo.Update(ctx, got))
+		got, err = repo.GetByID(ctx, fmt.Sprint(song.ID))
+		require.NoError(t, err)
+		assert.Equal(t, `The "Updated" Group`, got.Group)
+	})
+
+	t.Run("case is ignored beyond ASCII", func(t *testing.T) {
+		repo := newRepo(t)
+		for _, song := range []models.Song{{Group: "Кино", Name: "Группа крови"}, {Group: "Sigur Rós", Name: "Hoppípolla"}} {
+			require.NoError(t, repo.Create(ctx, &song))
+		}
+
+		songs, _, err := repo.List(ctx, 1, 10, map[string]string{FilterGroup: "кино"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Группа крови"}, names(songs))
+
+		songs, _, err = repo.List(ctx, 1, 10, map[string]string{FilterSong: "ГРУППА"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Группа крови"}, names(songs))
+
+		songs, _, err = repo.Search(ctx, models.Rule{Field: models.RuleFieldGroup, Op: models.RuleOpContains, Value: "RÓS"}, 1, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Hoppípolla"}, names(songs))
+	})
+
+	t.Run("concurrent writes", func(t *testing.T) {
+		repo := newRepo(t)
+
+		const writers = 8
+		const perWriter = 10
+
+		var wg sync.WaitGroup
+		errs := make(chan error, writers*perWriter)
+		ids := make(chan uint, writers*perWriter)
+		for w := 0; w < writers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for i := 0; i < perWriter; i++ {
+					song := models.Song{Group: fmt.Sprintf("Writer %d", w), Name: fmt.Sprintf("Song %d", i)}
+					if err := repo.Create(ctx, &song); err != nil {
+						errs <- err
+						continue
+					}
+					ids <- song.ID
+				}
+			}(w)
+		}
+		wg.Wait()
+		close(errs)
+		close(ids)
+
+		for err := range errs {
+			assert.NoError(t, err)
+		}
+		seen := make(map[uint]bool)
+		for id := range ids {
+			assert.False(t, seen[id], "duplicate id %d", id)
+			seen[id] = true
+		}
+
+		_, total, err := repo.List(ctx, 1, 1, map[string]string{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(writers*perWriter), total)
+
+		// Concurrent updates to one row must each succeed and leave one
+		// writer's complete version behind.
+		var target models.Song
+		for id := range seen {
+			target.ID = id
+			break
+		}
+		for w := 0; w < writers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				song := models.Song{ID: target.ID, Group: fmt.Sprintf("Group %d", w), Name: fmt.Sprintf("Name %d", w)}
+				assert.NoError(t, repo.Update(ctx, &song))
+			}(w)
+		}
+		wg.Wait()
+
+		got, err := repo.GetByID(ctx, fmt.Sprint(target.ID))
+		require.NoError(t, err)
+		assert.Equal(t, strings.TrimPrefix(got.Group, "Group "), strings.TrimPrefix(got.Name, "Name "))
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo := newRepo(t)
+		songs := seed(t, repo)
+
+		updated := songs[1]
+		updated.Text = "Far away"
+		require.NoError(t, repo.Update(ctx, &updated))
+
+		got, err := repo.GetByID(ctx, fmt.Sprint(updated.ID))
+		require.NoError(t, err)
+		assert.Equal(t, "Far away", got.Text)
+
+		missing := models.Song{ID: 999999, Group: "Nobody", Name: "Nothing"}
+		assert.ErrorIs(t, repo.Update(ctx, &missing), gorm.ErrRecordNotFound)
+	})
+
+	t.Run("links", func(t *testing.T) {
+		repo := newRepo(t)
+		youTube := models.SongLink{URL: "https://youtu.be/dQw4w9WgXcQ", Provider: models.ProviderYouTube, ProviderID: "dQw4w9WgXcQ"}
+		spotify := models.SongLink{URL: "https://open.spotify.com/track/4uLU6hMCjMI75M1A2tKUQC", Provider: models.ProviderSpotify, ProviderID: "track:4uLU6hMCjMI75M1A2tKUQC"}
+
+		song := models.Song{Group: "Muse", Name: "Uprising", Links: []models.SongLink{{URL: youTube.URL}, {URL: spotify.URL}, {URL: youTube.URL}}}
+		require.NoError(t, repo.Create(ctx, &song))
+		got, err := repo.GetByID(ctx, fmt.Sprint(song.ID))
+		require.NoError(t, err)
+		assert.Equal(t, youTube.URL, got.Link, "the first link becomes the primary link")
+		assert.Equal(t, []models.SongLink{youTube, spotify}, got.Links)
+
+		got.Link = spotify.URL
+		require.NoError(t, repo.Update(ctx, got))
+		got, err = repo.GetByID(ctx, fmt.Sprint(song.ID))
+		require.NoError(t, err)
+		assert.Equal(t, []models.SongLink{spotify, youTube}, got.Links)
+
+		for provider, want := range map[string]int64{models.ProviderSpotify: 1, models.ProviderSoundCloud: 0} {
+			_, total, err := repo.List(ctx, 1, 10, map[string]string{FilterProvider: provider})
+			require.NoError(t, err)
+			assert.Equal(t, want, total, provider)
+		}
+		_, total, err := repo.Search(ctx, models.Rule{Field: models.RuleFieldProvider, Op: models.RuleOpIn, Values: []string{models.ProviderBandcamp, models.ProviderYouTube}}, 1, 10)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+
+		got.Link, got.Links = "", nil
+		require.NoError(t, repo.Update(ctx, got))
+		got, err = repo.GetByID(ctx, fmt.Sprint(song.ID))
+		require.NoError(t, err)
+		assert.Empty(t, got.Link)
+		assert.Empty(t, got.Links)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repo := newRepo(t)
+		songs := seed(t, repo)
+
+		id := fmt.Sprint(songs[0].ID)
+		require.NoError(t, repo.Delete(ctx, id))
+		_, err := repo.GetByID(ctx, id)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		assert.NoError(t, repo.Delete(ctx, id), "deleting a missing song is not an error")
+	})
+
+	t.Run("tenants are isolated", func(t *testing.T) {
+		repo := newRepo(t)
+		songs := seed(t, repo)
+		other := tenant.NewContext(context.Background(), "other")
+
+		_, total, err := repo.List(other, 1, 10, map[string]string{})
+		require.NoError(t, err)
+		assert.Zero(t, total)
+
+		_, err = repo.GetByID(other, fmt.Sprint(songs[0].ID))
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+		hijack := songs[0]
+		assert.ErrorIs(t, repo.Update(other, &hijack), gorm.ErrRecordNotFound)
+
+		hijack = models.Song{ID: songs[0].ID, Group: "Other", Name: "Hijack"}
+		assert.Error(t, repo.Create(other, &hijack), "an existing ID is not overwritten")
+		got, err := repo.GetByID(ctx, fmt.Sprint(songs[0].ID))
+		require.NoError(t, err)
+		assert.Equal(t, songs[0].Name, got.Name)
+	})
+
+	t.Run("requires a tenant", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, _, err := repo.List(context.Background(), 1, 10, map[string]string{})
+		assert.ErrorIs(t, err, ErrNoTenant)
+		assert.ErrorIs(t, repo.Create(context.Background(), &models.Song{Group: "a", Name: "b"}), ErrNoTenant)
+	})
+}
+
+func TestSQLSongRepository_SQLite(t *testing.T) {
+	testSongRepository(t, func(t *testing.T) SongRepository {
+		return NewSQLSongRepository(setupSQLiteDB(t))
+	})
+}
+
+// TestSQLRepositories_Postgres runs the suite against a real Postgres when
+// TEST_DATABASE_URL is set. The tables in that database are emptied.
+func TestSQLRepositories_Postgres(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, Migrate(db))
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	truncate := func(t *testing.T) {
+		require.NoError(t, db.Exec("TRUNCATE TABLE songs, playlists, playlist_items, tags, song_tags, genres, song_genres, people, credits, favorites, ratings, plays, quality_reviews, song_links, attachments").Error)
+	}
+	t.Run("songs", func(t *testing.T) {
+		testSongRepository(t, func(t *testing.T) SongRepository {
+			truncate(t)
+			return NewSQLSongRepository(db)
+		})
+	})
+	t.Run("playlists", func(t *testing.T) {
+		testPlaylistRepository(t, func(t *testing.T) (SongRepository, PlaylistRepository) {
+			truncate(t)
+			return NewSQLSongRepository(db), NewSQLPlaylistRepository(db)
+		})
+	})
+	t.Run("taxonomy", func(t *testing.T) {
+		testTaxonomyRepository(t, func(t *testing.T) (SongRepository, TaxonomyRepository) {
+			truncate(t)
+			return NewSQLSongRepository(db), NewSQLTaxonomyRepository(db)
+		})
+	})
+	t.Run("credits", func(t *testing.T) {
+		testCreditRepository(t, func(t *testing.T) (SongRepository, CreditRepository) {
+			truncate(t)
+			return NewSQLSongRepository(db), NewSQLCreditRepository(db)
+		})
+	})
+	t.Run("engagement", func(t *testing.T) {
+		testEngagementRepository(t, func(t *testing.T) (SongRepository, EngagementRepository) {
+			truncate(t)
+			return NewSQLSongRepository(db), NewSQLEngagementRepository(db)
+		})
+	})
+	t.Run("stats", func(t *testing.T) {
+		testStatsRepository(t, func(t *testing.T) (SongRepository, StatsRepository) {
+			truncate(t)
+			return NewSQLSongRepository(db), NewSQLStatsRepository(db)
+		})
+	})
+	t.Run("reviews", func(t *testing.T) {
+		testReviewRepository(t, func(t *testing.T) (SongRepository, ReviewRepository) {
+			truncate(t)
+			return NewSQLSongRepository(db), NewSQLReviewRepository(db)
+		})
+	})
+	t.Run("attachments", func(t *testing.T) {
+		testAttachmentRepository(t, func(t *testing.T) (SongRepository, AttachmentRepository) {
+			truncate(t)
+			return NewSQLSongRepository(db), NewSQLAttachmentRepository(db)
+		})
+	})
+	t.Run("link checks", func(t *testing.T) {
+		testLinkCheckRepository(t, func(t *testing.T) (SongRepository, LinkCheckRepository) {
+			truncate(t)
+			return NewSQLSongRepository(db), NewSQLLinkCheckRepository(db)
+		})
+	})
+}
+
+func TestMemorySongRepository(t *testing.T) {
+	testSongRepository(t, func(t *testing.T) SongRepository {
+		return NewMemorySongRepository()
+	})
+}
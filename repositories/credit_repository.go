@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/tenant"
+	"awesomeProject/tracing"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+)
+
+// CreditRepository manages who is credited on songs and in which roles.
+type CreditRepository interface {
+	// SetCredits replaces a song's credits, creating people as needed.
+	SetCredits(ctx context.Context, songID uint, credits []models.SongCredit) error
+	// People lists credited people with their number of songs, optionally
+	// counting only credits in role.
+	People(ctx context.Context, role string) ([]models.PersonCredits, error)
+}
+
+// uniqueCredits normalises names and drops blanks and repeated
+// person/role pairs, keeping the first occurrence.
+func uniqueCredits(credits []models.SongCredit) []models.SongCredit {
+	var out []models.SongCredit
+	seen := make(map[models.SongCredit]bool)
+	for _, credit := range credits {
+		credit.Person = models.NormalizePersonName(credit.Person)
+		key := models.SongCredit{Person: strings.ToLower(credit.Person), Role: credit.Role}
+		if credit.Person == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, credit)
+	}
+	return out
+}
+
+type SQLCreditRepository struct {
+	db *gorm.DB
+}
+
+func NewSQLCreditRepository(db *gorm.DB) *SQLCreditRepository {
+	return &SQLCreditRepository{db: db}
+}
+
+func (r *SQLCreditRepository) SetCredits(ctx context.Context, songID uint, credits []models.SongCredit) (err error) {
+	ctx, span := tracer.Start(ctx, "CreditRepository.SetCredits")
+	defer func() { tracing.End(span, err) }()
+
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+	credits = uniqueCredits(credits)
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := requireSongs(tx, tenantID, []uint{songID}); err != nil {
+			return err
+		}
+
+		people := make(map[string]uint)
+		rows := make([]models.Credit, 0, len(credits))
+		for i, credit := range credits {
+			key := strings.ToLower(credit.Person)
+			if _, ok := people[key]; !ok {
+				id, err := findOrCreatePerson(tx, tenantID, credit.Person)
+				if err != nil {
+					return err
+				}
+				people[key] = id
+			}
+			rows = append(rows, models.Credit{SongID: songID, PersonID: people[key], Role: credit.Role, Position: i + 1})
+		}
+
+		if err := tx.Where("song_id = ?", songID).Delete(&models.Credit{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.Create(&rows).Error
+	})
+}
+
+func findOrCreatePerson(tx *gorm.DB, tenantID, name string) (uint, error) {
+	var person models.Person
+	err := tx.Where("tenant_id = ? AND LOWER(name) = LOWER(?)", tenantID, name).Order("id").Take(&person).Error
+	if err == nil {
+		return person.ID, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, err
+	}
+
+	person = models.Person{Name: name, TenantID: tenantID}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&person).Error; err != nil {
+		return 0, err
+	}
+	if person.ID != 0 {
+		return person.ID, nil
+	}
+	// A concurrent request created the same person first.
+	err = tx.Where("tenant_id = ? AND name = ?", tenantID, name).Take(&person).Error
+	return person.ID, err
+}
+
+func (r *SQLCreditRepository) People(ctx context.Context, role string) (people []models.PersonCredits, err error) {
+	ctx, span := tracer.Start(ctx, "CreditRepository.People")
+	defer func() { tracing.End(span, err) }()
+
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return nil, ErrNoTenant
+	}
+
+	query := conn(ctx, r.db).Table("people").
+		Select("people.name, COUNT(DISTINCT credits.song_id) AS songs").
+		Joins("JOIN credits ON credits.person_id = people.id").
+		Where("people.tenant_id = ?", tenantID)
+	if role != "" {
+		query = query.Where("credits.role = ?", role)
+	}
+	err = query.Group("people.id, people.name").Order("LOWER(people.name), people.name").Scan(&people).Error
+	return people, err
+}
@@ -0,0 +1,165 @@
+package repositories
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/tenant"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func testCreditRepository(t *testing.T, newRepos func(t *testing.T) (SongRepository, CreditRepository)) {
+	ctx := tenant.NewContext(context.Background(), tenant.Default)
+
+	setup := func(t *testing.T) (SongRepository, CreditRepository, []uint) {
+		songs, credits := newRepos(t)
+		var ids []uint
+		for _, name := range []string{"Bohemian Rhapsody", "Under Pressure", "Heroes"} {
+			song := models.Song{Group: "Queen", Name: name}
+			require.NoError(t, songs.Create(ctx, &song))
+			ids = append(ids, song.ID)
+		}
+		return songs, credits, ids
+	}
+
+	names := func(songs []models.Song) []string {
+		var out []string
+		for _, song := range songs {
+			out = append(out, song.Name)
+		}
+		return out
+	}
+
+	t.Run("SetCredits replaces credits in order", func(t *testing.T) {
+		songs, credits, ids := setup(t)
+		require.NoError(t, credits.SetCredits(ctx, ids[0], []models.SongCredit{
+			{Person: "Queen", Role: models.CreditRolePerformer},
+			{Person: "Brian May", Role: models.CreditRoleProducer},
+		}))
+		require.NoError(t, credits.SetCredits(ctx, ids[0], []models.SongCredit{
+			{Person: " Freddie  Mercury ", Role: models.CreditRoleComposer},
+			{Person: "Freddie Mercury", Role: models.CreditRoleLyricist},
+			{Person: "freddie mercury", Role: models.CreditRoleComposer},
+			{Person: "Queen", Role: models.CreditRolePerformer},
+		}))
+
+		song, err := songs.GetByID(ctx, "1")
+		require.NoError(t, err)
+		assert.Equal(t, []models.SongCredit{
+			{Person: "Freddie Mercury", Role: models.CreditRoleComposer},
+			{Person: "Freddie Mercury", Role: models.CreditRoleLyricist},
+			{Person: "Queen", Role: models.CreditRolePerformer},
+		}, song.Credits)
+
+		require.NoError(t, credits.SetCredits(ctx, ids[0], nil))
+		song, err = songs.GetByID(ctx, "1")
+		require.NoError(t, err)
+		assert.Empty(t, song.Credits)
+
+		assert.ErrorIs(t, credits.SetCredits(ctx, 999, nil), ErrSongNotFound)
+	})
+
+	t.Run("people and filters", func(t *testing.T) {
+		songs, credits, ids := setup(t)
+		require.NoError(t, credits.SetCredits(ctx, ids[0], []models.SongCredit{
+			{Person: "Queen", Role: models.CreditRolePerformer},
+			{Person: "Freddie Mercury", Role: models.CreditRoleComposer},
+		}))
+		require.NoError(t, credits.SetCredits(ctx, ids[1], []models.SongCredit{
+			{Person: "Queen", Role: models.CreditRolePerformer},
+			{Person: "david bowie", Role: models.CreditRoleFeatured},
+			{Person: "Freddie Mercury", Role: models.CreditRoleLyricist},
+		}))
+		require.NoError(t, credits.SetCredits(ctx, ids[2], []models.SongCredit{
+			{Person: "David Bowie", Role: models.CreditRolePerformer},
+		}))
+
+		people, err := credits.People(ctx, "")
+		require.NoError(t, err)
+		assert.Equal(t, []models.PersonCredits{{Name: "david bowie", Songs: 2}, {Name: "Freddie Mercury", Songs: 2}, {Name: "Queen", Songs: 2}}, people,
+			"a person keeps the spelling they were first credited with")
+
+		people, err = credits.People(ctx, models.CreditRoleComposer)
+		require.NoError(t, err)
+		assert.Equal(t, []models.PersonCredits{{Name: "Freddie Mercury", Songs: 1}}, people)
+
+		for _, tc := range []struct {
+			name    string
+			filters map[string]string
+			want    []string
+		}{
+			{"any role", map[string]string{FilterPerson: "DAVID BOWIE"}, []string{"Under Pressure", "Heroes"}},
+			{"role", map[string]string{FilterPerson: "David Bowie", FilterRole: models.CreditRoleFeatured}, []string{"Under Pressure"}},
+			{"role without match", map[string]string{FilterPerson: "Queen", FilterRole: models.CreditRoleComposer}, nil},
+			{"unknown person", map[string]string{FilterPerson: "Nobody"}, nil},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				got, total, err := songs.List(ctx, 1, 10, tc.filters)
+				require.NoError(t, err)
+				assert.Equal(t, tc.want, names(got))
+				assert.Equal(t, int64(len(tc.want)), total)
+			})
+		}
+
+		got, _, err := songs.Search(ctx, models.Rule{Field: models.RuleFieldPerson, Op: models.RuleOpIn, Values: []string{"freddie mercury"}, Role: models.CreditRoleLyricist}, 1, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Under Pressure"}, names(got))
+	})
+
+	t.Run("names ignore case beyond ASCII", func(t *testing.T) {
+		songs, credits, ids := setup(t)
+		require.NoError(t, credits.SetCredits(ctx, ids[0], []models.SongCredit{{Person: "Жанна Агузарова", Role: models.CreditRolePerformer}}))
+		require.NoError(t, credits.SetCredits(ctx, ids[1], []models.SongCredit{{Person: "ЖАННА АГУЗАРОВА", Role: models.CreditRolePerformer}}))
+
+		people, err := credits.People(ctx, "")
+		require.NoError(t, err)
+		assert.Equal(t, []models.PersonCredits{{Name: "Жанна Агузарова", Songs: 2}}, people)
+
+		got, _, err := songs.List(ctx, 1, 10, map[string]string{FilterPerson: "жанна агузарова"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Bohemian Rhapsody", "Under Pressure"}, names(got))
+	})
+
+	t.Run("deleting a song drops its credits", func(t *testing.T) {
+		songs, credits, ids := setup(t)
+		require.NoError(t, credits.SetCredits(ctx, ids[0], []models.SongCredit{{Person: "Queen", Role: models.CreditRolePerformer}}))
+		require.NoError(t, songs.Delete(ctx, "1"))
+
+		people, err := credits.People(ctx, "")
+		require.NoError(t, err)
+		assert.Empty(t, people)
+	})
+
+	t.Run("tenants are isolated", func(t *testing.T) {
+		_, credits, ids := setup(t)
+		require.NoError(t, credits.SetCredits(ctx, ids[0], []models.SongCredit{{Person: "Queen", Role: models.CreditRolePerformer}}))
+
+		other := tenant.NewContext(context.Background(), "other")
+		assert.ErrorIs(t, credits.SetCredits(other, ids[0], nil), ErrSongNotFound)
+		people, err := credits.People(other, "")
+		require.NoError(t, err)
+		assert.Empty(t, people)
+	})
+
+	t.Run("requires a tenant", func(t *testing.T) {
+		_, credits := newRepos(t)
+		_, err := credits.People(context.Background(), "")
+		assert.ErrorIs(t, err, ErrNoTenant)
+		assert.ErrorIs(t, credits.SetCredits(context.Background(), 1, nil), ErrNoTenant)
+	})
+}
+
+func TestSQLCreditRepository_SQLite(t *testing.T) {
+	testCreditRepository(t, func(t *testing.T) (SongRepository, CreditRepository) {
+		db := setupSQLiteDB(t)
+		return NewSQLSongRepository(db), NewSQLCreditRepository(db)
+	})
+}
+
+func TestMemoryCreditRepository(t *testing.T) {
+	testCreditRepository(t, func(t *testing.T) (SongRepository, CreditRepository) {
+		songs := NewMemorySongRepository()
+		return songs, NewMemoryCreditRepository(songs)
+	})
+}
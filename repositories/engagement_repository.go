@@ -0,0 +1,194 @@
+package repositories
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/tenant"
+	"awesomeProject/tracing"
+	"context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// EngagementRepository records favorites, ratings and plays per user. A
+// user is identified by the principal's Subject; songs remain tenant-scoped.
+type EngagementRepository interface {
+	Favorite(ctx context.Context, userID string, songID uint) error
+	Unfavorite(ctx context.Context, userID string, songID uint) error
+	// Favorites lists the user's favorite songs, most recently added first.
+	Favorites(ctx context.Context, userID string, page, limit int) ([]models.Song, int64, error)
+	Rate(ctx context.Context, userID string, songID uint, stars int) error
+	Unrate(ctx context.Context, userID string, songID uint) error
+	RecordPlay(ctx context.Context, userID string, songID uint) error
+	// TopPlayed lists the songs the user played most, most played first.
+	TopPlayed(ctx context.Context, userID string, limit int) ([]models.PlayedSong, error)
+}
+
+type SQLEngagementRepository struct {
+	db *gorm.DB
+}
+
+func NewSQLEngagementRepository(db *gorm.DB) *SQLEngagementRepository {
+	return &SQLEngagementRepository{db: db}
+}
+
+func (r *SQLEngagementRepository) Favorite(ctx context.Context, userID string, songID uint) (err error) {
+	ctx, span := tracer.Start(ctx, "EngagementRepository.Favorite")
+	defer func() { tracing.End(span, err) }()
+
+	return r.withSong(ctx, songID, func(tx *gorm.DB) error {
+		favorite := models.Favorite{UserID: userID, SongID: songID}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&favorite).Error
+	})
+}
+
+func (r *SQLEngagementRepository) Unfavorite(ctx context.Context, userID string, songID uint) (err error) {
+	ctx, span := tracer.Start(ctx, "EngagementRepository.Unfavorite")
+	defer func() { tracing.End(span, err) }()
+
+	return r.withSong(ctx, songID, func(tx *gorm.DB) error {
+		return tx.Where("user_id = ? AND song_id = ?", userID, songID).Delete(&models.Favorite{}).Error
+	})
+}
+
+func (r *SQLEngagementRepository) Favorites(ctx context.Context, userID string, page, limit int) (songs []models.Song, total int64, err error) {
+	ctx, span := tracer.Start(ctx, "EngagementRepository.Favorites")
+	defer func() { tracing.End(span, err) }()
+
+	db, tenantID, err := scoped(ctx, r.db)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := conn(ctx, r.db).Model(&models.Song{}).
+		Joins("JOIN favorites ON favorites.song_id = songs.id").
+		Where("favorites.user_id = ? AND songs.tenant_id = ?", userID, tenantID).
+		Session(&gorm.Session{})
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var ids []uint
+	err = query.Order("favorites.created_at DESC, songs.id DESC").
+		Offset((page-1)*limit).Limit(limit).
+		Pluck("songs.id", &ids).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	songs, err = songsInOrder(ctx, db, r.db, ids)
+	return songs, total, err
+}
+
+func (r *SQLEngagementRepository) Rate(ctx context.Context, userID string, songID uint, stars int) (err error) {
+	ctx, span := tracer.Start(ctx, "EngagementRepository.Rate")
+	defer func() { tracing.End(span, err) }()
+
+	return r.withSong(ctx, songID, func(tx *gorm.DB) error {
+		rating := models.Rating{UserID: userID, SongID: songID, Stars: stars}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "song_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"stars", "updated_at"}),
+		}).Create(&rating).Error
+	})
+}
+
+func (r *SQLEngagementRepository) Unrate(ctx context.Context, userID string, songID uint) (err error) {
+	ctx, span := tracer.Start(ctx, "EngagementRepository.Unrate")
+	defer func() { tracing.End(span, err) }()
+
+	return r.withSong(ctx, songID, func(tx *gorm.DB) error {
+		return tx.Where("user_id = ? AND song_id = ?", userID, songID).Delete(&models.Rating{}).Error
+	})
+}
+
+func (r *SQLEngagementRepository) RecordPlay(ctx context.Context, userID string, songID uint) (err error) {
+	ctx, span := tracer.Start(ctx, "EngagementRepository.RecordPlay")
+	defer func() { tracing.End(span, err) }()
+
+	return r.withSong(ctx, songID, func(tx *gorm.DB) error {
+		return tx.Create(&models.Play{UserID: userID, SongID: songID, PlayedAt: time.Now()}).Error
+	})
+}
+
+func (r *SQLEngagementRepository) TopPlayed(ctx context.Context, userID string, limit int) (played []models.PlayedSong, err error) {
+	ctx, span := tracer.Start(ctx, "EngagementRepository.TopPlayed")
+	defer func() { tracing.End(span, err) }()
+
+	db, tenantID, err := scoped(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+
+	var counts []struct {
+		SongID uint
+		Plays  int64
+	}
+	err = conn(ctx, r.db).Table("plays").
+		Select("plays.song_id, COUNT(*) AS plays").
+		Joins("JOIN songs ON songs.id = plays.song_id").
+		Where("plays.user_id = ? AND songs.tenant_id = ?", userID, tenantID).
+		Group("plays.song_id").
+		Order("plays DESC, plays.song_id").
+		Limit(limit).
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, len(counts))
+	plays := make(map[uint]int64, len(counts))
+	for i, count := range counts {
+		ids[i] = count.SongID
+		plays[count.SongID] = count.Plays
+	}
+	songs, err := songsInOrder(ctx, db, r.db, ids)
+	if err != nil {
+		return nil, err
+	}
+	played = make([]models.PlayedSong, len(songs))
+	for i, song := range songs {
+		played[i] = models.PlayedSong{Song: song, Plays: plays[song.ID]}
+	}
+	return played, nil
+}
+
+// withSong runs fn in a transaction once the song is known to belong to the
+// tenant in ctx.
+func (r *SQLEngagementRepository) withSong(ctx context.Context, songID uint, fn func(tx *gorm.DB) error) error {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := requireSongs(tx, tenantID, []uint{songID}); err != nil {
+			return err
+		}
+		return fn(tx)
+	})
+}
+
+// songsInOrder loads songs through the tenant-scoped session db, returning
+// them in the order of ids.
+func songsInOrder(ctx context.Context, db, root *gorm.DB, ids []uint) ([]models.Song, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var songs []models.Song
+	if err := db.Where("id IN ?", ids).Find(&songs).Error; err != nil {
+		return nil, err
+	}
+	if err := attachDetails(ctx, root, songs); err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.Song, len(songs))
+	for _, song := range songs {
+		byID[song.ID] = song
+	}
+	ordered := make([]models.Song, 0, len(ids))
+	for _, id := range ids {
+		if song, ok := byID[id]; ok {
+			ordered = append(ordered, song)
+		}
+	}
+	return ordered, nil
+}
@@ -0,0 +1,148 @@
+package repositories
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/tenant"
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func testEngagementRepository(t *testing.T, newRepos func(t *testing.T) (SongRepository, EngagementRepository)) {
+	ctx := tenant.NewContext(context.Background(), tenant.Default)
+
+	setup := func(t *testing.T) (SongRepository, EngagementRepository, []uint) {
+		songs, engagement := newRepos(t)
+		var ids []uint
+		for _, name := range []string{"One", "Two", "Three"} {
+			song := models.Song{Group: "Band", Name: name}
+			require.NoError(t, songs.Create(ctx, &song))
+			ids = append(ids, song.ID)
+		}
+		return songs, engagement, ids
+	}
+
+	stats := func(t *testing.T, songs SongRepository, id uint) models.SongStats {
+		song, err := songs.GetByID(ctx, fmt.Sprint(id))
+		require.NoError(t, err)
+		require.NotNil(t, song.Stats)
+		return *song.Stats
+	}
+
+	t.Run("favorites", func(t *testing.T) {
+		songs, engagement, ids := setup(t)
+		for _, id := range []uint{ids[0], ids[2], ids[1]} {
+			require.NoError(t, engagement.Favorite(ctx, "alice", id))
+		}
+		require.NoError(t, engagement.Favorite(ctx, "alice", ids[0]), "favoriting twice is a no-op")
+		require.NoError(t, engagement.Favorite(ctx, "bob", ids[0]))
+		require.NoError(t, engagement.Unfavorite(ctx, "alice", ids[1]))
+
+		got, total, err := engagement.Favorites(ctx, "alice", 1, 10)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		require.Len(t, got, 2)
+		assert.ElementsMatch(t, []uint{ids[0], ids[2]}, []uint{got[0].ID, got[1].ID})
+
+		got, total, err = engagement.Favorites(ctx, "alice", 2, 1)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		assert.Len(t, got, 1)
+
+		assert.Equal(t, int64(2), stats(t, songs, ids[0]).Favorites)
+		assert.ErrorIs(t, engagement.Favorite(ctx, "alice", 999), ErrSongNotFound)
+	})
+
+	t.Run("ratings", func(t *testing.T) {
+		songs, engagement, ids := setup(t)
+		require.NoError(t, engagement.Rate(ctx, "alice", ids[0], 2))
+		require.NoError(t, engagement.Rate(ctx, "alice", ids[0], 5), "rating again replaces the rating")
+		require.NoError(t, engagement.Rate(ctx, "bob", ids[0], 4))
+		require.NoError(t, engagement.Rate(ctx, "carol", ids[0], 1))
+		require.NoError(t, engagement.Unrate(ctx, "carol", ids[0]))
+
+		got := stats(t, songs, ids[0])
+		assert.Equal(t, int64(2), got.Ratings)
+		assert.InDelta(t, 4.5, got.AverageRating, 0.001)
+		assert.Equal(t, models.SongStats{}, stats(t, songs, ids[1]))
+	})
+
+	t.Run("plays", func(t *testing.T) {
+		songs, engagement, ids := setup(t)
+		for _, id := range []uint{ids[1], ids[2], ids[1], ids[0], ids[1], ids[2]} {
+			require.NoError(t, engagement.RecordPlay(ctx, "alice", id))
+		}
+		require.NoError(t, engagement.RecordPlay(ctx, "bob", ids[0]))
+
+		top, err := engagement.TopPlayed(ctx, "alice", 2)
+		require.NoError(t, err)
+		require.Len(t, top, 2)
+		assert.Equal(t, "Two", top[0].Song.Name)
+		assert.Equal(t, int64(3), top[0].Plays)
+		assert.Equal(t, "Three", top[1].Song.Name)
+		assert.Equal(t, int64(2), top[1].Plays)
+		require.NotNil(t, top[0].Song.Stats)
+		assert.Equal(t, int64(3), top[0].Song.Stats.Plays)
+
+		assert.Equal(t, int64(2), stats(t, songs, ids[0]).Plays)
+
+		list, _, err := songs.List(ctx, 1, 10, map[string]string{})
+		require.NoError(t, err)
+		require.Len(t, list, 3)
+		assert.Equal(t, int64(3), list[1].Stats.Plays, "List carries stats too")
+	})
+
+	t.Run("deleting a song drops its engagement", func(t *testing.T) {
+		songs, engagement, ids := setup(t)
+		require.NoError(t, engagement.Favorite(ctx, "alice", ids[0]))
+		require.NoError(t, engagement.RecordPlay(ctx, "alice", ids[0]))
+		require.NoError(t, songs.Delete(ctx, fmt.Sprint(ids[0])))
+
+		favorites, total, err := engagement.Favorites(ctx, "alice", 1, 10)
+		require.NoError(t, err)
+		assert.Empty(t, favorites)
+		assert.Zero(t, total)
+		top, err := engagement.TopPlayed(ctx, "alice", 10)
+		require.NoError(t, err)
+		assert.Empty(t, top)
+	})
+
+	t.Run("tenants are isolated", func(t *testing.T) {
+		_, engagement, ids := setup(t)
+		require.NoError(t, engagement.Favorite(ctx, "alice", ids[0]))
+		require.NoError(t, engagement.RecordPlay(ctx, "alice", ids[0]))
+
+		other := tenant.NewContext(context.Background(), "other")
+		assert.ErrorIs(t, engagement.Rate(other, "alice", ids[0], 5), ErrSongNotFound)
+		favorites, total, err := engagement.Favorites(other, "alice", 1, 10)
+		require.NoError(t, err)
+		assert.Empty(t, favorites)
+		assert.Zero(t, total)
+		top, err := engagement.TopPlayed(other, "alice", 10)
+		require.NoError(t, err)
+		assert.Empty(t, top)
+	})
+
+	t.Run("requires a tenant", func(t *testing.T) {
+		_, engagement := newRepos(t)
+		assert.ErrorIs(t, engagement.Favorite(context.Background(), "alice", 1), ErrNoTenant)
+		_, _, err := engagement.Favorites(context.Background(), "alice", 1, 10)
+		assert.ErrorIs(t, err, ErrNoTenant)
+	})
+}
+
+func TestSQLEngagementRepository_SQLite(t *testing.T) {
+	testEngagementRepository(t, func(t *testing.T) (SongRepository, EngagementRepository) {
+		db := setupSQLiteDB(t)
+		return NewSQLSongRepository(db), NewSQLEngagementRepository(db)
+	})
+}
+
+func TestMemoryEngagementRepository(t *testing.T) {
+	testEngagementRepository(t, func(t *testing.T) (SongRepository, EngagementRepository) {
+		songs := NewMemorySongRepository()
+		return songs, NewMemoryEngagementRepository(songs)
+	})
+}
@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/tracing"
+	"context"
+	"gorm.io/gorm"
+	"time"
+)
+
+// LinkCheckRepository feeds the background link checker. It works across all
+// tenants, so its callers must never be request handlers.
+type LinkCheckRepository interface {
+	// DueLinks returns up to limit songs whose link was never checked or was
+	// last checked before cutoff, never-checked songs first, then the
+	// longest unchecked.
+	DueLinks(ctx context.Context, cutoff time.Time, limit int) ([]models.LinkCheck, error)
+	// RecordLinkChecks stores check results. A result is dropped if the song
+	// was deleted or its link changed since it was checked.
+	RecordLinkChecks(ctx context.Context, checks []models.LinkCheck) error
+}
+
+type SQLLinkCheckRepository struct {
+	db *gorm.DB
+}
+
+func NewSQLLinkCheckRepository(db *gorm.DB) *SQLLinkCheckRepository {
+	return &SQLLinkCheckRepository{db: db}
+}
+
+func (r *SQLLinkCheckRepository) DueLinks(ctx context.Context, cutoff time.Time, limit int) (checks []models.LinkCheck, err error) {
+	ctx, span := tracer.Start(ctx, "LinkCheckRepository.DueLinks")
+	defer func() { tracing.End(span, err) }()
+
+	var songs []models.Song
+	err = conn(ctx, r.db).Select("id, link, link_status, link_checked_at").
+		Where("link <> '' AND (link_checked_at IS NULL OR link_checked_at < ?)", cutoff).
+		Order("CASE WHEN link_checked_at IS NULL THEN 0 ELSE 1 END, link_checked_at, id").
+		Limit(limit).
+		Find(&songs).Error
+	if err != nil {
+		return nil, err
+	}
+	return dueChecks(songs), nil
+}
+
+func (r *SQLLinkCheckRepository) RecordLinkChecks(ctx context.Context, checks []models.LinkCheck) (err error) {
+	ctx, span := tracer.Start(ctx, "LinkCheckRepository.RecordLinkChecks")
+	defer func() { tracing.End(span, err) }()
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, check := range checks {
+			err := tx.Model(&models.Song{}).
+				Where("id = ? AND link = ?", check.SongID, check.Link).
+				Updates(map[string]any{"link_status": check.Status, "link_checked_at": check.CheckedAt}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func dueChecks(songs []models.Song) []models.LinkCheck {
+	checks := make([]models.LinkCheck, len(songs))
+	for i, song := range songs {
+		checks[i] = models.LinkCheck{SongID: song.ID, Link: song.Link, Status: song.LinkStatus}
+		if song.LinkCheckedAt != nil {
+			checks[i].CheckedAt = *song.LinkCheckedAt
+		}
+	}
+	return checks
+}
@@ -0,0 +1,133 @@
+package repositories
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/tenant"
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func testLinkCheckRepository(t *testing.T, newRepos func(t *testing.T) (SongRepository, LinkCheckRepository)) {
+	ctx := tenant.NewContext(context.Background(), tenant.Default)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	setup := func(t *testing.T) (SongRepository, LinkCheckRepository, []models.Song) {
+		songs, checks := newRepos(t)
+		stored := []models.Song{
+			{Group: "Muse", Name: "Uprising", Link: "https://example.com/uprising"},
+			{Group: "Muse", Name: "Starlight", Link: "https://example.com/starlight"},
+			{Group: "Muse", Name: "Unlinked"},
+		}
+		for i := range stored {
+			require.NoError(t, songs.Create(ctx, &stored[i]))
+		}
+		other := models.Song{Group: "Queen", Name: "Elsewhere", Link: "https://example.com/elsewhere"}
+		require.NoError(t, songs.Create(tenant.NewContext(context.Background(), "other"), &other))
+		return songs, checks, append(stored, other)
+	}
+
+	ids := func(checks []models.LinkCheck) []uint {
+		out := make([]uint, len(checks))
+		for i, check := range checks {
+			out[i] = check.SongID
+		}
+		return out
+	}
+
+	t.Run("DueLinks covers every tenant, oldest first", func(t *testing.T) {
+		_, repo, songs := setup(t)
+
+		due, err := repo.DueLinks(context.Background(), now, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []uint{songs[0].ID, songs[1].ID, songs[3].ID}, ids(due))
+		assert.Equal(t, songs[0].Link, due[0].Link)
+
+		require.NoError(t, repo.RecordLinkChecks(context.Background(), []models.LinkCheck{
+			{SongID: songs[0].ID, Link: songs[0].Link, Status: 200, CheckedAt: now.Add(-time.Hour)},
+			{SongID: songs[1].ID, Link: songs[1].Link, Status: 404, CheckedAt: now.Add(-2 * time.Hour)},
+		}))
+		due, err = repo.DueLinks(context.Background(), now, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []uint{songs[3].ID, songs[1].ID, songs[0].ID}, ids(due))
+		assert.Equal(t, 404, due[1].Status)
+
+		due, err = repo.DueLinks(context.Background(), now.Add(-90*time.Minute), 1)
+		require.NoError(t, err)
+		assert.Equal(t, []uint{songs[3].ID}, ids(due))
+	})
+
+	t.Run("results show on songs and in filters", func(t *testing.T) {
+		songRepo, repo, songs := setup(t)
+		require.NoError(t, repo.RecordLinkChecks(context.Background(), []models.LinkCheck{
+			{SongID: songs[0].ID, Link: songs[0].Link, Status: 200, CheckedAt: now},
+			{SongID: songs[1].ID, Link: songs[1].Link, Status: 0, CheckedAt: now},
+		}))
+
+		got, err := songRepo.GetByID(ctx, fmt.Sprint(songs[1].ID))
+		require.NoError(t, err)
+		require.NotNil(t, got.LinkCheckedAt)
+		assert.WithinDuration(t, now, *got.LinkCheckedAt, time.Second)
+		assert.Equal(t, models.LinkStateBroken, got.LinkState())
+
+		for state, want := range map[string][]uint{
+			models.LinkStateBroken:    {songs[1].ID},
+			models.LinkStateOK:        {songs[0].ID},
+			models.LinkStateUnchecked: nil,
+		} {
+			found, _, err := songRepo.List(ctx, 1, 10, map[string]string{FilterLinkState: state})
+			require.NoError(t, err)
+			var foundIDs []uint
+			for _, song := range found {
+				foundIDs = append(foundIDs, song.ID)
+			}
+			assert.Equal(t, want, foundIDs, state)
+		}
+	})
+
+	t.Run("changing a link resets its check", func(t *testing.T) {
+		songRepo, repo, songs := setup(t)
+		require.NoError(t, repo.RecordLinkChecks(context.Background(), []models.LinkCheck{
+			{SongID: songs[0].ID, Link: songs[0].Link, Status: 404, CheckedAt: now},
+			{SongID: songs[1].ID, Link: songs[1].Link, Status: 404, CheckedAt: now},
+		}))
+
+		song, err := songRepo.GetByID(ctx, fmt.Sprint(songs[0].ID))
+		require.NoError(t, err)
+		song.Text = "Paranoia is in bloom"
+		require.NoError(t, songRepo.Update(ctx, song))
+		song, err = songRepo.GetByID(ctx, fmt.Sprint(songs[0].ID))
+		require.NoError(t, err)
+		assert.Equal(t, 404, song.LinkStatus, "other edits keep the check")
+
+		song.Link = "https://example.com/uprising-live"
+		require.NoError(t, songRepo.Update(ctx, song))
+		song, err = songRepo.GetByID(ctx, fmt.Sprint(songs[0].ID))
+		require.NoError(t, err)
+		assert.Equal(t, models.LinkStateUnchecked, song.LinkState())
+
+		require.NoError(t, repo.RecordLinkChecks(context.Background(), []models.LinkCheck{
+			{SongID: songs[0].ID, Link: songs[0].Link, Status: 500, CheckedAt: now},
+		}))
+		song, err = songRepo.GetByID(ctx, fmt.Sprint(songs[0].ID))
+		require.NoError(t, err)
+		assert.Equal(t, models.LinkStateUnchecked, song.LinkState(), "results for the old link are dropped")
+	})
+}
+
+func TestSQLLinkCheckRepository_SQLite(t *testing.T) {
+	testLinkCheckRepository(t, func(t *testing.T) (SongRepository, LinkCheckRepository) {
+		db := setupSQLiteDB(t)
+		return NewSQLSongRepository(db), NewSQLLinkCheckRepository(db)
+	})
+}
+
+func TestMemoryLinkCheckRepository(t *testing.T) {
+	testLinkCheckRepository(t, func(t *testing.T) (SongRepository, LinkCheckRepository) {
+		songs := NewMemorySongRepository()
+		return songs, NewMemoryLinkCheckRepository(songs)
+	})
+}
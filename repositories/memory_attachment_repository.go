@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/tenant"
+	"context"
+	"slices"
+	"time"
+)
+
+// MemoryAttachmentRepository stores attachments in a MemorySongRepository.
+type MemoryAttachmentRepository struct {
+	songs *MemorySongRepository
+}
+
+func NewMemoryAttachmentRepository(songs *MemorySongRepository) *MemoryAttachmentRepository {
+	return &MemoryAttachmentRepository{songs: songs}
+}
+
+func (r *MemoryAttachmentRepository) List(ctx context.Context, songID uint) ([]models.Attachment, error) {
+	r.songs.mu.RLock()
+	defer r.songs.mu.RUnlock()
+
+	if err := r.requireSong(ctx, songID); err != nil {
+		return nil, err
+	}
+	return append([]models.Attachment{}, r.songs.attachments[songID]...), nil
+}
+
+func (r *MemoryAttachmentRepository) Get(ctx context.Context, songID, id uint) (*models.Attachment, error) {
+	r.songs.mu.RLock()
+	defer r.songs.mu.RUnlock()
+
+	if err := r.requireSong(ctx, songID); err != nil {
+		if err == ErrSongNotFound {
+			return nil, ErrAttachmentNotFound
+		}
+		return nil, err
+	}
+	for _, attachment := range r.songs.attachments[songID] {
+		if attachment.ID == id {
+			return &attachment, nil
+		}
+	}
+	return nil, ErrAttachmentNotFound
+}
+
+func (r *MemoryAttachmentRepository) Create(ctx context.Context, attachment *models.Attachment) error {
+	r.songs.mu.Lock()
+	defer r.songs.mu.Unlock()
+
+	if err := r.requireSong(ctx, attachment.SongID); err != nil {
+		return err
+	}
+	r.songs.nextAttachmentID++
+	attachment.ID = r.songs.nextAttachmentID
+	attachment.CreatedAt = time.Now()
+	r.songs.attachments[attachment.SongID] = append(r.songs.attachments[attachment.SongID], *attachment)
+	return nil
+}
+
+func (r *MemoryAttachmentRepository) Delete(ctx context.Context, songID, id uint) error {
+	r.songs.mu.Lock()
+	defer r.songs.mu.Unlock()
+
+	if err := r.requireSong(ctx, songID); err != nil {
+		return err
+	}
+	attachments := r.songs.attachments[songID]
+	i := slices.IndexFunc(attachments, func(a models.Attachment) bool { return a.ID == id })
+	if i < 0 {
+		return ErrAttachmentNotFound
+	}
+	r.songs.attachments[songID] = slices.Delete(attachments, i, i+1)
+	return nil
+}
+
+// requireSong fails unless songID is a song of the tenant. Callers hold
+// r.songs.mu.
+func (r *MemoryAttachmentRepository) requireSong(ctx context.Context, songID uint) error {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+	if song, ok := r.songs.songs[songID]; !ok || song.TenantID != tenantID {
+		return ErrSongNotFound
+	}
+	return nil
+}
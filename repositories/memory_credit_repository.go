@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/tenant"
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+)
+
+// MemoryCreditRepository manages credits stored in a MemorySongRepository.
+type MemoryCreditRepository struct {
+	songs *MemorySongRepository
+}
+
+func NewMemoryCreditRepository(songs *MemorySongRepository) *MemoryCreditRepository {
+	return &MemoryCreditRepository{songs: songs}
+}
+
+func (r *MemoryCreditRepository) SetCredits(ctx context.Context, songID uint, credits []models.SongCredit) error {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+	credits = uniqueCredits(credits)
+
+	r.songs.mu.Lock()
+	defer r.songs.mu.Unlock()
+
+	if song, ok := r.songs.songs[songID]; !ok || song.TenantID != tenantID {
+		return ErrSongNotFound
+	}
+
+	rows := make([]models.Credit, 0, len(credits))
+	for i, credit := range credits {
+		rows = append(rows, models.Credit{SongID: songID, PersonID: r.person(tenantID, credit.Person), Role: credit.Role, Position: i + 1})
+	}
+	if len(rows) == 0 {
+		delete(r.songs.songCredits, songID)
+		return nil
+	}
+	r.songs.songCredits[songID] = rows
+	return nil
+}
+
+// person finds or creates the tenant's person called name. Callers hold
+// r.songs.mu.
+func (r *MemoryCreditRepository) person(tenantID, name string) uint {
+	for id, person := range r.songs.people {
+		if person.TenantID == tenantID && strings.EqualFold(person.Name, name) {
+			return id
+		}
+	}
+	r.songs.nextPersonID++
+	r.songs.people[r.songs.nextPersonID] = models.Person{ID: r.songs.nextPersonID, Name: name, TenantID: tenantID}
+	return r.songs.nextPersonID
+}
+
+func (r *MemoryCreditRepository) People(ctx context.Context, role string) ([]models.PersonCredits, error) {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return nil, ErrNoTenant
+	}
+
+	r.songs.mu.RLock()
+	songs := make(map[uint]map[uint]bool)
+	for songID, credits := range r.songs.songCredits {
+		for _, credit := range credits {
+			if r.songs.people[credit.PersonID].TenantID != tenantID || (role != "" && credit.Role != role) {
+				continue
+			}
+			if songs[credit.PersonID] == nil {
+				songs[credit.PersonID] = make(map[uint]bool)
+			}
+			songs[credit.PersonID][songID] = true
+		}
+	}
+	out := make([]models.PersonCredits, 0, len(songs))
+	for id, credited := range songs {
+		out = append(out, models.PersonCredits{Name: r.songs.people[id].Name, Songs: int64(len(credited))})
+	}
+	r.songs.mu.RUnlock()
+
+	slices.SortFunc(out, func(a, b models.PersonCredits) int {
+		if c := cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return out, nil
+}
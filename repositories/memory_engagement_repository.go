@@ -0,0 +1,134 @@
+package repositories
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/tenant"
+	"cmp"
+	"context"
+	"slices"
+	"time"
+)
+
+// MemoryEngagementRepository records engagement in a MemorySongRepository.
+type MemoryEngagementRepository struct {
+	songs *MemorySongRepository
+}
+
+func NewMemoryEngagementRepository(songs *MemorySongRepository) *MemoryEngagementRepository {
+	return &MemoryEngagementRepository{songs: songs}
+}
+
+func (r *MemoryEngagementRepository) Favorite(ctx context.Context, userID string, songID uint) error {
+	return r.withSong(ctx, songID, func() {
+		if r.songs.favorites[songID] == nil {
+			r.songs.favorites[songID] = make(map[string]time.Time)
+		}
+		if _, ok := r.songs.favorites[songID][userID]; !ok {
+			r.songs.favorites[songID][userID] = time.Now()
+		}
+	})
+}
+
+func (r *MemoryEngagementRepository) Unfavorite(ctx context.Context, userID string, songID uint) error {
+	return r.withSong(ctx, songID, func() {
+		delete(r.songs.favorites[songID], userID)
+	})
+}
+
+func (r *MemoryEngagementRepository) Favorites(ctx context.Context, userID string, page, limit int) ([]models.Song, int64, error) {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return nil, 0, ErrNoTenant
+	}
+
+	type favorite struct {
+		song  models.Song
+		added time.Time
+	}
+	r.songs.mu.RLock()
+	var favorites []favorite
+	for songID, users := range r.songs.favorites {
+		added, ok := users[userID]
+		if song := r.songs.songs[songID]; ok && song.TenantID == tenantID {
+			favorites = append(favorites, favorite{r.songs.decorate(song), added})
+		}
+	}
+	r.songs.mu.RUnlock()
+
+	slices.SortFunc(favorites, func(a, b favorite) int {
+		if c := b.added.Compare(a.added); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.song.ID, a.song.ID)
+	})
+	var songs []models.Song
+	for _, f := range paginate(favorites, page, limit) {
+		songs = append(songs, f.song)
+	}
+	return songs, int64(len(favorites)), nil
+}
+
+func (r *MemoryEngagementRepository) Rate(ctx context.Context, userID string, songID uint, stars int) error {
+	return r.withSong(ctx, songID, func() {
+		if r.songs.ratings[songID] == nil {
+			r.songs.ratings[songID] = make(map[string]int)
+		}
+		r.songs.ratings[songID][userID] = stars
+	})
+}
+
+func (r *MemoryEngagementRepository) Unrate(ctx context.Context, userID string, songID uint) error {
+	return r.withSong(ctx, songID, func() {
+		delete(r.songs.ratings[songID], userID)
+	})
+}
+
+func (r *MemoryEngagementRepository) RecordPlay(ctx context.Context, userID string, songID uint) error {
+	return r.withSong(ctx, songID, func() {
+		if r.songs.plays[songID] == nil {
+			r.songs.plays[songID] = make(map[string]int64)
+		}
+		r.songs.plays[songID][userID]++
+	})
+}
+
+func (r *MemoryEngagementRepository) TopPlayed(ctx context.Context, userID string, limit int) ([]models.PlayedSong, error) {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return nil, ErrNoTenant
+	}
+
+	r.songs.mu.RLock()
+	var played []models.PlayedSong
+	for songID, users := range r.songs.plays {
+		plays, ok := users[userID]
+		if song := r.songs.songs[songID]; ok && song.TenantID == tenantID {
+			played = append(played, models.PlayedSong{Song: r.songs.decorate(song), Plays: plays})
+		}
+	}
+	r.songs.mu.RUnlock()
+
+	slices.SortFunc(played, func(a, b models.PlayedSong) int {
+		if c := cmp.Compare(b.Plays, a.Plays); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Song.ID, b.Song.ID)
+	})
+	return paginate(played, 1, limit), nil
+}
+
+func (r *MemoryEngagementRepository) withSong(ctx context.Context, songID uint, fn func()) error {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+
+	r.songs.mu.Lock()
+	defer r.songs.mu.Unlock()
+
+	if song, ok := r.songs.songs[songID]; !ok || song.TenantID != tenantID {
+		return ErrSongNotFound
+	}
+	fn()
+	return nil
+}
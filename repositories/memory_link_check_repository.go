@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"awesomeProject/models"
+	"cmp"
+	"context"
+	"slices"
+	"time"
+)
+
+type MemoryLinkCheckRepository struct {
+	songs *MemorySongRepository
+}
+
+func NewMemoryLinkCheckRepository(songs *MemorySongRepository) *MemoryLinkCheckRepository {
+	return &MemoryLinkCheckRepository{songs: songs}
+}
+
+func (r *MemoryLinkCheckRepository) DueLinks(ctx context.Context, cutoff time.Time, limit int) ([]models.LinkCheck, error) {
+	r.songs.mu.RLock()
+	var due []models.Song
+	for _, song := range r.songs.songs {
+		if song.Link != "" && (song.LinkCheckedAt == nil || song.LinkCheckedAt.Before(cutoff)) {
+			due = append(due, song)
+		}
+	}
+	r.songs.mu.RUnlock()
+
+	slices.SortFunc(due, func(a, b models.Song) int {
+		if a.LinkCheckedAt == nil || b.LinkCheckedAt == nil {
+			if c := cmp.Compare(checked(a), checked(b)); c != 0 {
+				return c
+			}
+		} else if c := a.LinkCheckedAt.Compare(*b.LinkCheckedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return dueChecks(paginate(due, 1, limit)), nil
+}
+
+func checked(song models.Song) int {
+	if song.LinkCheckedAt == nil {
+		return 0
+	}
+	return 1
+}
+
+func (r *MemoryLinkCheckRepository) RecordLinkChecks(ctx context.Context, checks []models.LinkCheck) error {
+	r.songs.mu.Lock()
+	defer r.songs.mu.Unlock()
+
+	for _, check := range checks {
+		song, ok := r.songs.songs[check.SongID]
+		if !ok || song.Link != check.Link {
+			continue
+		}
+		checkedAt := check.CheckedAt
+		song.LinkStatus, song.LinkCheckedAt = check.Status, &checkedAt
+		r.songs.songs[song.ID] = song
+	}
+	return nil
+}
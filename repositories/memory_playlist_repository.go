@@ -0,0 +1,186 @@
+package repositories
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/tenant"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type memoryPlaylist struct {
+	playlist models.Playlist
+	songIDs  []uint
+}
+
+// MemoryPlaylistRepository keeps playlists in process memory alongside a
+// MemorySongRepository, whose songs it references.
+type MemoryPlaylistRepository struct {
+	mu        sync.Mutex
+	nextID    uint
+	playlists map[uint]*memoryPlaylist
+	songs     *MemorySongRepository
+}
+
+func NewMemoryPlaylistRepository(songs *MemorySongRepository) *MemoryPlaylistRepository {
+	return &MemoryPlaylistRepository{playlists: make(map[uint]*memoryPlaylist), songs: songs}
+}
+
+func (r *MemoryPlaylistRepository) List(ctx context.Context, page, limit int) ([]models.Playlist, int64, error) {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return nil, 0, ErrNoTenant
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var matched []models.Playlist
+	for id := uint(1); id <= r.nextID; id++ {
+		if p, ok := r.playlists[id]; ok && p.playlist.TenantID == tenantID {
+			matched = append(matched, p.playlist)
+		}
+	}
+
+	total := int64(len(matched))
+	offset := max((page-1)*limit, 0)
+	if offset >= len(matched) {
+		return nil, total, nil
+	}
+	return matched[offset:min(offset+limit, len(matched))], total, nil
+}
+
+func (r *MemoryPlaylistRepository) GetByID(ctx context.Context, id uint) (*models.Playlist, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, err := r.lookup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	playlist := p.playlist
+	for _, songID := range p.songIDs {
+		song, err := r.songs.GetByID(ctx, strconv.FormatUint(uint64(songID), 10))
+		if err != nil {
+			continue
+		}
+		playlist.Items = append(playlist.Items, models.PlaylistItem{
+			PlaylistID: id,
+			SongID:     songID,
+			Position:   len(playlist.Items) + 1,
+			Song:       song,
+		})
+	}
+	return &playlist, nil
+}
+
+func (r *MemoryPlaylistRepository) Create(ctx context.Context, playlist *models.Playlist) error {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	now := time.Now()
+	playlist.ID = r.nextID
+	playlist.TenantID = tenantID
+	playlist.CreatedAt = now
+	playlist.UpdatedAt = now
+	playlist.Items = nil
+	r.playlists[playlist.ID] = &memoryPlaylist{playlist: *playlist}
+	return nil
+}
+
+func (r *MemoryPlaylistRepository) Update(ctx context.Context, playlist *models.Playlist) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, err := r.lookup(ctx, playlist.ID)
+	if err != nil {
+		return err
+	}
+	p.playlist.Name = playlist.Name
+	p.playlist.Description = playlist.Description
+	p.playlist.Rules = playlist.Rules
+	p.playlist.UpdatedAt = time.Now()
+	playlist.UpdatedAt = p.playlist.UpdatedAt
+	return nil
+}
+
+func (r *MemoryPlaylistRepository) Delete(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.lookup(ctx, id); err == nil {
+		delete(r.playlists, id)
+	} else if errors.Is(err, ErrNoTenant) {
+		return err
+	}
+	return nil
+}
+
+func (r *MemoryPlaylistRepository) AddSong(ctx context.Context, id, songID uint, position int) error {
+	return r.editSongs(ctx, id, func(songIDs []uint) ([]uint, error) {
+		if _, err := r.songs.GetByID(ctx, strconv.FormatUint(uint64(songID), 10)); err != nil {
+			return nil, ErrSongNotFound
+		}
+		return insertSong(songIDs, songID, position)
+	})
+}
+
+func (r *MemoryPlaylistRepository) RemoveSong(ctx context.Context, id, songID uint) error {
+	return r.editSongs(ctx, id, func(songIDs []uint) ([]uint, error) {
+		return removeSong(songIDs, songID)
+	})
+}
+
+func (r *MemoryPlaylistRepository) Reorder(ctx context.Context, id uint, order []uint) error {
+	return r.editSongs(ctx, id, func(songIDs []uint) ([]uint, error) {
+		return reorderSongs(songIDs, order)
+	})
+}
+
+func (r *MemoryPlaylistRepository) editSongs(ctx context.Context, id uint, edit func(songIDs []uint) ([]uint, error)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, err := r.lookup(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// Songs deleted since they were added no longer belong to the playlist.
+	var current []uint
+	for _, songID := range p.songIDs {
+		if _, err := r.songs.GetByID(ctx, strconv.FormatUint(uint64(songID), 10)); err == nil {
+			current = append(current, songID)
+		}
+	}
+
+	next, err := edit(current)
+	if err != nil {
+		return err
+	}
+	p.songIDs = next
+	p.playlist.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *MemoryPlaylistRepository) lookup(ctx context.Context, id uint) (*memoryPlaylist, error) {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return nil, ErrNoTenant
+	}
+	p, ok := r.playlists[id]
+	if !ok || p.playlist.TenantID != tenantID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return p, nil
+}
@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/tenant"
+	"context"
+	"slices"
+	"time"
+)
+
+// MemoryReviewRepository stores reviews in a MemorySongRepository.
+type MemoryReviewRepository struct {
+	songs *MemorySongRepository
+}
+
+func NewMemoryReviewRepository(songs *MemorySongRepository) *MemoryReviewRepository {
+	return &MemoryReviewRepository{songs: songs}
+}
+
+func (r *MemoryReviewRepository) Reviews(ctx context.Context) (map[uint][]string, error) {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return nil, ErrNoTenant
+	}
+
+	r.songs.mu.RLock()
+	defer r.songs.mu.RUnlock()
+
+	reviews := make(map[uint][]string)
+	for id, review := range r.songs.reviews {
+		if r.songs.songs[id].TenantID == tenantID {
+			reviews[id] = slices.Clone(review.Issues)
+		}
+	}
+	return reviews, nil
+}
+
+func (r *MemoryReviewRepository) MarkReviewed(ctx context.Context, issues map[uint][]string) error {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+
+	r.songs.mu.Lock()
+	defer r.songs.mu.Unlock()
+
+	for id := range issues {
+		if song, ok := r.songs.songs[id]; !ok || song.TenantID != tenantID {
+			return ErrSongNotFound
+		}
+	}
+	now := time.Now()
+	for id, songIssues := range issues {
+		r.songs.reviews[id] = models.QualityReview{SongID: id, Issues: slices.Clone(songIssues), ReviewedAt: now}
+	}
+	return nil
+}
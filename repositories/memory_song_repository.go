@@ -0,0 +1,244 @@
+package repositories
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/tenant"
+	"cmp"
+	"context"
+	"gorm.io/gorm"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemorySongRepository keeps songs in process memory. It is meant for local
+// development and tests; nothing survives a restart.
+type MemorySongRepository struct {
+	mu     sync.RWMutex
+	nextID uint
+	songs  map[uint]models.Song
+
+	// Taxonomy, credit and engagement state lives here so searches can see
+	// it; it is managed through the other Memory*Repository types.
+	songTags     map[uint][]string
+	songGenres   map[uint][]uint
+	genres       map[uint]models.Genre
+	nextGenreID  uint
+	songCredits  map[uint][]models.Credit
+	people       map[uint]models.Person
+	nextPersonID uint
+	// Engagement is keyed by song, then user.
+	favorites map[uint]map[string]time.Time
+	ratings   map[uint]map[string]int
+	plays     map[uint]map[string]int64
+	reviews   map[uint]models.QualityReview
+
+	attachments      map[uint][]models.Attachment
+	nextAttachmentID uint
+}
+
+func NewMemorySongRepository() *MemorySongRepository {
+	return &MemorySongRepository{
+		songs:       make(map[uint]models.Song),
+		songTags:    make(map[uint][]string),
+		songGenres:  make(map[uint][]uint),
+		genres:      make(map[uint]models.Genre),
+		songCredits: make(map[uint][]models.Credit),
+		people:      make(map[uint]models.Person),
+		favorites:   make(map[uint]map[string]time.Time),
+		ratings:     make(map[uint]map[string]int),
+		plays:       make(map[uint]map[string]int64),
+		reviews:     make(map[uint]models.QualityReview),
+		attachments: make(map[uint][]models.Attachment),
+	}
+}
+
+// decorate attaches a stored song's tags, genres, credits and stats.
+// Callers hold r.mu.
+func (r *MemorySongRepository) decorate(song models.Song) models.Song {
+	song.Links = slices.Clone(song.Links)
+	song.Tags = slices.Clone(r.songTags[song.ID])
+	song.Genres = nil
+	for _, id := range r.songGenres[song.ID] {
+		song.Genres = append(song.Genres, r.genres[id].Name)
+	}
+	slices.Sort(song.Genres)
+	song.Credits = nil
+	for _, credit := range r.songCredits[song.ID] {
+		song.Credits = append(song.Credits, models.SongCredit{Person: r.people[credit.PersonID].Name, Role: credit.Role})
+	}
+	song.Stats = &models.SongStats{Favorites: int64(len(r.favorites[song.ID])), Ratings: int64(len(r.ratings[song.ID]))}
+	for _, stars := range r.ratings[song.ID] {
+		song.Stats.AverageRating += float64(stars) / float64(len(r.ratings[song.ID]))
+	}
+	for _, plays := range r.plays[song.ID] {
+		song.Stats.Plays += plays
+	}
+	return song
+}
+
+// facts collects what matchRule needs about a song. Callers hold r.mu.
+func (r *MemorySongRepository) facts(song models.Song) songFacts {
+	facts := songFacts{song: r.decorate(song)}
+	for _, id := range r.songGenres[song.ID] {
+		for genre, ok := r.genres[id]; ok; {
+			facts.genres = append(facts.genres, strings.ToLower(genre.Name))
+			if genre.ParentID == nil {
+				break
+			}
+			genre, ok = r.genres[*genre.ParentID]
+		}
+	}
+	return facts
+}
+
+func (r *MemorySongRepository) List(ctx context.Context, page, limit int, filters map[string]string) ([]models.Song, int64, error) {
+	return r.Search(ctx, filtersRule(filters), page, limit)
+}
+
+func (r *MemorySongRepository) Search(ctx context.Context, rule models.Rule, page, limit int) ([]models.Song, int64, error) {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return nil, 0, ErrNoTenant
+	}
+
+	r.mu.RLock()
+	var matched []models.Song
+	for _, song := range r.songs {
+		if song.TenantID != tenantID {
+			continue
+		}
+		if facts := r.facts(song); matchRule(facts, rule) {
+			matched = append(matched, facts.song)
+		}
+	}
+	r.mu.RUnlock()
+
+	slices.SortFunc(matched, func(a, b models.Song) int { return cmp.Compare(a.ID, b.ID) })
+
+	return paginate(matched, page, limit), int64(len(matched)), nil
+}
+
+// paginate returns the items of a 1-based page. Like SQL, a negative limit
+// means no limit.
+func paginate[T any](items []T, page, limit int) []T {
+	offset := max((page-1)*limit, 0)
+	if offset >= len(items) {
+		return nil
+	}
+	end := len(items)
+	if limit >= 0 && offset+limit < end {
+		end = offset + limit
+	}
+	return items[offset:end]
+}
+
+func (r *MemorySongRepository) GetByID(ctx context.Context, id string) (*models.Song, error) {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return nil, ErrNoTenant
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	song, ok := r.lookup(tenantID, id)
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	song = r.decorate(song)
+	return &song, nil
+}
+
+func (r *MemorySongRepository) Create(ctx context.Context, song *models.Song) error {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if song.ID == 0 {
+		song.ID = r.nextID + 1
+	}
+	// IDs are unique across tenants, as the primary key is in SQL.
+	if _, exists := r.songs[song.ID]; exists {
+		return gorm.ErrDuplicatedKey
+	}
+	if song.ID > r.nextID {
+		r.nextID = song.ID
+	}
+	song.TenantID = tenantID
+	prepareLinks(song)
+	r.store(*song)
+	return nil
+}
+
+func (r *MemorySongRepository) Update(ctx context.Context, song *models.Song) error {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.songs[song.ID]
+	if !ok || existing.TenantID != tenantID {
+		return gorm.ErrRecordNotFound
+	}
+	keepLinkCheck(song, existing)
+	song.TenantID = tenantID
+	prepareLinks(song)
+	r.store(*song)
+	return nil
+}
+
+func (r *MemorySongRepository) Delete(ctx context.Context, id string) error {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if song, ok := r.lookup(tenantID, id); ok {
+		delete(r.songs, song.ID)
+		delete(r.songTags, song.ID)
+		delete(r.songGenres, song.ID)
+		delete(r.songCredits, song.ID)
+		delete(r.favorites, song.ID)
+		delete(r.ratings, song.ID)
+		delete(r.plays, song.ID)
+		delete(r.reviews, song.ID)
+		delete(r.attachments, song.ID)
+	}
+	return nil
+}
+
+// store saves a song without its derived tags, genres, credits and stats.
+// Callers hold r.mu.
+func (r *MemorySongRepository) store(song models.Song) {
+	song.Links = slices.Clone(song.Links)
+	song.Tags = nil
+	song.Genres = nil
+	song.Credits = nil
+	song.Stats = nil
+	r.songs[song.ID] = song
+}
+
+func (r *MemorySongRepository) lookup(tenantID, id string) (models.Song, bool) {
+	n, err := strconv.ParseUint(id, 10, 0)
+	if err != nil {
+		return models.Song{}, false
+	}
+	song, ok := r.songs[uint(n)]
+	if !ok || song.TenantID != tenantID {
+		return models.Song{}, false
+	}
+	return song, true
+}
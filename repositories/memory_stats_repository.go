@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/tenant"
+	"cmp"
+	"context"
+	"slices"
+	"unicode/utf8"
+)
+
+// MemoryStatsRepository computes stats over a MemorySongRepository.
+type MemoryStatsRepository struct {
+	songs *MemorySongRepository
+}
+
+func NewMemoryStatsRepository(songs *MemorySongRepository) *MemoryStatsRepository {
+	return &MemoryStatsRepository{songs: songs}
+}
+
+func (r *MemoryStatsRepository) LibraryStats(ctx context.Context) (*models.LibraryStats, error) {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return nil, ErrNoTenant
+	}
+
+	stats := &models.LibraryStats{}
+	artists := make(map[string]int64)
+	years := make(map[string]int64)
+	var complete, withText, textLength int64
+
+	r.songs.mu.RLock()
+	for _, song := range r.songs.songs {
+		if song.TenantID != tenantID {
+			continue
+		}
+		stats.Songs++
+		artists[song.Group]++
+		if song.Text == "" {
+			stats.Missing.Text++
+		} else {
+			withText++
+			textLength += int64(utf8.RuneCountInString(song.Text))
+		}
+		if song.Link == "" {
+			stats.Missing.Link++
+		}
+		if song.ReleaseDate == "" {
+			stats.Missing.ReleaseDate++
+		} else if len(song.ReleaseDate) >= 4 {
+			years[song.ReleaseDate[len(song.ReleaseDate)-4:]]++
+		}
+		if song.Text != "" && song.Link != "" && song.ReleaseDate != "" {
+			complete++
+		}
+	}
+	r.songs.mu.RUnlock()
+
+	if withText > 0 {
+		stats.AverageTextLength = float64(textLength) / float64(withText)
+	}
+	for artist, count := range artists {
+		stats.ByArtist = append(stats.ByArtist, models.StatsCount{Key: artist, Count: count})
+	}
+	slices.SortFunc(stats.ByArtist, func(a, b models.StatsCount) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Key, b.Key)
+	})
+	stats.ByArtist = stats.ByArtist[:min(len(stats.ByArtist), statsTopArtists)]
+
+	var yearCounts []models.StatsCount
+	for year, count := range years {
+		yearCounts = append(yearCounts, models.StatsCount{Key: year, Count: count})
+	}
+	finishStats(stats, yearCounts, complete)
+	return stats, nil
+}
@@ -0,0 +1,210 @@
+package repositories
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/tenant"
+	"cmp"
+	"context"
+	"gorm.io/gorm"
+	"slices"
+	"strings"
+)
+
+// MemoryTaxonomyRepository manages tags and genres stored in a
+// MemorySongRepository.
+type MemoryTaxonomyRepository struct {
+	songs *MemorySongRepository
+}
+
+func NewMemoryTaxonomyRepository(songs *MemorySongRepository) *MemoryTaxonomyRepository {
+	return &MemoryTaxonomyRepository{songs: songs}
+}
+
+func (r *MemoryTaxonomyRepository) TagCounts(ctx context.Context) ([]models.TagCount, error) {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return nil, ErrNoTenant
+	}
+
+	r.songs.mu.RLock()
+	counts := make(map[string]int64)
+	for id, tags := range r.songs.songTags {
+		if r.songs.songs[id].TenantID != tenantID {
+			continue
+		}
+		for _, tag := range tags {
+			counts[tag]++
+		}
+	}
+	r.songs.mu.RUnlock()
+
+	var out []models.TagCount
+	for name, count := range counts {
+		out = append(out, models.TagCount{Name: name, Count: count})
+	}
+	slices.SortFunc(out, func(a, b models.TagCount) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return out, nil
+}
+
+func (r *MemoryTaxonomyRepository) TagSongs(ctx context.Context, songIDs []uint, tags []string) error {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+	songIDs, tags = uniqueIDs(songIDs), uniqueTags(tags)
+
+	r.songs.mu.Lock()
+	defer r.songs.mu.Unlock()
+
+	if !r.hasSongs(tenantID, songIDs) {
+		return ErrSongNotFound
+	}
+	for _, id := range songIDs {
+		current := r.songs.songTags[id]
+		for _, tag := range tags {
+			if !slices.Contains(current, tag) {
+				current = append(current, tag)
+			}
+		}
+		slices.Sort(current)
+		r.songs.songTags[id] = current
+	}
+	return nil
+}
+
+func (r *MemoryTaxonomyRepository) UntagSongs(ctx context.Context, songIDs []uint, tags []string) error {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+	tags = uniqueTags(tags)
+
+	r.songs.mu.Lock()
+	defer r.songs.mu.Unlock()
+
+	for _, id := range songIDs {
+		if r.songs.songs[id].TenantID != tenantID {
+			continue
+		}
+		r.songs.songTags[id] = slices.DeleteFunc(r.songs.songTags[id], func(tag string) bool {
+			return slices.Contains(tags, tag)
+		})
+		if len(r.songs.songTags[id]) == 0 {
+			delete(r.songs.songTags, id)
+		}
+	}
+	return nil
+}
+
+func (r *MemoryTaxonomyRepository) Genres(ctx context.Context) ([]models.Genre, error) {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return nil, ErrNoTenant
+	}
+
+	r.songs.mu.RLock()
+	defer r.songs.mu.RUnlock()
+
+	var out []models.Genre
+	for _, genre := range r.songs.genres {
+		if genre.TenantID == tenantID {
+			out = append(out, genre)
+		}
+	}
+	slices.SortFunc(out, func(a, b models.Genre) int { return cmp.Compare(a.Name, b.Name) })
+	return out, nil
+}
+
+func (r *MemoryTaxonomyRepository) CreateGenre(ctx context.Context, genre *models.Genre) error {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+
+	r.songs.mu.Lock()
+	defer r.songs.mu.Unlock()
+
+	if genre.ParentID != nil && !r.hasGenres(tenantID, []uint{*genre.ParentID}) {
+		return ErrGenreNotFound
+	}
+	for _, existing := range r.songs.genres {
+		if existing.TenantID == tenantID && strings.EqualFold(existing.Name, genre.Name) {
+			return ErrGenreExists
+		}
+	}
+
+	r.songs.nextGenreID++
+	genre.ID = r.songs.nextGenreID
+	genre.TenantID = tenantID
+	r.songs.genres[genre.ID] = *genre
+	return nil
+}
+
+func (r *MemoryTaxonomyRepository) DeleteGenre(ctx context.Context, id uint) error {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+
+	r.songs.mu.Lock()
+	defer r.songs.mu.Unlock()
+
+	if !r.hasGenres(tenantID, []uint{id}) {
+		return gorm.ErrRecordNotFound
+	}
+	for _, genre := range r.songs.genres {
+		if genre.ParentID != nil && *genre.ParentID == id {
+			return ErrGenreHasChildren
+		}
+	}
+
+	delete(r.songs.genres, id)
+	for songID, genreIDs := range r.songs.songGenres {
+		r.songs.songGenres[songID] = slices.DeleteFunc(genreIDs, func(g uint) bool { return g == id })
+	}
+	return nil
+}
+
+func (r *MemoryTaxonomyRepository) SetSongGenres(ctx context.Context, songID uint, genreIDs []uint) error {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+	genreIDs = uniqueIDs(genreIDs)
+
+	r.songs.mu.Lock()
+	defer r.songs.mu.Unlock()
+
+	if !r.hasSongs(tenantID, []uint{songID}) {
+		return ErrSongNotFound
+	}
+	if !r.hasGenres(tenantID, genreIDs) {
+		return ErrGenreNotFound
+	}
+	r.songs.songGenres[songID] = genreIDs
+	return nil
+}
+
+// hasSongs and hasGenres require the caller to hold r.songs.mu.
+func (r *MemoryTaxonomyRepository) hasSongs(tenantID string, ids []uint) bool {
+	for _, id := range ids {
+		if song, ok := r.songs.songs[id]; !ok || song.TenantID != tenantID {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *MemoryTaxonomyRepository) hasGenres(tenantID string, ids []uint) bool {
+	for _, id := range ids {
+		if genre, ok := r.songs.genres[id]; !ok || genre.TenantID != tenantID {
+			return false
+		}
+	}
+	return true
+}
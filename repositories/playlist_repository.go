@@ -0,0 +1,282 @@
+package repositories
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/tenant"
+	"awesomeProject/tracing"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+var (
+	ErrSongNotFound      = errors.New("song not found")
+	ErrSongInPlaylist    = errors.New("song is already in the playlist")
+	ErrSongNotInPlaylist = errors.New("song is not in the playlist")
+	ErrInvalidPosition   = errors.New("position is out of range")
+	ErrInvalidOrder      = errors.New("order must list every song in the playlist exactly once")
+)
+
+// PlaylistRepository stores playlists and their ordered songs. Membership
+// changes are atomic: concurrent edits to one playlist are serialised and
+// positions are always 1..n.
+type PlaylistRepository interface {
+	List(ctx context.Context, page, limit int) ([]models.Playlist, int64, error)
+	// GetByID returns the playlist with its items and their songs in order.
+	GetByID(ctx context.Context, id uint) (*models.Playlist, error)
+	Create(ctx context.Context, playlist *models.Playlist) error
+	Update(ctx context.Context, playlist *models.Playlist) error
+	Delete(ctx context.Context, id uint) error
+	AddSong(ctx context.Context, id, songID uint, position int) error
+	RemoveSong(ctx context.Context, id, songID uint) error
+	Reorder(ctx context.Context, id uint, songIDs []uint) error
+}
+
+// insertSong places songID at the 1-based position, or appends it when
+// position is zero.
+func insertSong(songIDs []uint, songID uint, position int) ([]uint, error) {
+	for _, id := range songIDs {
+		if id == songID {
+			return nil, ErrSongInPlaylist
+		}
+	}
+	if position == 0 {
+		position = len(songIDs) + 1
+	}
+	if position < 1 || position > len(songIDs)+1 {
+		return nil, ErrInvalidPosition
+	}
+
+	next := make([]uint, 0, len(songIDs)+1)
+	next = append(next, songIDs[:position-1]...)
+	next = append(next, songID)
+	return append(next, songIDs[position-1:]...), nil
+}
+
+func removeSong(songIDs []uint, songID uint) ([]uint, error) {
+	for i, id := range songIDs {
+		if id == songID {
+			next := make([]uint, 0, len(songIDs)-1)
+			next = append(next, songIDs[:i]...)
+			return append(next, songIDs[i+1:]...), nil
+		}
+	}
+	return nil, ErrSongNotInPlaylist
+}
+
+// reorderSongs accepts order only if it is a permutation of songIDs.
+func reorderSongs(songIDs, order []uint) ([]uint, error) {
+	if len(order) != len(songIDs) {
+		return nil, ErrInvalidOrder
+	}
+	remaining := make(map[uint]bool, len(songIDs))
+	for _, id := range songIDs {
+		remaining[id] = true
+	}
+	for _, id := range order {
+		if !remaining[id] {
+			return nil, ErrInvalidOrder
+		}
+		delete(remaining, id)
+	}
+	return append([]uint(nil), order...), nil
+}
+
+type SQLPlaylistRepository struct {
+	db *gorm.DB
+}
+
+func NewSQLPlaylistRepository(db *gorm.DB) *SQLPlaylistRepository {
+	return &SQLPlaylistRepository{db: db}
+}
+
+func (r *SQLPlaylistRepository) List(ctx context.Context, page, limit int) (playlists []models.Playlist, total int64, err error) {
+	ctx, span := tracer.Start(ctx, "PlaylistRepository.List")
+	defer func() { tracing.End(span, err) }()
+
+	db, _, err := scoped(ctx, r.db)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := db.Model(&models.Playlist{})
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err = query.Order("id").Offset((page - 1) * limit).Limit(limit).Find(&playlists).Error
+	return playlists, total, err
+}
+
+func (r *SQLPlaylistRepository) GetByID(ctx context.Context, id uint) (playlist *models.Playlist, err error) {
+	ctx, span := tracer.Start(ctx, "PlaylistRepository.GetByID")
+	defer func() { tracing.End(span, err) }()
+
+	db, _, err := scoped(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+
+	playlist = &models.Playlist{}
+	if err := db.First(playlist, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+
+	var items []models.PlaylistItem
+	if err := conn(ctx, r.db).Where("playlist_id = ?", id).Order("position").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return playlist, nil
+	}
+
+	songIDs := make([]uint, len(items))
+	for i, item := range items {
+		songIDs[i] = item.SongID
+	}
+	var songs []models.Song
+	if err := db.Where("id IN ?", songIDs).Find(&songs).Error; err != nil {
+		return nil, err
+	}
+	if err := attachDetails(ctx, r.db, songs); err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*models.Song, len(songs))
+	for i := range songs {
+		byID[songs[i].ID] = &songs[i]
+	}
+	for i := range items {
+		items[i].Song = byID[items[i].SongID]
+	}
+	playlist.Items = items
+	return playlist, nil
+}
+
+func (r *SQLPlaylistRepository) Create(ctx context.Context, playlist *models.Playlist) (err error) {
+	ctx, span := tracer.Start(ctx, "PlaylistRepository.Create")
+	defer func() { tracing.End(span, err) }()
+
+	db, tenantID, err := scoped(ctx, r.db)
+	if err != nil {
+		return err
+	}
+
+	playlist.TenantID = tenantID
+	return db.Omit("Items").Create(playlist).Error
+}
+
+func (r *SQLPlaylistRepository) Update(ctx context.Context, playlist *models.Playlist) (err error) {
+	ctx, span := tracer.Start(ctx, "PlaylistRepository.Update")
+	defer func() { tracing.End(span, err) }()
+
+	db, _, err := scoped(ctx, r.db)
+	if err != nil {
+		return err
+	}
+
+	result := db.Model(playlist).Select("name", "description", "rules", "updated_at").Updates(playlist)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *SQLPlaylistRepository) Delete(ctx context.Context, id uint) (err error) {
+	ctx, span := tracer.Start(ctx, "PlaylistRepository.Delete")
+	defer func() { tracing.End(span, err) }()
+
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("tenant_id = ?", tenantID).Delete(&models.Playlist{}, "id = ?", id)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Where("playlist_id = ?", id).Delete(&models.PlaylistItem{}).Error
+	})
+}
+
+func (r *SQLPlaylistRepository) AddSong(ctx context.Context, id, songID uint, position int) (err error) {
+	ctx, span := tracer.Start(ctx, "PlaylistRepository.AddSong")
+	defer func() { tracing.End(span, err) }()
+
+	return r.editSongs(ctx, id, func(tx *gorm.DB, tenantID string, songIDs []uint) ([]uint, error) {
+		var count int64
+		err := tx.Model(&models.Song{}).Where("id = ? AND tenant_id = ?", songID, tenantID).Count(&count).Error
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return nil, ErrSongNotFound
+		}
+		return insertSong(songIDs, songID, position)
+	})
+}
+
+func (r *SQLPlaylistRepository) RemoveSong(ctx context.Context, id, songID uint) (err error) {
+	ctx, span := tracer.Start(ctx, "PlaylistRepository.RemoveSong")
+	defer func() { tracing.End(span, err) }()
+
+	return r.editSongs(ctx, id, func(_ *gorm.DB, _ string, songIDs []uint) ([]uint, error) {
+		return removeSong(songIDs, songID)
+	})
+}
+
+func (r *SQLPlaylistRepository) Reorder(ctx context.Context, id uint, order []uint) (err error) {
+	ctx, span := tracer.Start(ctx, "PlaylistRepository.Reorder")
+	defer func() { tracing.End(span, err) }()
+
+	return r.editSongs(ctx, id, func(_ *gorm.DB, _ string, songIDs []uint) ([]uint, error) {
+		return reorderSongs(songIDs, order)
+	})
+}
+
+// editSongs rewrites a playlist's membership in one transaction. Touching
+// the playlist row first locks it, so concurrent edits cannot interleave.
+func (r *SQLPlaylistRepository) editSongs(ctx context.Context, id uint, edit func(tx *gorm.DB, tenantID string, songIDs []uint) ([]uint, error)) error {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Playlist{}).
+			Where("id = ? AND tenant_id = ?", id, tenantID).
+			Update("updated_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		var songIDs []uint
+		err := tx.Model(&models.PlaylistItem{}).Where("playlist_id = ?", id).Order("position").Pluck("song_id", &songIDs).Error
+		if err != nil {
+			return err
+		}
+
+		next, err := edit(tx, tenantID, songIDs)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("playlist_id = ?", id).Delete(&models.PlaylistItem{}).Error; err != nil {
+			return err
+		}
+		if len(next) == 0 {
+			return nil
+		}
+		items := make([]models.PlaylistItem, len(next))
+		for i, songID := range next {
+			items[i] = models.PlaylistItem{PlaylistID: id, SongID: songID, Position: i + 1}
+		}
+		return tx.Create(&items).Error
+	})
+}
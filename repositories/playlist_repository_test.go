@@ -0,0 +1,229 @@
+package repositories
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/tenant"
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"sync"
+	"testing"
+)
+
+func testPlaylistRepository(t *testing.T, newRepos func(t *testing.T) (SongRepository, PlaylistRepository)) {
+	ctx := tenant.NewContext(context.Background(), tenant.Default)
+
+	setup := func(t *testing.T) (PlaylistRepository, *models.Playlist, []uint) {
+		songs, playlists := newRepos(t)
+		var ids []uint
+		for _, name := range []string{"One", "Two", "Three"} {
+			song := models.Song{Group: "Band", Name: name}
+			require.NoError(t, songs.Create(ctx, &song))
+			ids = append(ids, song.ID)
+		}
+		playlist := &models.Playlist{Name: "Mix", Description: "Weekend", Owner: "alice"}
+		require.NoError(t, playlists.Create(ctx, playlist))
+		require.NotZero(t, playlist.ID)
+		return playlists, playlist, ids
+	}
+
+	order := func(t *testing.T, repo PlaylistRepository, id uint) []uint {
+		playlist, err := repo.GetByID(ctx, id)
+		require.NoError(t, err)
+		var songIDs []uint
+		for i, item := range playlist.Items {
+			assert.Equal(t, i+1, item.Position, "positions are contiguous")
+			require.NotNil(t, item.Song)
+			assert.Equal(t, item.SongID, item.Song.ID)
+			songIDs = append(songIDs, item.SongID)
+		}
+		return songIDs
+	}
+
+	t.Run("Create, GetByID and Update", func(t *testing.T) {
+		repo, playlist, _ := setup(t)
+
+		got, err := repo.GetByID(ctx, playlist.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Mix", got.Name)
+		assert.Equal(t, "alice", got.Owner)
+		assert.Empty(t, got.Items)
+
+		got.Name = "Renamed"
+		require.NoError(t, repo.Update(ctx, got))
+		got, err = repo.GetByID(ctx, playlist.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Renamed", got.Name)
+
+		assert.ErrorIs(t, repo.Update(ctx, &models.Playlist{ID: 999999, Name: "x"}), gorm.ErrRecordNotFound)
+		_, err = repo.GetByID(ctx, 999999)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("rules round-trip", func(t *testing.T) {
+		_, repo := newRepos(t)
+		rules := &models.Rule{Any: []models.Rule{
+			{Field: models.RuleFieldGroup, Op: models.RuleOpContains, Value: "muse"},
+			{Field: models.RuleFieldReleaseYear, Op: models.RuleOpBetween, Values: []string{"2000", "2009"}},
+		}}
+		playlist := &models.Playlist{Name: "Smart", Rules: rules}
+		require.NoError(t, repo.Create(ctx, playlist))
+
+		got, err := repo.GetByID(ctx, playlist.ID)
+		require.NoError(t, err)
+		assert.Equal(t, rules, got.Rules)
+
+		got.Rules = &models.Rule{Field: models.RuleFieldSong, Op: models.RuleOpEquals, Value: "x"}
+		require.NoError(t, repo.Update(ctx, got))
+		got, err = repo.GetByID(ctx, playlist.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "x", got.Rules.Value)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		repo, first, _ := setup(t)
+		second := &models.Playlist{Name: "Second", Owner: "bob"}
+		require.NoError(t, repo.Create(ctx, second))
+
+		playlists, total, err := repo.List(ctx, 2, 1)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		require.Len(t, playlists, 1)
+		assert.Equal(t, second.ID, playlists[0].ID)
+
+		playlists, _, err = repo.List(ctx, 1, 10)
+		require.NoError(t, err)
+		assert.Equal(t, first.ID, playlists[0].ID)
+	})
+
+	t.Run("AddSong appends and inserts", func(t *testing.T) {
+		repo, playlist, ids := setup(t)
+
+		require.NoError(t, repo.AddSong(ctx, playlist.ID, ids[0], 0))
+		require.NoError(t, repo.AddSong(ctx, playlist.ID, ids[1], 0))
+		require.NoError(t, repo.AddSong(ctx, playlist.ID, ids[2], 1))
+		assert.Equal(t, []uint{ids[2], ids[0], ids[1]}, order(t, repo, playlist.ID))
+
+		assert.ErrorIs(t, repo.AddSong(ctx, playlist.ID, ids[0], 0), ErrSongInPlaylist)
+		assert.ErrorIs(t, repo.AddSong(ctx, playlist.ID, 999999, 0), ErrSongNotFound)
+		assert.ErrorIs(t, repo.AddSong(ctx, 999999, ids[0], 0), gorm.ErrRecordNotFound)
+	})
+
+	t.Run("AddSong rejects positions past the end", func(t *testing.T) {
+		repo, playlist, ids := setup(t)
+
+		assert.ErrorIs(t, repo.AddSong(ctx, playlist.ID, ids[0], 2), ErrInvalidPosition)
+		assert.Empty(t, order(t, repo, playlist.ID), "failed edits change nothing")
+	})
+
+	t.Run("RemoveSong closes the gap", func(t *testing.T) {
+		repo, playlist, ids := setup(t)
+		for _, id := range ids {
+			require.NoError(t, repo.AddSong(ctx, playlist.ID, id, 0))
+		}
+
+		require.NoError(t, repo.RemoveSong(ctx, playlist.ID, ids[1]))
+		assert.Equal(t, []uint{ids[0], ids[2]}, order(t, repo, playlist.ID))
+		assert.ErrorIs(t, repo.RemoveSong(ctx, playlist.ID, ids[1]), ErrSongNotInPlaylist)
+	})
+
+	t.Run("Reorder", func(t *testing.T) {
+		repo, playlist, ids := setup(t)
+		for _, id := range ids {
+			require.NoError(t, repo.AddSong(ctx, playlist.ID, id, 0))
+		}
+
+		require.NoError(t, repo.Reorder(ctx, playlist.ID, []uint{ids[2], ids[0], ids[1]}))
+		assert.Equal(t, []uint{ids[2], ids[0], ids[1]}, order(t, repo, playlist.ID))
+
+		for _, bad := range [][]uint{
+			{ids[0], ids[1]},
+			{ids[0], ids[0], ids[1]},
+			{ids[0], ids[1], 999999},
+		} {
+			assert.ErrorIs(t, repo.Reorder(ctx, playlist.ID, bad), ErrInvalidOrder, fmt.Sprint(bad))
+		}
+		assert.Equal(t, []uint{ids[2], ids[0], ids[1]}, order(t, repo, playlist.ID))
+	})
+
+	t.Run("deleting a song removes it from playlists", func(t *testing.T) {
+		songs, repo := newRepos(t)
+		a := models.Song{Group: "Band", Name: "A"}
+		b := models.Song{Group: "Band", Name: "B"}
+		require.NoError(t, songs.Create(ctx, &a))
+		require.NoError(t, songs.Create(ctx, &b))
+		playlist := &models.Playlist{Name: "Mix"}
+		require.NoError(t, repo.Create(ctx, playlist))
+		require.NoError(t, repo.AddSong(ctx, playlist.ID, a.ID, 0))
+		require.NoError(t, repo.AddSong(ctx, playlist.ID, b.ID, 0))
+
+		require.NoError(t, songs.Delete(ctx, fmt.Sprint(a.ID)))
+		assert.Equal(t, []uint{b.ID}, order(t, repo, playlist.ID))
+	})
+
+	t.Run("concurrent edits keep positions consistent", func(t *testing.T) {
+		songs, repo := newRepos(t)
+		playlist := &models.Playlist{Name: "Mix"}
+		require.NoError(t, repo.Create(ctx, playlist))
+
+		const n = 12
+		ids := make([]uint, n)
+		for i := range ids {
+			song := models.Song{Group: "Band", Name: fmt.Sprint(i)}
+			require.NoError(t, songs.Create(ctx, &song))
+			ids[i] = song.ID
+		}
+
+		var wg sync.WaitGroup
+		for _, id := range ids {
+			wg.Add(1)
+			go func(id uint) {
+				defer wg.Done()
+				assert.NoError(t, repo.AddSong(ctx, playlist.ID, id, 1))
+			}(id)
+		}
+		wg.Wait()
+
+		assert.ElementsMatch(t, ids, order(t, repo, playlist.ID))
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repo, playlist, ids := setup(t)
+		require.NoError(t, repo.AddSong(ctx, playlist.ID, ids[0], 0))
+
+		require.NoError(t, repo.Delete(ctx, playlist.ID))
+		_, err := repo.GetByID(ctx, playlist.ID)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		assert.NoError(t, repo.Delete(ctx, playlist.ID))
+	})
+
+	t.Run("tenants are isolated", func(t *testing.T) {
+		repo, playlist, ids := setup(t)
+		other := tenant.NewContext(context.Background(), "other")
+
+		_, err := repo.GetByID(other, playlist.ID)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		assert.ErrorIs(t, repo.AddSong(other, playlist.ID, ids[0], 0), gorm.ErrRecordNotFound)
+
+		mine := &models.Playlist{Name: "Other tenant"}
+		require.NoError(t, repo.Create(other, mine))
+		assert.ErrorIs(t, repo.AddSong(other, mine.ID, ids[0], 0), ErrSongNotFound,
+			"songs from another tenant cannot be added")
+	})
+}
+
+func TestSQLPlaylistRepository_SQLite(t *testing.T) {
+	testPlaylistRepository(t, func(t *testing.T) (SongRepository, PlaylistRepository) {
+		db := setupSQLiteDB(t)
+		return NewSQLSongRepository(db), NewSQLPlaylistRepository(db)
+	})
+}
+
+func TestMemoryPlaylistRepository(t *testing.T) {
+	testPlaylistRepository(t, func(t *testing.T) (SongRepository, PlaylistRepository) {
+		songs := NewMemorySongRepository()
+		return songs, NewMemoryPlaylistRepository(songs)
+	})
+}
@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/tenant"
+	"awesomeProject/tracing"
+	"context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// ReviewRepository remembers which data-quality issues were accepted for
+// each song.
+type ReviewRepository interface {
+	// Reviews returns the accepted issues of every reviewed song.
+	Reviews(ctx context.Context) (map[uint][]string, error)
+	// MarkReviewed records the accepted issues for each song, replacing
+	// earlier reviews. It fails without changes if any song is missing.
+	MarkReviewed(ctx context.Context, issues map[uint][]string) error
+}
+
+type SQLReviewRepository struct {
+	db *gorm.DB
+}
+
+func NewSQLReviewRepository(db *gorm.DB) *SQLReviewRepository {
+	return &SQLReviewRepository{db: db}
+}
+
+func (r *SQLReviewRepository) Reviews(ctx context.Context) (_ map[uint][]string, err error) {
+	ctx, span := tracer.Start(ctx, "ReviewRepository.Reviews")
+	defer func() { tracing.End(span, err) }()
+
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return nil, ErrNoTenant
+	}
+
+	var rows []models.QualityReview
+	err = conn(ctx, r.db).
+		Joins("JOIN songs ON songs.id = quality_reviews.song_id").
+		Where("songs.tenant_id = ?", tenantID).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	reviews := make(map[uint][]string, len(rows))
+	for _, row := range rows {
+		reviews[row.SongID] = row.Issues
+	}
+	return reviews, nil
+}
+
+func (r *SQLReviewRepository) MarkReviewed(ctx context.Context, issues map[uint][]string) (err error) {
+	ctx, span := tracer.Start(ctx, "ReviewRepository.MarkReviewed")
+	defer func() { tracing.End(span, err) }()
+
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+	if len(issues) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, 0, len(issues))
+	rows := make([]models.QualityReview, 0, len(issues))
+	now := time.Now()
+	for id, songIssues := range issues {
+		ids = append(ids, id)
+		rows = append(rows, models.QualityReview{SongID: id, Issues: songIssues, ReviewedAt: now})
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := requireSongs(tx, tenantID, ids); err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "song_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"issues", "reviewed_at"}),
+		}).Create(&rows).Error
+	})
+}
@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/tenant"
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func testReviewRepository(t *testing.T, newRepos func(t *testing.T) (SongRepository, ReviewRepository)) {
+	ctx := tenant.NewContext(context.Background(), tenant.Default)
+
+	setup := func(t *testing.T) (SongRepository, ReviewRepository, []uint) {
+		songs, reviews := newRepos(t)
+		var ids []uint
+		for _, name := range []string{"One", "Two"} {
+			song := models.Song{Group: "Band", Name: name}
+			require.NoError(t, songs.Create(ctx, &song))
+			ids = append(ids, song.ID)
+		}
+		return songs, reviews, ids
+	}
+
+	t.Run("MarkReviewed replaces reviews", func(t *testing.T) {
+		_, reviews, ids := setup(t)
+		require.NoError(t, reviews.MarkReviewed(ctx, map[uint][]string{ids[0]: {"missing_text"}, ids[1]: {"missing_link"}}))
+		require.NoError(t, reviews.MarkReviewed(ctx, map[uint][]string{ids[0]: {"missing_text", "missing_link"}}))
+
+		got, err := reviews.Reviews(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, map[uint][]string{ids[0]: {"missing_text", "missing_link"}, ids[1]: {"missing_link"}}, got)
+	})
+
+	t.Run("MarkReviewed is all or nothing", func(t *testing.T) {
+		_, reviews, ids := setup(t)
+		err := reviews.MarkReviewed(ctx, map[uint][]string{ids[0]: {"missing_text"}, 999: {"missing_text"}})
+		assert.ErrorIs(t, err, ErrSongNotFound)
+
+		got, err := reviews.Reviews(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	})
+
+	t.Run("deleting a song drops its review", func(t *testing.T) {
+		songs, reviews, ids := setup(t)
+		require.NoError(t, reviews.MarkReviewed(ctx, map[uint][]string{ids[0]: {"missing_text"}}))
+		require.NoError(t, songs.Delete(ctx, fmt.Sprint(ids[0])))
+
+		got, err := reviews.Reviews(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	})
+
+	t.Run("tenants are isolated", func(t *testing.T) {
+		_, reviews, ids := setup(t)
+		require.NoError(t, reviews.MarkReviewed(ctx, map[uint][]string{ids[0]: {"missing_text"}}))
+
+		other := tenant.NewContext(context.Background(), "other")
+		assert.ErrorIs(t, reviews.MarkReviewed(other, map[uint][]string{ids[1]: nil}), ErrSongNotFound)
+		got, err := reviews.Reviews(other)
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	})
+
+	t.Run("requires a tenant", func(t *testing.T) {
+		_, reviews := newRepos(t)
+		_, err := reviews.Reviews(context.Background())
+		assert.ErrorIs(t, err, ErrNoTenant)
+	})
+}
+
+func TestSQLReviewRepository_SQLite(t *testing.T) {
+	testReviewRepository(t, func(t *testing.T) (SongRepository, ReviewRepository) {
+		db := setupSQLiteDB(t)
+		return NewSQLSongRepository(db), NewSQLReviewRepository(db)
+	})
+}
+
+func TestMemoryReviewRepository(t *testing.T) {
+	testReviewRepository(t, func(t *testing.T) (SongRepository, ReviewRepository) {
+		songs := NewMemorySongRepository()
+		return songs, NewMemoryReviewRepository(songs)
+	})
+}
@@ -0,0 +1,278 @@
+package repositories
+
+import (
+	"awesomeProject/models"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// filtersRule turns List's query-string filters into a rule so that List
+// and smart playlists are evaluated by the same query builder.
+func filtersRule(filters map[string]string) models.Rule {
+	rule := models.Rule{All: []models.Rule{}}
+	for _, f := range []struct{ key, field, op string }{
+		{FilterGroup, models.RuleFieldGroup, models.RuleOpContains},
+		{FilterSong, models.RuleFieldSong, models.RuleOpContains},
+		{FilterReleaseDate, models.RuleFieldReleaseDate, models.RuleOpEquals},
+		{FilterLink, models.RuleFieldLink, models.RuleOpContains},
+	} {
+		if value := filters[f.key]; value != "" {
+			rule.All = append(rule.All, models.Rule{Field: f.field, Op: f.op, Value: value})
+		}
+	}
+
+	var tags []string
+	for _, tag := range strings.Split(filters[FilterTags], ",") {
+		if tag = models.NormalizeTag(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) > 0 && filters[FilterTagMode] == TagModeAny {
+		rule.All = append(rule.All, models.Rule{Field: models.RuleFieldTag, Op: models.RuleOpIn, Values: tags})
+	} else {
+		for _, tag := range tags {
+			rule.All = append(rule.All, models.Rule{Field: models.RuleFieldTag, Op: models.RuleOpEquals, Value: tag})
+		}
+	}
+
+	if genre := filters[FilterGenre]; genre != "" {
+		rule.All = append(rule.All, models.Rule{Field: models.RuleFieldGenre, Op: models.RuleOpEquals, Value: genre})
+	}
+	if provider := filters[FilterProvider]; provider != "" {
+		rule.All = append(rule.All, models.Rule{Field: models.RuleFieldProvider, Op: models.RuleOpEquals, Value: provider})
+	}
+	if state := filters[FilterLinkState]; state != "" {
+		rule.All = append(rule.All, models.Rule{Field: models.RuleFieldLinkState, Op: models.RuleOpEquals, Value: state})
+	}
+	if person := models.NormalizePersonName(filters[FilterPerson]); person != "" {
+		rule.All = append(rule.All, models.Rule{Field: models.RuleFieldPerson, Op: models.RuleOpEquals, Value: person, Role: filters[FilterRole]})
+	}
+	return rule
+}
+
+var songColumns = map[string]string{
+	models.RuleFieldGroup:       `"group"`,
+	models.RuleFieldSong:        "name",
+	models.RuleFieldLink:        "link",
+	models.RuleFieldReleaseDate: "release_date",
+	models.RuleFieldReleaseYear: "CASE WHEN LENGTH(release_date) >= 4 THEN SUBSTR(release_date, LENGTH(release_date) - 3, 4) ELSE '' END",
+}
+
+var linkStateConditions = map[string]string{
+	models.LinkStateUnchecked: "link_checked_at IS NULL",
+	models.LinkStateOK:        "link_checked_at IS NOT NULL AND link_status > 0 AND link_status < 400",
+	models.LinkStateBroken:    "link_checked_at IS NOT NULL AND (link_status <= 0 OR link_status >= 400)",
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// songQuery compiles a rule tree into a SQL condition on the songs table.
+// An empty All or Any compiles to no condition.
+type songQuery struct {
+	// ilike selects Postgres' ILIKE over the portable LOWER(...) LIKE.
+	ilike bool
+}
+
+func (q songQuery) compile(rule models.Rule) (string, []any, error) {
+	switch {
+	case rule.All != nil || rule.Any != nil:
+		children, joiner := rule.All, " AND "
+		if rule.Any != nil {
+			children, joiner = rule.Any, " OR "
+		}
+		var parts []string
+		var args []any
+		for _, child := range children {
+			sql, childArgs, err := q.compile(child)
+			if err != nil {
+				return "", nil, err
+			}
+			if sql != "" {
+				parts = append(parts, "("+sql+")")
+				args = append(args, childArgs...)
+			}
+		}
+		return strings.Join(parts, joiner), args, nil
+	case rule.Not != nil:
+		sql, args, err := q.compile(*rule.Not)
+		if err != nil {
+			return "", nil, err
+		}
+		if sql == "" {
+			return "1 = 0", nil, nil
+		}
+		return "NOT (" + sql + ")", args, nil
+	}
+
+	column, ok := songColumns[rule.Field]
+	if !ok && rule.Field != models.RuleFieldTag && rule.Field != models.RuleFieldGenre && rule.Field != models.RuleFieldPerson &&
+		rule.Field != models.RuleFieldProvider && rule.Field != models.RuleFieldLinkState {
+		return "", nil, fmt.Errorf("unknown rule field %q", rule.Field)
+	}
+
+	switch rule.Field {
+	case models.RuleFieldTag:
+		return "id IN (SELECT st.song_id FROM song_tags st JOIN tags t ON t.id = st.tag_id WHERE t.name IN ?)",
+			[]any{normalizeTags(ruleValues(rule))}, nil
+	case models.RuleFieldGenre:
+		return "id IN (SELECT sg.song_id FROM song_genres sg WHERE sg.genre_id IN (" +
+				"WITH RECURSIVE matched(id) AS (" +
+				"SELECT id FROM genres WHERE LOWER(name) IN ? " +
+				"UNION SELECT g.id FROM genres g JOIN matched ON g.parent_id = matched.id" +
+				") SELECT id FROM matched))",
+			[]any{lowerAll(ruleValues(rule))}, nil
+	case models.RuleFieldProvider:
+		return "id IN (SELECT sl.song_id FROM song_links sl WHERE sl.provider IN ?)", []any{ruleValues(rule)}, nil
+	case models.RuleFieldLinkState:
+		var states []string
+		for _, state := range ruleValues(rule) {
+			if condition, ok := linkStateConditions[state]; ok {
+				states = append(states, "("+condition+")")
+			}
+		}
+		if len(states) == 0 {
+			return "1 = 0", nil, nil
+		}
+		return "link <> '' AND (" + strings.Join(states, " OR ") + ")", nil, nil
+	case models.RuleFieldPerson:
+		sql := "id IN (SELECT c.song_id FROM credits c JOIN people p ON p.id = c.person_id WHERE LOWER(p.name) IN ?"
+		args := []any{lowerAll(personNames(ruleValues(rule)))}
+		if rule.Role != "" {
+			sql += " AND c.role = ?"
+			args = append(args, rule.Role)
+		}
+		return sql + ")", args, nil
+	}
+
+	switch rule.Op {
+	case models.RuleOpContains:
+		// Wildcards in the value are matched literally so every backend agrees.
+		pattern := "%" + likeEscaper.Replace(rule.Value) + "%"
+		if q.ilike {
+			return column + ` ILIKE ? ESCAPE '\'`, []any{pattern}, nil
+		}
+		return "LOWER(" + column + `) LIKE LOWER(?) ESCAPE '\'`, []any{pattern}, nil
+	case models.RuleOpEquals:
+		return column + " = ?", []any{rule.Value}, nil
+	case models.RuleOpIn:
+		return column + " IN ?", []any{rule.Values}, nil
+	case models.RuleOpBetween:
+		return column + " BETWEEN ? AND ?", []any{rule.Values[0], rule.Values[1]}, nil
+	}
+	return "", nil, fmt.Errorf("unknown rule op %q", rule.Op)
+}
+
+// ruleValues returns the values an equals or in condition accepts.
+func ruleValues(rule models.Rule) []string {
+	if rule.Op == models.RuleOpEquals {
+		return []string{rule.Value}
+	}
+	return rule.Values
+}
+
+func normalizeTags(tags []string) []string {
+	out := make([]string, len(tags))
+	for i, tag := range tags {
+		out[i] = models.NormalizeTag(tag)
+	}
+	return out
+}
+
+func personNames(names []string) []string {
+	out := make([]string, len(names))
+	for i, name := range names {
+		out[i] = models.NormalizePersonName(name)
+	}
+	return out
+}
+
+func lowerAll(values []string) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = strings.ToLower(v)
+	}
+	return out
+}
+
+// songFacts is what matchRule knows about a song: the song itself plus the
+// lower-cased names of its genres and all of their ancestors.
+type songFacts struct {
+	song   models.Song
+	genres []string
+}
+
+// matchRule evaluates a rule tree against a song in memory, mirroring
+// songQuery.compile.
+func matchRule(facts songFacts, rule models.Rule) bool {
+	switch {
+	case rule.All != nil:
+		for _, child := range rule.All {
+			if !matchRule(facts, child) {
+				return false
+			}
+		}
+		return true
+	case rule.Any != nil:
+		for _, child := range rule.Any {
+			if matchRule(facts, child) {
+				return true
+			}
+		}
+		return len(rule.Any) == 0
+	case rule.Not != nil:
+		return !matchRule(facts, *rule.Not)
+	}
+
+	song := facts.song
+	switch rule.Field {
+	case models.RuleFieldTag:
+		return slices.ContainsFunc(normalizeTags(ruleValues(rule)), func(tag string) bool {
+			return slices.Contains(song.Tags, tag)
+		})
+	case models.RuleFieldGenre:
+		return slices.ContainsFunc(lowerAll(ruleValues(rule)), func(genre string) bool {
+			return slices.Contains(facts.genres, genre)
+		})
+	case models.RuleFieldLinkState:
+		return slices.Contains(ruleValues(rule), song.LinkState())
+	case models.RuleFieldProvider:
+		return slices.ContainsFunc(song.Links, func(link models.SongLink) bool {
+			return slices.Contains(ruleValues(rule), link.Provider)
+		})
+	case models.RuleFieldPerson:
+		names := personNames(ruleValues(rule))
+		return slices.ContainsFunc(song.Credits, func(credit models.SongCredit) bool {
+			return (rule.Role == "" || credit.Role == rule.Role) &&
+				slices.ContainsFunc(names, func(name string) bool { return strings.EqualFold(name, credit.Person) })
+		})
+	}
+
+	var value string
+	switch rule.Field {
+	case models.RuleFieldGroup:
+		value = song.Group
+	case models.RuleFieldSong:
+		value = song.Name
+	case models.RuleFieldLink:
+		value = song.Link
+	case models.RuleFieldReleaseDate:
+		value = song.ReleaseDate
+	case models.RuleFieldReleaseYear:
+		if len(song.ReleaseDate) >= 4 {
+			value = song.ReleaseDate[len(song.ReleaseDate)-4:]
+		}
+	}
+
+	switch rule.Op {
+	case models.RuleOpContains:
+		return strings.Contains(strings.ToLower(value), strings.ToLower(rule.Value))
+	case models.RuleOpEquals:
+		return value == rule.Value
+	case models.RuleOpIn:
+		return slices.Contains(rule.Values, value)
+	case models.RuleOpBetween:
+		return rule.Values[0] <= value && value <= rule.Values[1]
+	}
+	return false
+}
@@ -1,16 +1,87 @@
 package repositories
 
 import (
+	"awesomeProject/links"
 	"awesomeProject/models"
+	"awesomeProject/tenant"
+	"awesomeProject/tracing"
+	"context"
+	"errors"
 	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
 )
 
+var ErrNoTenant = errors.New("no tenant in context")
+
+// Filters accepted by SongRepository.List. Text filters are
+// case-insensitive substring matches; releaseDate must match exactly. tags
+// is a comma-separated list matched according to tagMode, and genre also
+// matches songs in its sub-genres. person matches any credited person,
+// optionally only in role. provider matches songs with a link to that
+// provider, and linkState songs whose primary link was last found in that
+// state.
+const (
+	FilterGroup       = "group"
+	FilterSong        = "song"
+	FilterReleaseDate = "releaseDate"
+	FilterLink        = "link"
+	FilterTags        = "tags"
+	FilterTagMode     = "tagMode"
+	FilterGenre       = "genre"
+	FilterPerson      = "person"
+	FilterRole        = "role"
+	FilterProvider    = "provider"
+	FilterLinkState   = "linkState"
+)
+
+// Values of FilterTagMode: all requires every tag, any at least one.
+const (
+	TagModeAll = "all"
+	TagModeAny = "any"
+)
+
+var tracer = tracing.Tracer("repositories")
+
 type SongRepository interface {
-	List(page, limit int, filters map[string]string) ([]models.Song, int64, error)
-	GetByID(id string) (*models.Song, error)
-	Create(song *models.Song) error
-	Update(song *models.Song) error
-	Delete(id string) error
+	List(ctx context.Context, page, limit int, filters map[string]string) ([]models.Song, int64, error)
+	// Search returns the songs matching a rule tree, as used by smart playlists.
+	Search(ctx context.Context, rule models.Rule, page, limit int) ([]models.Song, int64, error)
+	GetByID(ctx context.Context, id string) (*models.Song, error)
+	Create(ctx context.Context, song *models.Song) error
+	Update(ctx context.Context, song *models.Song) error
+	Delete(ctx context.Context, id string) error
+}
+
+// Migrate creates or updates the tables behind the SQL repositories.
+func Migrate(db *gorm.DB) error {
+	err := db.AutoMigrate(
+		&models.Song{},
+		&models.Playlist{}, &models.PlaylistItem{},
+		&models.Tag{}, &models.SongTag{}, &models.Genre{}, &models.SongGenre{},
+		&models.Person{}, &models.Credit{},
+		&models.Favorite{}, &models.Rating{}, &models.Play{},
+		&models.QualityReview{}, &models.SongLink{}, &models.Attachment{},
+	)
+	if err != nil {
+		return err
+	}
+	return backfillLinks(db)
+}
+
+// backfillLinks records the link of songs saved before songs had several
+// links, so provider filters see them.
+func backfillLinks(db *gorm.DB) error {
+	var songs []models.Song
+	return db.Select("id, link").
+		Where("link <> '' AND id NOT IN (SELECT song_id FROM song_links)").
+		FindInBatches(&songs, 500, func(tx *gorm.DB, _ int) error {
+			rows := make([]models.SongLink, len(songs))
+			for i, song := range songs {
+				rows[i] = links.Recognise(song.Link)
+				rows[i].SongID, rows[i].Position = song.ID, 1
+			}
+			return db.Create(&rows).Error
+		}).Error
 }
 
 type SQLSongRepository struct {
@@ -21,50 +92,330 @@ func NewSQLSongRepository(db *gorm.DB) *SQLSongRepository {
 	return &SQLSongRepository{db: db}
 }
 
-func (r *SQLSongRepository) List(page, limit int, filters map[string]string) ([]models.Song, int64, error) {
-	var songs []models.Song
-	query := r.db.Model(&models.Song{})
+type primaryKey struct{}
+
+// ReadFromPrimary makes reads in ctx bypass the read replicas, so a caller
+// sees its own writes despite replication lag.
+func ReadFromPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+func readsFromPrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryKey{}).(bool)
+	return primary
+}
 
-	if group, ok := filters["group"]; ok && group != "" {
-		query = query.Where("\"group\" ILIKE ?", "%"+group+"%")
+// conn binds db to ctx, routing reads to the primary when ctx asks for it.
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	db = db.WithContext(ctx)
+	if readsFromPrimary(ctx) {
+		db = db.Clauses(dbresolver.Write)
 	}
-	if song, ok := filters["song"]; ok && song != "" {
-		query = query.Where("name ILIKE ?", "%"+song+"%")
+	return db
+}
+
+// scoped returns a gorm session restricted to the tenant carried by ctx.
+// Every tenant-owned query goes through it, so a missing tenant fails closed.
+func scoped(ctx context.Context, db *gorm.DB) (*gorm.DB, string, error) {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return nil, "", ErrNoTenant
 	}
-	if releaseDate, ok := filters["releaseDate"]; ok && releaseDate != "" {
-		query = query.Where("release_date = ?", releaseDate)
+	return conn(ctx, db).Where("tenant_id = ?", tenantID).Session(&gorm.Session{}), tenantID, nil
+}
+
+func (r *SQLSongRepository) session(ctx context.Context) (*gorm.DB, string, error) {
+	return scoped(ctx, r.db)
+}
+
+func (r *SQLSongRepository) List(ctx context.Context, page, limit int, filters map[string]string) (songs []models.Song, total int64, err error) {
+	ctx, span := tracer.Start(ctx, "SongRepository.List")
+	defer func() { tracing.End(span, err) }()
+
+	return r.find(ctx, filtersRule(filters), page, limit)
+}
+
+func (r *SQLSongRepository) Search(ctx context.Context, rule models.Rule, page, limit int) (songs []models.Song, total int64, err error) {
+	ctx, span := tracer.Start(ctx, "SongRepository.Search")
+	defer func() { tracing.End(span, err) }()
+
+	return r.find(ctx, rule, page, limit)
+}
+
+func (r *SQLSongRepository) find(ctx context.Context, rule models.Rule, page, limit int) (songs []models.Song, total int64, err error) {
+	db, _, err := r.session(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := db.Model(&models.Song{})
+	condition, args, err := songQuery{ilike: r.db.Dialector.Name() == "postgres"}.compile(rule)
+	if err != nil {
+		return nil, 0, err
 	}
-	if link, ok := filters["link"]; ok && link != "" {
-		query = query.Where("link ILIKE ?", "%"+link+"%")
+	if condition != "" {
+		query = query.Where(condition, args...)
 	}
 
-	var total int64
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	offset := (page - 1) * limit
-	err := query.Offset(offset).Limit(limit).Find(&songs).Error
-	return songs, total, err
+	if err := query.Order("id").Offset(offset).Limit(limit).Find(&songs).Error; err != nil {
+		return nil, 0, err
+	}
+	return songs, total, attachDetails(ctx, r.db, songs)
+}
+
+// attachDetails loads the links, tags, genres, credits and engagement stats
+// of songs, one query each.
+func attachDetails(ctx context.Context, db *gorm.DB, songs []models.Song) error {
+	if len(songs) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(songs))
+	index := make(map[uint]*models.Song, len(songs))
+	for i := range songs {
+		ids[i] = songs[i].ID
+		index[songs[i].ID] = &songs[i]
+	}
+
+	var songLinks []models.SongLink
+	err := conn(ctx, db).Where("song_id IN ?", ids).Order("song_id, position").Find(&songLinks).Error
+	if err != nil {
+		return err
+	}
+	for _, link := range songLinks {
+		song := index[link.SongID]
+		song.Links = append(song.Links, models.SongLink{URL: link.URL, Provider: link.Provider, ProviderID: link.ProviderID})
+	}
+
+	var rows []struct {
+		SongID uint
+		Name   string
+	}
+	err = conn(ctx, db).Table("song_tags").
+		Select("song_tags.song_id, tags.name").
+		Joins("JOIN tags ON tags.id = song_tags.tag_id").
+		Where("song_tags.song_id IN ?", ids).
+		Order("tags.name").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		index[row.SongID].Tags = append(index[row.SongID].Tags, row.Name)
+	}
+
+	rows = nil
+	err = conn(ctx, db).Table("song_genres").
+		Select("song_genres.song_id, genres.name").
+		Joins("JOIN genres ON genres.id = song_genres.genre_id").
+		Where("song_genres.song_id IN ?", ids).
+		Order("genres.name").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		index[row.SongID].Genres = append(index[row.SongID].Genres, row.Name)
+	}
+
+	var credits []struct {
+		SongID uint
+		Name   string
+		Role   string
+	}
+	err = conn(ctx, db).Table("credits").
+		Select("credits.song_id, people.name, credits.role").
+		Joins("JOIN people ON people.id = credits.person_id").
+		Where("credits.song_id IN ?", ids).
+		Order("credits.position").
+		Scan(&credits).Error
+	if err != nil {
+		return err
+	}
+	for _, credit := range credits {
+		song := index[credit.SongID]
+		song.Credits = append(song.Credits, models.SongCredit{Person: credit.Name, Role: credit.Role})
+	}
+	return attachStats(ctx, db, ids, index)
+}
+
+func attachStats(ctx context.Context, db *gorm.DB, ids []uint, index map[uint]*models.Song) error {
+	for _, song := range index {
+		song.Stats = &models.SongStats{}
+	}
+
+	var rows []struct {
+		SongID  uint
+		Count   int64
+		Average float64
+	}
+	for _, aggregate := range []struct {
+		table, average string
+		assign         func(stats *models.SongStats, count int64, average float64)
+	}{
+		{"favorites", "0", func(stats *models.SongStats, count int64, _ float64) { stats.Favorites = count }},
+		{"ratings", "AVG(stars)", func(stats *models.SongStats, count int64, average float64) {
+			stats.Ratings, stats.AverageRating = count, average
+		}},
+		{"plays", "0", func(stats *models.SongStats, count int64, _ float64) { stats.Plays = count }},
+	} {
+		rows = nil
+		err := conn(ctx, db).Table(aggregate.table).
+			Select("song_id, COUNT(*) AS count, "+aggregate.average+" AS average").
+			Where("song_id IN ?", ids).
+			Group("song_id").
+			Scan(&rows).Error
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			aggregate.assign(index[row.SongID].Stats, row.Count, row.Average)
+		}
+	}
+	return nil
 }
 
-func (r *SQLSongRepository) GetByID(id string) (*models.Song, error) {
+func (r *SQLSongRepository) GetByID(ctx context.Context, id string) (_ *models.Song, err error) {
+	ctx, span := tracer.Start(ctx, "SongRepository.GetByID")
+	defer func() { tracing.End(span, err) }()
+
+	db, _, err := r.session(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	var song models.Song
-	err := r.db.First(&song, id).Error
+	err = db.First(&song, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
-	return &song, nil
+	songs := []models.Song{song}
+	if err := attachDetails(ctx, r.db, songs); err != nil {
+		return nil, err
+	}
+	return &songs[0], nil
+}
+
+func (r *SQLSongRepository) Create(ctx context.Context, song *models.Song) (err error) {
+	ctx, span := tracer.Start(ctx, "SongRepository.Create")
+	defer func() { tracing.End(span, err) }()
+
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+
+	song.TenantID = tenantID
+	prepareLinks(song)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(song).Error; err != nil {
+			return err
+		}
+		return saveLinks(tx, song)
+	})
 }
 
-func (r *SQLSongRepository) Create(song *models.Song) error {
-	return r.db.Create(song).Error
+func (r *SQLSongRepository) Update(ctx context.Context, song *models.Song) (err error) {
+	ctx, span := tracer.Start(ctx, "SongRepository.Update")
+	defer func() { tracing.End(span, err) }()
+
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+
+	// Save would fall back to an upsert when no row matches, which could
+	// overwrite another tenant's song; update the scoped row explicitly.
+	song.TenantID = tenantID
+	prepareLinks(song)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var stored models.Song
+		err := tx.Select("link, link_status, link_checked_at").Where("id = ? AND tenant_id = ?", song.ID, tenantID).Take(&stored).Error
+		if err != nil {
+			return err
+		}
+		keepLinkCheck(song, stored)
+
+		result := tx.Model(song).Where("tenant_id = ?", tenantID).Select("*").Updates(song)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return saveLinks(tx, song)
+	})
 }
 
-func (r *SQLSongRepository) Update(song *models.Song) error {
-	return r.db.Save(song).Error
+// keepLinkCheck carries the stored link check over to song, unless song has a
+// new link that is yet to be checked.
+func keepLinkCheck(song *models.Song, stored models.Song) {
+	song.LinkStatus, song.LinkCheckedAt = 0, nil
+	if song.Link == stored.Link {
+		song.LinkStatus, song.LinkCheckedAt = stored.LinkStatus, stored.LinkCheckedAt
+	}
 }
 
-func (r *SQLSongRepository) Delete(id string) error {
-	return r.db.Delete(&models.Song{}, id).Error
+// prepareLinks puts song.Link first in song.Links, or makes the first of
+// song.Links the primary link when song.Link is empty.
+func prepareLinks(song *models.Song) {
+	song.Links = links.Merge(song.Link, song.Links)
+	if song.Link == "" && len(song.Links) > 0 {
+		song.Link = song.Links[0].URL
+	}
+}
+
+// saveLinks replaces the stored links of song with song.Links.
+func saveLinks(tx *gorm.DB, song *models.Song) error {
+	if err := tx.Where("song_id = ?", song.ID).Delete(&models.SongLink{}).Error; err != nil {
+		return err
+	}
+	if len(song.Links) == 0 {
+		return nil
+	}
+	rows := make([]models.SongLink, len(song.Links))
+	for i, link := range song.Links {
+		link.SongID, link.Position = song.ID, i+1
+		rows[i] = link
+	}
+	return tx.Create(&rows).Error
+}
+
+func (r *SQLSongRepository) Delete(ctx context.Context, id string) (err error) {
+	ctx, span := tracer.Start(ctx, "SongRepository.Delete")
+	defer func() { tracing.End(span, err) }()
+
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+
+	// Drop the song from every playlist and classification in the same
+	// transaction so nothing keeps pointing at it.
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("tenant_id = ?", tenantID).Delete(&models.Song{}, "id = ?", id)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		err := tx.Model(&models.PlaylistItem{}).
+			Where("EXISTS (SELECT 1 FROM playlist_items removed WHERE removed.song_id = ? AND removed.playlist_id = playlist_items.playlist_id AND removed.position < playlist_items.position)", id).
+			Update("position", gorm.Expr("position - 1")).Error
+		if err != nil {
+			return err
+		}
+		for _, association := range []any{
+			&models.PlaylistItem{}, &models.SongTag{}, &models.SongGenre{}, &models.Credit{},
+			&models.Favorite{}, &models.Rating{}, &models.Play{}, &models.QualityReview{}, &models.SongLink{},
+			&models.Attachment{},
+		} {
+			if err := tx.Where("song_id = ?", id).Delete(association).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
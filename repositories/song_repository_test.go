@@ -0,0 +1,161 @@
+package repositories
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/sqlitedb"
+	"awesomeProject/tenant"
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+	"testing"
+)
+
+func setupSQLiteDB(t *testing.T) *gorm.DB {
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlitedb.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, Migrate(db))
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	// Shared-cache connections fail with "table is locked" instead of
+	// waiting, so serialise them as a single SQLite file would.
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+func TestSQLSongRepository_TenantIsolation(t *testing.T) {
+	repo := NewSQLSongRepository(setupSQLiteDB(t))
+
+	acme := tenant.NewContext(context.Background(), "acme")
+	globex := tenant.NewContext(context.Background(), "globex")
+
+	acmeSong := &models.Song{Group: "Muse", Name: "Uprising"}
+	require.NoError(t, repo.Create(acme, acmeSong))
+	globexSong := &models.Song{Group: "Queen", Name: "Bohemian Rhapsody"}
+	require.NoError(t, repo.Create(globex, globexSong))
+
+	acmeID := fmt.Sprint(acmeSong.ID)
+	globexID := fmt.Sprint(globexSong.ID)
+
+	t.Run("List only returns own songs", func(t *testing.T) {
+		songs, total, err := repo.List(acme, 1, 10, map[string]string{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		require.Len(t, songs, 1)
+		assert.Equal(t, "Uprising", songs[0].Name)
+	})
+
+	t.Run("GetByID cannot read another tenant's song", func(t *testing.T) {
+		_, err := repo.GetByID(acme, globexID)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+		song, err := repo.GetByID(globex, globexID)
+		require.NoError(t, err)
+		assert.Equal(t, "globex", song.TenantID)
+	})
+
+	t.Run("Update cannot modify another tenant's song", func(t *testing.T) {
+		hijack := &models.Song{ID: globexSong.ID, Group: "Hijacked", Name: "Hijacked"}
+		assert.ErrorIs(t, repo.Update(acme, hijack), gorm.ErrRecordNotFound)
+
+		song, err := repo.GetByID(globex, globexID)
+		require.NoError(t, err)
+		assert.Equal(t, "Queen", song.Group)
+		assert.Equal(t, "globex", song.TenantID)
+	})
+
+	t.Run("Update keeps the song in its tenant", func(t *testing.T) {
+		song, err := repo.GetByID(acme, acmeID)
+		require.NoError(t, err)
+		song.Name = "Starlight"
+		song.TenantID = "globex"
+		require.NoError(t, repo.Update(acme, song))
+
+		_, err = repo.GetByID(globex, acmeID)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		song, err = repo.GetByID(acme, acmeID)
+		require.NoError(t, err)
+		assert.Equal(t, "Starlight", song.Name)
+	})
+
+	t.Run("Delete cannot remove another tenant's song", func(t *testing.T) {
+		require.NoError(t, repo.Delete(acme, globexID))
+
+		_, err := repo.GetByID(globex, globexID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Missing tenant fails closed", func(t *testing.T) {
+		_, _, err := repo.List(context.Background(), 1, 10, map[string]string{})
+		assert.ErrorIs(t, err, ErrNoTenant)
+		assert.ErrorIs(t, repo.Create(context.Background(), &models.Song{Group: "A", Name: "B"}), ErrNoTenant)
+	})
+}
+
+func TestSQLSongRepository_ReadReplicas(t *testing.T) {
+	primary := setupSQLiteDB(t)
+
+	replicaDSN := fmt.Sprintf("file:%s_replica?mode=memory&cache=shared", t.Name())
+	replica, err := gorm.Open(sqlitedb.Open(replicaDSN), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, replica.AutoMigrate(&models.Song{}))
+	replicaSQL, err := replica.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { replicaSQL.Close() })
+
+	require.NoError(t, primary.Use(dbresolver.Register(dbresolver.Config{
+		Replicas: []gorm.Dialector{sqlitedb.Open(replicaDSN)},
+	})))
+
+	repo := NewSQLSongRepository(primary)
+	ctx := tenant.NewContext(context.Background(), tenant.Default)
+
+	song := &models.Song{Group: "Muse", Name: "Uprising"}
+	require.NoError(t, repo.Create(ctx, song))
+	id := fmt.Sprint(song.ID)
+
+	t.Run("reads go to the replica", func(t *testing.T) {
+		_, err := repo.GetByID(ctx, id)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+		_, total, err := repo.List(ctx, 1, 10, map[string]string{})
+		require.NoError(t, err)
+		assert.Zero(t, total)
+	})
+
+	t.Run("ReadFromPrimary bypasses the replica", func(t *testing.T) {
+		got, err := repo.GetByID(ReadFromPrimary(ctx), id)
+		require.NoError(t, err)
+		assert.Equal(t, "Uprising", got.Name)
+
+		_, total, err := repo.List(ReadFromPrimary(ctx), 1, 10, map[string]string{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+	})
+}
+
+func TestMigrate_BackfillsLinks(t *testing.T) {
+	db := setupSQLiteDB(t)
+	ctx := tenant.NewContext(context.Background(), tenant.Default)
+
+	legacy := models.Song{Group: "Muse", Name: "Uprising", Link: "https://youtu.be/dQw4w9WgXcQ", TenantID: tenant.Default}
+	require.NoError(t, db.Create(&legacy).Error)
+	require.NoError(t, db.Create(&models.Song{Group: "Queen", Name: "Unlinked", TenantID: tenant.Default}).Error)
+
+	require.NoError(t, Migrate(db))
+	require.NoError(t, Migrate(db), "backfilling twice changes nothing")
+
+	repo := NewSQLSongRepository(db)
+	got, err := repo.GetByID(ctx, fmt.Sprint(legacy.ID))
+	require.NoError(t, err)
+	assert.Equal(t, []models.SongLink{{URL: legacy.Link, Provider: models.ProviderYouTube, ProviderID: "dQw4w9WgXcQ"}}, got.Links)
+
+	_, total, err := repo.List(ctx, 1, 10, map[string]string{FilterProvider: models.ProviderYouTube})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+}
@@ -0,0 +1,193 @@
+package repositories
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/tenant"
+	"awesomeProject/tracing"
+	"cmp"
+	"context"
+	"gorm.io/gorm"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// statsTopArtists caps LibraryStats.ByArtist.
+const statsTopArtists = 50
+
+type StatsRepository interface {
+	LibraryStats(ctx context.Context) (*models.LibraryStats, error)
+}
+
+// finishStats derives the fields both backends compute the same way:
+// years are filtered to four digits and bucketed into decades, and coverage
+// is the complete share of all songs.
+func finishStats(stats *models.LibraryStats, years []models.StatsCount, complete int64) {
+	stats.ByYear = []models.StatsCount{}
+	decades := make(map[string]int64)
+	for _, year := range years {
+		n, err := strconv.Atoi(year.Key)
+		if err != nil || len(year.Key) != 4 {
+			continue
+		}
+		stats.ByYear = append(stats.ByYear, year)
+		decades[strconv.Itoa(n/10*10)+"s"] += year.Count
+	}
+	slices.SortFunc(stats.ByYear, func(a, b models.StatsCount) int { return cmp.Compare(a.Key, b.Key) })
+
+	stats.ByDecade = []models.StatsCount{}
+	for decade, count := range decades {
+		stats.ByDecade = append(stats.ByDecade, models.StatsCount{Key: decade, Count: count})
+	}
+	slices.SortFunc(stats.ByDecade, func(a, b models.StatsCount) int { return cmp.Compare(a.Key, b.Key) })
+
+	if stats.ByArtist == nil {
+		stats.ByArtist = []models.StatsCount{}
+	}
+	if stats.Songs > 0 {
+		stats.EnrichmentCoverage = float64(complete) / float64(stats.Songs)
+	}
+	stats.GeneratedAt = time.Now().UTC()
+}
+
+type SQLStatsRepository struct {
+	db *gorm.DB
+}
+
+func NewSQLStatsRepository(db *gorm.DB) *SQLStatsRepository {
+	return &SQLStatsRepository{db: db}
+}
+
+func (r *SQLStatsRepository) LibraryStats(ctx context.Context) (stats *models.LibraryStats, err error) {
+	ctx, span := tracer.Start(ctx, "StatsRepository.LibraryStats")
+	defer func() { tracing.End(span, err) }()
+
+	db, _, err := scoped(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+
+	var totals struct {
+		Songs              int64
+		MissingText        int64
+		MissingLink        int64
+		MissingReleaseDate int64
+		Complete           int64
+		AverageTextLength  float64
+	}
+	err = db.Model(&models.Song{}).Select(
+		"COUNT(*) AS songs, " +
+			"COALESCE(SUM(CASE WHEN text = '' THEN 1 ELSE 0 END), 0) AS missing_text, " +
+			"COALESCE(SUM(CASE WHEN link = '' THEN 1 ELSE 0 END), 0) AS missing_link, " +
+			"COALESCE(SUM(CASE WHEN release_date = '' THEN 1 ELSE 0 END), 0) AS missing_release_date, " +
+			"COALESCE(SUM(CASE WHEN text <> '' AND link <> '' AND release_date <> '' THEN 1 ELSE 0 END), 0) AS complete, " +
+			"COALESCE(AVG(CASE WHEN text <> '' THEN LENGTH(text) END), 0) AS average_text_length",
+	).Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+
+	stats = &models.LibraryStats{
+		Songs:             totals.Songs,
+		Missing:           models.MissingCounts{Text: totals.MissingText, Link: totals.MissingLink, ReleaseDate: totals.MissingReleaseDate},
+		AverageTextLength: totals.AverageTextLength,
+	}
+
+	// Qualified so gorm's Group keeps the quoted reserved word as written.
+	artist := "songs." + songColumns[models.RuleFieldGroup]
+	err = db.Model(&models.Song{}).
+		Select(artist + " AS key, COUNT(*) AS count").
+		Group(artist).
+		Order("count DESC, " + artist).
+		Limit(statsTopArtists).
+		Scan(&stats.ByArtist).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var years []models.StatsCount
+	year := songColumns[models.RuleFieldReleaseYear]
+	err = db.Model(&models.Song{}).
+		Select(year + " AS key, COUNT(*) AS count").
+		Where("release_date <> ''").
+		Group(year).
+		Scan(&years).Error
+	if err != nil {
+		return nil, err
+	}
+
+	finishStats(stats, years, totals.Complete)
+	return stats, nil
+}
+
+// CachedStatsRepository serves each tenant's stats from memory for ttl,
+// recomputing them at most once at a time per tenant.
+type CachedStatsRepository struct {
+	repo StatsRepository
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*statsEntry
+	swept   time.Time
+}
+
+type statsEntry struct {
+	mu      sync.Mutex
+	stats   *models.LibraryStats
+	expires time.Time
+}
+
+func NewCachedStatsRepository(repo StatsRepository, ttl time.Duration) *CachedStatsRepository {
+	return &CachedStatsRepository{repo: repo, ttl: ttl, now: time.Now, entries: make(map[string]*statsEntry)}
+}
+
+func (r *CachedStatsRepository) LibraryStats(ctx context.Context) (*models.LibraryStats, error) {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return nil, ErrNoTenant
+	}
+
+	r.mu.Lock()
+	entry, ok := r.entries[tenantID]
+	if !ok {
+		r.sweep()
+		entry = &statsEntry{}
+		r.entries[tenantID] = entry
+	}
+	r.mu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if entry.stats != nil && r.now().Before(entry.expires) {
+		return entry.stats, nil
+	}
+
+	stats, err := r.repo.LibraryStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entry.stats, entry.expires = stats, r.now().Add(r.ttl)
+	return stats, nil
+}
+
+// sweep drops expired entries, at most once per ttl, so tenant IDs that are
+// never asked for again do not pile up. Entries in use are kept. r.mu must
+// be held.
+func (r *CachedStatsRepository) sweep() {
+	now := r.now()
+	if now.Sub(r.swept) < r.ttl {
+		return
+	}
+	r.swept = now
+	for tenantID, entry := range r.entries {
+		if !entry.mu.TryLock() {
+			continue
+		}
+		if entry.stats == nil || !now.Before(entry.expires) {
+			delete(r.entries, tenantID)
+		}
+		entry.mu.Unlock()
+	}
+}
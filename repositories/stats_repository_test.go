@@ -0,0 +1,135 @@
+package repositories
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/tenant"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func testStatsRepository(t *testing.T, newRepos func(t *testing.T) (SongRepository, StatsRepository)) {
+	ctx := tenant.NewContext(context.Background(), tenant.Default)
+
+	t.Run("empty library", func(t *testing.T) {
+		_, repo := newRepos(t)
+		stats, err := repo.LibraryStats(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, stats.Songs)
+		assert.Empty(t, stats.ByArtist)
+		assert.NotNil(t, stats.ByYear)
+		assert.Zero(t, stats.EnrichmentCoverage)
+	})
+
+	t.Run("aggregates", func(t *testing.T) {
+		songs, repo := newRepos(t)
+		for _, song := range []models.Song{
+			{Group: "Muse", Name: "Uprising", ReleaseDate: "16.07.2009", Text: "abcd", Link: "https://example.com/1"},
+			{Group: "Muse", Name: "Starlight", ReleaseDate: "04.09.2006", Text: "ab"},
+			{Group: "Queen", Name: "Bicycle", ReleaseDate: "13.10.1978", Link: "https://example.com/2"},
+			{Group: "Muse", Name: "Madness", ReleaseDate: "20.08.2012", Text: "ñññ", Link: "https://example.com/3"},
+			{Group: "ABBA", Name: "Unknown", ReleaseDate: "soon"},
+			{Group: "Queen", Name: "Undated"},
+		} {
+			require.NoError(t, songs.Create(ctx, &song))
+		}
+		other := tenant.NewContext(context.Background(), "other")
+		require.NoError(t, songs.Create(other, &models.Song{Group: "Muse", Name: "Elsewhere"}))
+
+		stats, err := repo.LibraryStats(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(6), stats.Songs)
+		assert.Equal(t, []models.StatsCount{{Key: "Muse", Count: 3}, {Key: "Queen", Count: 2}, {Key: "ABBA", Count: 1}}, stats.ByArtist)
+		assert.Equal(t, []models.StatsCount{{Key: "1978", Count: 1}, {Key: "2006", Count: 1}, {Key: "2009", Count: 1}, {Key: "2012", Count: 1}}, stats.ByYear)
+		assert.Equal(t, []models.StatsCount{{Key: "1970s", Count: 1}, {Key: "2000s", Count: 2}, {Key: "2010s", Count: 1}}, stats.ByDecade)
+		assert.Equal(t, models.MissingCounts{Text: 3, Link: 3, ReleaseDate: 1}, stats.Missing)
+		assert.InDelta(t, 3.0, stats.AverageTextLength, 0.001, "length counts characters, not bytes")
+		assert.InDelta(t, 2.0/6.0, stats.EnrichmentCoverage, 0.001)
+		assert.False(t, stats.GeneratedAt.IsZero())
+	})
+
+	t.Run("requires a tenant", func(t *testing.T) {
+		_, repo := newRepos(t)
+		_, err := repo.LibraryStats(context.Background())
+		assert.ErrorIs(t, err, ErrNoTenant)
+	})
+}
+
+func TestSQLStatsRepository_SQLite(t *testing.T) {
+	testStatsRepository(t, func(t *testing.T) (SongRepository, StatsRepository) {
+		db := setupSQLiteDB(t)
+		return NewSQLSongRepository(db), NewSQLStatsRepository(db)
+	})
+}
+
+func TestMemoryStatsRepository(t *testing.T) {
+	testStatsRepository(t, func(t *testing.T) (SongRepository, StatsRepository) {
+		songs := NewMemorySongRepository()
+		return songs, NewMemoryStatsRepository(songs)
+	})
+}
+
+type countingStats struct {
+	calls int
+	err   error
+}
+
+func (s *countingStats) LibraryStats(ctx context.Context) (*models.LibraryStats, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &models.LibraryStats{Songs: int64(s.calls)}, nil
+}
+
+func TestCachedStatsRepository(t *testing.T) {
+	ctx := tenant.NewContext(context.Background(), tenant.Default)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	inner := &countingStats{}
+	repo := NewCachedStatsRepository(inner, time.Minute)
+	repo.now = func() time.Time { return now }
+
+	stats, err := repo.LibraryStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats.Songs)
+
+	now = now.Add(59 * time.Second)
+	stats, err = repo.LibraryStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats.Songs, "served from cache within the TTL")
+
+	_, err = repo.LibraryStats(tenant.NewContext(context.Background(), "other"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, inner.calls, "tenants are cached separately")
+
+	now = now.Add(time.Second)
+	stats, err = repo.LibraryStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), stats.Songs, "recomputed once expired")
+
+	now = now.Add(time.Minute)
+	inner.err = errors.New("db down")
+	_, err = repo.LibraryStats(ctx)
+	assert.Error(t, err, "errors are not cached over stale stats")
+}
+
+func TestCachedStatsRepository_EvictsExpiredTenants(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := NewCachedStatsRepository(&countingStats{}, time.Minute)
+	repo.now = func() time.Time { return now }
+
+	for i := 0; i < 100; i++ {
+		_, err := repo.LibraryStats(tenant.NewContext(context.Background(), fmt.Sprint("tenant-", i)))
+		require.NoError(t, err)
+	}
+	assert.Len(t, repo.entries, 100)
+
+	now = now.Add(time.Minute)
+	_, err := repo.LibraryStats(tenant.NewContext(context.Background(), "late"))
+	require.NoError(t, err)
+	assert.Len(t, repo.entries, 1, "expired tenants are dropped")
+}
@@ -0,0 +1,268 @@
+package repositories
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/tenant"
+	"awesomeProject/tracing"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"slices"
+)
+
+var (
+	ErrGenreNotFound    = errors.New("genre not found")
+	ErrGenreExists      = errors.New("genre already exists")
+	ErrGenreHasChildren = errors.New("genre has sub-genres")
+)
+
+// TaxonomyRepository manages free-form tags and the curated genre tree.
+// Bulk operations are atomic: either every song is changed or none is.
+type TaxonomyRepository interface {
+	// TagCounts lists tags in use with the number of songs carrying each.
+	TagCounts(ctx context.Context) ([]models.TagCount, error)
+	TagSongs(ctx context.Context, songIDs []uint, tags []string) error
+	UntagSongs(ctx context.Context, songIDs []uint, tags []string) error
+	Genres(ctx context.Context) ([]models.Genre, error)
+	CreateGenre(ctx context.Context, genre *models.Genre) error
+	DeleteGenre(ctx context.Context, id uint) error
+	SetSongGenres(ctx context.Context, songID uint, genreIDs []uint) error
+}
+
+// uniqueTags normalises tags and drops blanks and duplicates.
+func uniqueTags(tags []string) []string {
+	var out []string
+	for _, tag := range tags {
+		if tag = models.NormalizeTag(tag); tag != "" && !slices.Contains(out, tag) {
+			out = append(out, tag)
+		}
+	}
+	return out
+}
+
+func uniqueIDs(ids []uint) []uint {
+	out := slices.Clone(ids)
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+type SQLTaxonomyRepository struct {
+	db *gorm.DB
+}
+
+func NewSQLTaxonomyRepository(db *gorm.DB) *SQLTaxonomyRepository {
+	return &SQLTaxonomyRepository{db: db}
+}
+
+func (r *SQLTaxonomyRepository) TagCounts(ctx context.Context) (counts []models.TagCount, err error) {
+	ctx, span := tracer.Start(ctx, "TaxonomyRepository.TagCounts")
+	defer func() { tracing.End(span, err) }()
+
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return nil, ErrNoTenant
+	}
+
+	err = conn(ctx, r.db).Table("tags").
+		Select("tags.name, COUNT(*) AS count").
+		Joins("JOIN song_tags ON song_tags.tag_id = tags.id").
+		Where("tags.tenant_id = ?", tenantID).
+		Group("tags.name").
+		Order("count DESC, tags.name").
+		Scan(&counts).Error
+	return counts, err
+}
+
+func (r *SQLTaxonomyRepository) TagSongs(ctx context.Context, songIDs []uint, tags []string) (err error) {
+	ctx, span := tracer.Start(ctx, "TaxonomyRepository.TagSongs")
+	defer func() { tracing.End(span, err) }()
+
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+	songIDs, tags = uniqueIDs(songIDs), uniqueTags(tags)
+	if len(songIDs) == 0 || len(tags) == 0 {
+		return nil
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := requireSongs(tx, tenantID, songIDs); err != nil {
+			return err
+		}
+
+		rows := make([]models.Tag, len(tags))
+		for i, name := range tags {
+			rows[i] = models.Tag{Name: name, TenantID: tenantID}
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error; err != nil {
+			return err
+		}
+		var tagIDs []uint
+		if err := tx.Model(&models.Tag{}).Where("tenant_id = ? AND name IN ?", tenantID, tags).Pluck("id", &tagIDs).Error; err != nil {
+			return err
+		}
+
+		links := make([]models.SongTag, 0, len(songIDs)*len(tagIDs))
+		for _, songID := range songIDs {
+			for _, tagID := range tagIDs {
+				links = append(links, models.SongTag{SongID: songID, TagID: tagID})
+			}
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&links).Error
+	})
+}
+
+func (r *SQLTaxonomyRepository) UntagSongs(ctx context.Context, songIDs []uint, tags []string) (err error) {
+	ctx, span := tracer.Start(ctx, "TaxonomyRepository.UntagSongs")
+	defer func() { tracing.End(span, err) }()
+
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+	songIDs, tags = uniqueIDs(songIDs), uniqueTags(tags)
+	if len(songIDs) == 0 || len(tags) == 0 {
+		return nil
+	}
+
+	// Tags are tenant-scoped, so songs of other tenants cannot carry them.
+	return r.db.WithContext(ctx).
+		Where("song_id IN ?", songIDs).
+		Where("tag_id IN (?)", r.db.Model(&models.Tag{}).Select("id").Where("tenant_id = ? AND name IN ?", tenantID, tags)).
+		Delete(&models.SongTag{}).Error
+}
+
+func (r *SQLTaxonomyRepository) Genres(ctx context.Context) (genres []models.Genre, err error) {
+	ctx, span := tracer.Start(ctx, "TaxonomyRepository.Genres")
+	defer func() { tracing.End(span, err) }()
+
+	db, _, err := scoped(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	err = db.Order("name").Find(&genres).Error
+	return genres, err
+}
+
+func (r *SQLTaxonomyRepository) CreateGenre(ctx context.Context, genre *models.Genre) (err error) {
+	ctx, span := tracer.Start(ctx, "TaxonomyRepository.CreateGenre")
+	defer func() { tracing.End(span, err) }()
+
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if genre.ParentID != nil {
+			if err := requireGenres(tx, tenantID, []uint{*genre.ParentID}); err != nil {
+				return err
+			}
+		}
+
+		var count int64
+		if err := tx.Model(&models.Genre{}).Where("tenant_id = ? AND LOWER(name) = LOWER(?)", tenantID, genre.Name).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrGenreExists
+		}
+
+		genre.TenantID = tenantID
+		return tx.Create(genre).Error
+	})
+}
+
+func (r *SQLTaxonomyRepository) DeleteGenre(ctx context.Context, id uint) (err error) {
+	ctx, span := tracer.Start(ctx, "TaxonomyRepository.DeleteGenre")
+	defer func() { tracing.End(span, err) }()
+
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := requireGenres(tx, tenantID, []uint{id}); errors.Is(err, ErrGenreNotFound) {
+			return gorm.ErrRecordNotFound
+		} else if err != nil {
+			return err
+		}
+
+		var children int64
+		if err := tx.Model(&models.Genre{}).Where("parent_id = ?", id).Count(&children).Error; err != nil {
+			return err
+		}
+		if children > 0 {
+			return ErrGenreHasChildren
+		}
+
+		if err := tx.Where("genre_id = ?", id).Delete(&models.SongGenre{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Genre{}, "id = ?", id).Error
+	})
+}
+
+func (r *SQLTaxonomyRepository) SetSongGenres(ctx context.Context, songID uint, genreIDs []uint) (err error) {
+	ctx, span := tracer.Start(ctx, "TaxonomyRepository.SetSongGenres")
+	defer func() { tracing.End(span, err) }()
+
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+	genreIDs = uniqueIDs(genreIDs)
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := requireSongs(tx, tenantID, []uint{songID}); err != nil {
+			return err
+		}
+		if err := requireGenres(tx, tenantID, genreIDs); err != nil {
+			return err
+		}
+
+		if err := tx.Where("song_id = ?", songID).Delete(&models.SongGenre{}).Error; err != nil {
+			return err
+		}
+		if len(genreIDs) == 0 {
+			return nil
+		}
+		links := make([]models.SongGenre, len(genreIDs))
+		for i, genreID := range genreIDs {
+			links[i] = models.SongGenre{SongID: songID, GenreID: genreID}
+		}
+		return tx.Create(&links).Error
+	})
+}
+
+// requireSongs fails with ErrSongNotFound unless every id is a song of the
+// tenant. ids must be unique.
+func requireSongs(tx *gorm.DB, tenantID string, ids []uint) error {
+	var count int64
+	if err := tx.Model(&models.Song{}).Where("tenant_id = ? AND id IN ?", tenantID, ids).Count(&count).Error; err != nil {
+		return err
+	}
+	if count != int64(len(ids)) {
+		return ErrSongNotFound
+	}
+	return nil
+}
+
+// requireGenres fails with ErrGenreNotFound unless every id is a genre of
+// the tenant. ids must be unique.
+func requireGenres(tx *gorm.DB, tenantID string, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	var count int64
+	if err := tx.Model(&models.Genre{}).Where("tenant_id = ? AND id IN ?", tenantID, ids).Count(&count).Error; err != nil {
+		return err
+	}
+	if count != int64(len(ids)) {
+		return ErrGenreNotFound
+	}
+	return nil
+}
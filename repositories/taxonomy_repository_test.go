@@ -0,0 +1,182 @@
+package repositories
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/tenant"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"testing"
+)
+
+func testTaxonomyRepository(t *testing.T, newRepos func(t *testing.T) (SongRepository, TaxonomyRepository)) {
+	ctx := tenant.NewContext(context.Background(), tenant.Default)
+
+	setup := func(t *testing.T) (SongRepository, TaxonomyRepository, []uint) {
+		songs, taxonomy := newRepos(t)
+		var ids []uint
+		for _, name := range []string{"One", "Two", "Three"} {
+			song := models.Song{Group: "Band", Name: name}
+			require.NoError(t, songs.Create(ctx, &song))
+			ids = append(ids, song.ID)
+		}
+		return songs, taxonomy, ids
+	}
+
+	names := func(songs []models.Song) []string {
+		var out []string
+		for _, song := range songs {
+			out = append(out, song.Name)
+		}
+		return out
+	}
+
+	t.Run("TagSongs, TagCounts and UntagSongs", func(t *testing.T) {
+		songs, taxonomy, ids := setup(t)
+		require.NoError(t, taxonomy.TagSongs(ctx, ids[:2], []string{" Live  Rock", "acoustic"}))
+		require.NoError(t, taxonomy.TagSongs(ctx, ids[:1], []string{"live rock", "Demo"}), "re-tagging is idempotent")
+
+		counts, err := taxonomy.TagCounts(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []models.TagCount{{Name: "acoustic", Count: 2}, {Name: "live rock", Count: 2}, {Name: "demo", Count: 1}}, counts)
+
+		song, err := songs.GetByID(ctx, "1")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"acoustic", "demo", "live rock"}, song.Tags)
+
+		require.NoError(t, taxonomy.UntagSongs(ctx, ids, []string{"ACOUSTIC", "demo"}))
+		counts, err = taxonomy.TagCounts(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []models.TagCount{{Name: "live rock", Count: 2}}, counts)
+	})
+
+	t.Run("TagSongs is all or nothing", func(t *testing.T) {
+		_, taxonomy, ids := setup(t)
+		err := taxonomy.TagSongs(ctx, []uint{ids[0], 999}, []string{"rock"})
+		assert.ErrorIs(t, err, ErrSongNotFound)
+
+		counts, err := taxonomy.TagCounts(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, counts)
+	})
+
+	t.Run("List filters by tags", func(t *testing.T) {
+		songs, taxonomy, ids := setup(t)
+		require.NoError(t, taxonomy.TagSongs(ctx, ids[:2], []string{"rock"}))
+		require.NoError(t, taxonomy.TagSongs(ctx, ids[1:], []string{"live"}))
+
+		for _, tc := range []struct {
+			name    string
+			filters map[string]string
+			want    []string
+		}{
+			{"all by default", map[string]string{FilterTags: "rock, Live"}, []string{"Two"}},
+			{"any", map[string]string{FilterTags: "rock,live", FilterTagMode: TagModeAny}, []string{"One", "Two", "Three"}},
+			{"unknown tag", map[string]string{FilterTags: "jazz"}, nil},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				got, total, err := songs.List(ctx, 1, 10, tc.filters)
+				require.NoError(t, err)
+				assert.Equal(t, tc.want, names(got))
+				assert.Equal(t, int64(len(tc.want)), total)
+			})
+		}
+	})
+
+	t.Run("genres form a tree", func(t *testing.T) {
+		songs, taxonomy, ids := setup(t)
+		rock := &models.Genre{Name: "Rock"}
+		require.NoError(t, taxonomy.CreateGenre(ctx, rock))
+		punk := &models.Genre{Name: "Punk", ParentID: &rock.ID}
+		require.NoError(t, taxonomy.CreateGenre(ctx, punk))
+		jazz := &models.Genre{Name: "Jazz"}
+		require.NoError(t, taxonomy.CreateGenre(ctx, jazz))
+
+		assert.ErrorIs(t, taxonomy.CreateGenre(ctx, &models.Genre{Name: "rock"}), ErrGenreExists)
+		missing := uint(999)
+		assert.ErrorIs(t, taxonomy.CreateGenre(ctx, &models.Genre{Name: "Ska", ParentID: &missing}), ErrGenreNotFound)
+
+		genres, err := taxonomy.Genres(ctx)
+		require.NoError(t, err)
+		require.Len(t, genres, 3)
+		assert.Equal(t, "Jazz", genres[0].Name)
+
+		require.NoError(t, taxonomy.SetSongGenres(ctx, ids[0], []uint{rock.ID}))
+		require.NoError(t, taxonomy.SetSongGenres(ctx, ids[1], []uint{punk.ID, jazz.ID}))
+		require.NoError(t, taxonomy.SetSongGenres(ctx, ids[2], []uint{jazz.ID}))
+		require.NoError(t, taxonomy.SetSongGenres(ctx, ids[2], []uint{}), "setting replaces previous genres")
+
+		got, _, err := songs.List(ctx, 1, 10, map[string]string{FilterGenre: "rock"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"One", "Two"}, names(got), "sub-genres count towards their parent")
+		assert.Equal(t, []string{"Jazz", "Punk"}, got[1].Genres)
+
+		got, _, err = songs.List(ctx, 1, 10, map[string]string{FilterGenre: "Punk"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Two"}, names(got))
+
+		assert.ErrorIs(t, taxonomy.SetSongGenres(ctx, ids[0], []uint{999}), ErrGenreNotFound)
+		assert.ErrorIs(t, taxonomy.SetSongGenres(ctx, 999, []uint{rock.ID}), ErrSongNotFound)
+
+		assert.ErrorIs(t, taxonomy.DeleteGenre(ctx, rock.ID), ErrGenreHasChildren)
+		assert.ErrorIs(t, taxonomy.DeleteGenre(ctx, 999), gorm.ErrRecordNotFound)
+		require.NoError(t, taxonomy.DeleteGenre(ctx, punk.ID))
+		song, err := songs.GetByID(ctx, "2")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Jazz"}, song.Genres)
+	})
+
+	t.Run("genre names ignore case beyond ASCII", func(t *testing.T) {
+		songs, taxonomy, ids := setup(t)
+		pop := &models.Genre{Name: "Эстрада"}
+		require.NoError(t, taxonomy.CreateGenre(ctx, pop))
+		assert.ErrorIs(t, taxonomy.CreateGenre(ctx, &models.Genre{Name: "ЭСТРАДА"}), ErrGenreExists)
+
+		require.NoError(t, taxonomy.SetSongGenres(ctx, ids[0], []uint{pop.ID}))
+		got, _, err := songs.List(ctx, 1, 10, map[string]string{FilterGenre: "эСТРАДА"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"One"}, names(got))
+	})
+
+	t.Run("tenants are isolated", func(t *testing.T) {
+		_, taxonomy, ids := setup(t)
+		require.NoError(t, taxonomy.TagSongs(ctx, ids, []string{"rock"}))
+		rock := &models.Genre{Name: "Rock"}
+		require.NoError(t, taxonomy.CreateGenre(ctx, rock))
+
+		other := tenant.NewContext(context.Background(), "other")
+		assert.ErrorIs(t, taxonomy.TagSongs(other, ids, []string{"stolen"}), ErrSongNotFound)
+		require.NoError(t, taxonomy.UntagSongs(other, ids, []string{"rock"}))
+		assert.ErrorIs(t, taxonomy.DeleteGenre(other, rock.ID), gorm.ErrRecordNotFound)
+		require.NoError(t, taxonomy.CreateGenre(other, &models.Genre{Name: "Rock"}), "genre names are unique per tenant")
+
+		counts, err := taxonomy.TagCounts(other)
+		require.NoError(t, err)
+		assert.Empty(t, counts)
+		counts, err = taxonomy.TagCounts(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []models.TagCount{{Name: "rock", Count: 3}}, counts)
+	})
+
+	t.Run("requires a tenant", func(t *testing.T) {
+		_, taxonomy := newRepos(t)
+		_, err := taxonomy.TagCounts(context.Background())
+		assert.ErrorIs(t, err, ErrNoTenant)
+		assert.ErrorIs(t, taxonomy.CreateGenre(context.Background(), &models.Genre{Name: "Rock"}), ErrNoTenant)
+	})
+}
+
+func TestSQLTaxonomyRepository_SQLite(t *testing.T) {
+	testTaxonomyRepository(t, func(t *testing.T) (SongRepository, TaxonomyRepository) {
+		db := setupSQLiteDB(t)
+		return NewSQLSongRepository(db), NewSQLTaxonomyRepository(db)
+	})
+}
+
+func TestMemoryTaxonomyRepository(t *testing.T) {
+	testTaxonomyRepository(t, func(t *testing.T) (SongRepository, TaxonomyRepository) {
+		songs := NewMemorySongRepository()
+		return songs, NewMemoryTaxonomyRepository(songs)
+	})
+}
@@ -2,16 +2,34 @@ package services
 
 import (
 	"awesomeProject/logger"
+	"awesomeProject/metrics"
 	"awesomeProject/models"
+	"awesomeProject/tracing"
+	"context"
 	"encoding/json"
 	"fmt"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 	"net/http"
 	"net/url"
+	"time"
 )
 
+var tracer = tracing.Tracer("services")
+
+// StatusError reports a non-2xx answer from the music API, whose body is an
+// error page rather than song details.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("music API returned status %d", e.StatusCode)
+}
+
 type MusicAPIServiceInterface interface {
-	GetSongInfo(group, song string) (*models.SongDetail, error)
+	GetSongInfo(ctx context.Context, group, song string) (*models.SongDetail, error)
 }
 
 type MusicAPIService struct {
@@ -19,36 +37,80 @@ type MusicAPIService struct {
 	client  *http.Client
 }
 
-func NewMusicAPIService(baseURL string) *MusicAPIService {
+func NewMusicAPIService(baseURL string, timeout time.Duration) *MusicAPIService {
 	return &MusicAPIService{
 		baseURL: baseURL,
-		client:  &http.Client{},
+		// The otelhttp transport propagates the W3C trace context upstream.
+		client: &http.Client{
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+			Timeout:   timeout,
+		},
 	}
 }
 
-func (s *MusicAPIService) GetSongInfo(group, song string) (*models.SongDetail, error) {
-	logger.Debug("Fetching song info",
+func (s *MusicAPIService) GetSongInfo(ctx context.Context, group, song string) (_ *models.SongDetail, err error) {
+	ctx, span := tracer.Start(ctx, "MusicAPIService.GetSongInfo")
+	span.SetAttributes(attribute.String("song.group", group), attribute.String("song.name", song))
+	defer func() { tracing.End(span, err) }()
+
+	log := logger.FromContext(ctx)
+	log.Debug("Fetching song info",
 		zap.String("group", group),
 		zap.String("song", song))
 
-	resp, err := s.client.Get(fmt.Sprintf(
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf(
 		"%s/info?group=%s&song=%s",
 		s.baseURL,
 		url.QueryEscape(group),
 		url.QueryEscape(song),
-	))
+	), nil)
 	if err != nil {
-		logger.Info("Failed to fetch song info", zap.Error(err))
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	if err != nil {
+		log.Info("Failed to fetch song info", zap.Error(err))
+		metrics.ObserveMusicAPICall("transport_error", time.Since(start))
 		return nil, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		err := &StatusError{StatusCode: resp.StatusCode}
+		log.Info("Music API returned an error status", zap.Int("status", resp.StatusCode))
+		metrics.ObserveMusicAPICall("status_error", time.Since(start))
+		return nil, err
+	}
+
 	var details models.SongDetail
 	if err := json.NewDecoder(resp.Body).Decode(&details); err != nil {
-		logger.Info("Failed to decode response", zap.Error(err))
+		log.Info("Failed to decode response", zap.Error(err))
+		metrics.ObserveMusicAPICall("decode_error", time.Since(start))
 		return nil, err
 	}
 
-	logger.Debug("Successfully fetched song info")
+	metrics.ObserveMusicAPICall("success", time.Since(start))
+	log.Debug("Successfully fetched song info")
 	return &details, nil
 }
+
+// Ping checks that the external API answers HTTP requests at all.
+func (s *MusicAPIService) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "HEAD", s.baseURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("music API returned %s", resp.Status)
+	}
+	return nil
+}
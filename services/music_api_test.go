@@ -0,0 +1,66 @@
+package services
+
+import (
+	"awesomeProject/logger"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMusicAPIService_GetSongInfo_Tracing(t *testing.T) {
+	logger.Init()
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var traceparent string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.Write([]byte(`{"releaseDate":"16.07.2006","text":"Verse","link":"https://example.com"}`))
+	}))
+	defer upstream.Close()
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "request")
+	details, err := NewMusicAPIService(upstream.URL, time.Second).GetSongInfo(ctx, "Muse", "Uprising")
+	parent.End()
+
+	require.NoError(t, err)
+	assert.Equal(t, "16.07.2006", details.ReleaseDate)
+
+	traceID := parent.SpanContext().TraceID().String()
+	assert.Contains(t, traceparent, traceID, "trace context is propagated upstream")
+
+	var found bool
+	for _, span := range recorder.Ended() {
+		if span.Name() == "MusicAPIService.GetSongInfo" {
+			found = true
+			assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+		}
+	}
+	assert.True(t, found, "GetSongInfo span recorded")
+}
+
+func TestMusicAPIService_GetSongInfo_StatusError(t *testing.T) {
+	logger.Init()
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"text":"<html>Not Found</html>"}`))
+	}))
+	defer upstream.Close()
+
+	_, err := NewMusicAPIService(upstream.URL, time.Second).GetSongInfo(context.Background(), "Muse", "Unknown")
+
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+}
@@ -0,0 +1,43 @@
+// Package sqlitedb opens SQLite databases whose LOWER and UPPER fold all of
+// Unicode, as Postgres and strings.ToLower do, rather than ASCII only.
+package sqlitedb
+
+import (
+	"database/sql"
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"strings"
+)
+
+// DriverName is the database/sql driver registered by this package.
+const DriverName = "sqlite3_unicode"
+
+func init() {
+	sql.Register(DriverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			// Functions registered on a connection take precedence over the
+			// built-in ones of the same name.
+			if err := conn.RegisterFunc("lower", foldWith(strings.ToLower), true); err != nil {
+				return err
+			}
+			return conn.RegisterFunc("upper", foldWith(strings.ToUpper), true)
+		},
+	})
+}
+
+// Open returns a dialector for dsn, like sqlite.Open.
+func Open(dsn string) gorm.Dialector {
+	return sqlite.New(sqlite.Config{DriverName: DriverName, DSN: dsn})
+}
+
+// foldWith applies fold to text and passes other values, NULL included,
+// through unchanged.
+func foldWith(fold func(string) string) func(any) any {
+	return func(v any) any {
+		if s, ok := v.(string); ok {
+			return fold(s)
+		}
+		return v
+	}
+}
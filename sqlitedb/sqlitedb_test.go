@@ -0,0 +1,27 @@
+package sqlitedb
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"testing"
+)
+
+func TestOpen_UnicodeCaseFolding(t *testing.T) {
+	db, err := gorm.Open(Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	var row struct {
+		Lower, Upper string
+		Missing      *string
+		Number       int
+		Like         bool
+	}
+	require.NoError(t, db.Raw(`SELECT LOWER('КИНО Sigur RÓS') AS lower, UPPER('ärger') AS upper,
+		LOWER(NULL) AS missing, LOWER(42) AS number, LOWER('Группа') LIKE LOWER('%ГРУП%') AS "like"`).Scan(&row).Error)
+	assert.Equal(t, "кино sigur rós", row.Lower)
+	assert.Equal(t, "ÄRGER", row.Upper)
+	assert.Nil(t, row.Missing)
+	assert.Equal(t, 42, row.Number)
+	assert.True(t, row.Like)
+}
@@ -0,0 +1,32 @@
+package tenant
+
+import (
+	"context"
+	"regexp"
+)
+
+// Default is the tenant used for principals that are not bound to a tenant
+// and do not select one explicitly.
+const Default = "default"
+
+// MaxIDLength matches the size of the tenant_id columns.
+const MaxIDLength = 64
+
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ValidID reports whether id may name a tenant: 1 to MaxIDLength letters,
+// digits, underscores or hyphens.
+func ValidID(id string) bool {
+	return len(id) <= MaxIDLength && idPattern.MatchString(id)
+}
+
+type contextKey struct{}
+
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(contextKey{}).(string)
+	return id, ok && id != ""
+}
@@ -0,0 +1,55 @@
+// Package thumbnail scales cover images down to small JPEG previews.
+package thumbnail
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+)
+
+// MaxPixels bounds the decoded size of an image so a small, highly
+// compressed upload cannot exhaust memory.
+const MaxPixels = 50_000_000
+
+var ErrTooLarge = errors.New("image dimensions too large")
+
+// Generate writes a JPEG copy of the image in r scaled to fit within
+// size×size pixels, keeping its aspect ratio. Smaller images are not
+// enlarged.
+func Generate(r io.ReadSeeker, w io.Writer, size int) error {
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return fmt.Errorf("decode image: %w", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > MaxPixels {
+		return ErrTooLarge
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	src, _, err := image.Decode(r)
+	if err != nil {
+		return fmt.Errorf("decode image: %w", err)
+	}
+
+	width, height := fit(cfg.Width, cfg.Height, size)
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+	return jpeg.Encode(w, dst, &jpeg.Options{Quality: 85})
+}
+
+func fit(width, height, size int) (int, int) {
+	if width <= size && height <= size {
+		return width, height
+	}
+	if width >= height {
+		return size, max(1, height*size/width)
+	}
+	return max(1, width*size/height), size
+}
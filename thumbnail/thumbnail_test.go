@@ -0,0 +1,59 @@
+package thumbnail
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func encodePNG(t *testing.T, width, height int) *bytes.Reader {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		img.Set(x, 0, color.RGBA{R: 255, A: 255})
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestGenerate(t *testing.T) {
+	for _, tc := range []struct {
+		name                  string
+		width, height         int
+		wantWidth, wantHeight int
+	}{
+		{"landscape", 1200, 600, 300, 150},
+		{"portrait", 400, 800, 150, 300},
+		{"small images are kept", 100, 50, 100, 50},
+		{"thin images keep a pixel", 3000, 2, 300, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			require.NoError(t, Generate(encodePNG(t, tc.width, tc.height), &out, 300))
+
+			img, err := jpeg.Decode(&out)
+			require.NoError(t, err)
+			assert.Equal(t, image.Pt(tc.wantWidth, tc.wantHeight), img.Bounds().Size())
+		})
+	}
+}
+
+func TestGenerate_Rejects(t *testing.T) {
+	var out bytes.Buffer
+	assert.Error(t, Generate(bytes.NewReader([]byte("%PDF-1.7")), &out, 300))
+
+	// A PNG header claiming 100000×100000 pixels is refused before decoding.
+	header := encodePNG(t, 1, 1)
+	data := make([]byte, header.Len())
+	_, _ = header.Read(data)
+	copy(data[16:24], []byte{0, 1, 0x86, 0xa0, 0, 1, 0x86, 0xa0})
+	binary.BigEndian.PutUint32(data[29:33], crc32.ChecksumIEEE(data[12:29]))
+	assert.ErrorIs(t, Generate(bytes.NewReader(data), &out, 300), ErrTooLarge)
+}